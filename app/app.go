@@ -1,9 +1,11 @@
 package app
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	auth "github.com/AdityaSripal/plasma-mvp-sidechain/auth"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/eth"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
@@ -12,6 +14,7 @@ import (
 	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
 	abci "github.com/tendermint/tendermint/abci/types"
 	"io"
+	"time"
 
 	bam "github.com/cosmos/cosmos-sdk/baseapp"
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -28,6 +31,16 @@ import (
 
 const (
 	appName = "plasmaChildChain"
+
+	// Time the rootchain contract waits before finalizing an exit, during which it can be
+	// challenged. Spends are kept in the history this long
+	ExitChallengePeriod = 7 * 24 * time.Hour
+)
+
+var (
+	// plasma store keys of the genesis validator and of the block number offset of a restarted chain
+	genesisValidatorKey = []byte("genesis validator")
+	blockOffsetKey      = []byte("block offset")
 )
 
 // Extended ABCI application
@@ -38,9 +51,14 @@ type ChildChain struct {
 
 	txIndex uint16
 
+	// Output index of the last output created by the current spend
+	oIndex uint8
+
 	// keys to access the substores
 	capKeyMainStore *sdk.KVStoreKey
 
+	capKeyUTXOIndexStore *sdk.KVStoreKey
+
 	capKeyPlasmaStore *sdk.KVStoreKey
 
 	// Manage addition and deletion of utxo's
@@ -54,6 +72,19 @@ type ChildChain struct {
 	// Address that validator uses to collect fees
 	validatorAddress ethcmn.Address
 
+	// Fees of the spends delivered in the current block
+	feeAmount uint64
+
+	// Minimum fee for a spend to be admitted into the mempool
+	minimumFee uint64
+
+	// Maximum number of inputs and outputs of a spend admitted into the mempool. Unbounded if 0
+	maxInputs  int
+	maxOutputs int
+
+	// Bounds the mempool by fee rate and limits invalid spends. Unbounded if nil
+	mempool *auth.Mempool
+
 	// Private key for submitting blocks to rootchain
 	validatorPrivKey *ecdsa.PrivateKey
 
@@ -63,10 +94,41 @@ type ChildChain struct {
 	// NodeURL for connecting to ethereum client
 	nodeURL string
 
+	// Connection to the rootchain used instead of dialing `nodeURL` if set
+	ethClient *eth.Client
+
 	// Number of blocks required for a submitted block to be considered final
 	blockFinality uint64
 
-	ethConnection *eth.Plasma
+	// Rootchain validated against. Nil when running without one
+	ethConnection eth.RootChain
+
+	// Set for read-only tooling that runs without a rootchain
+	noRootChain bool
+
+	// Location of the rootchain event cache. Kept in memory if empty
+	eventDB string
+
+	// Submits committed blocks to the rootchain. Only set for validators
+	submitter *eth.Submitter
+
+	// Location of the block submission records. Empty if blocks are not submitted
+	submissionDB string
+
+	// Determines how blocks are batched into rootchain transactions
+	batchPolicy eth.BatchPolicy
+
+	// Determines how validator transactions to the rootchain are priced and replaced
+	txConfig eth.TxConfig
+
+	// Keeps the spends pruned from the history. Nil if they are not archived
+	archive *spendArchive
+
+	// Location of the spend archive. Kept in memory if empty
+	archiveDB string
+
+	// Duration spends are archived for, counted from the time they were spent
+	archiveRetention time.Duration
 }
 
 func NewChildChain(logger log.Logger, db dbm.DB, traceStore io.Writer, options ...func(*ChildChain)) *ChildChain {
@@ -76,20 +138,31 @@ func NewChildChain(logger log.Logger, db dbm.DB, traceStore io.Writer, options .
 	bapp.SetCommitMultiStoreTracer(traceStore)
 
 	var app = &ChildChain{
-		BaseApp:           bapp,
-		cdc:               cdc,
-		txIndex:           0,
-		capKeyMainStore:   sdk.NewKVStoreKey("main"),
-		capKeyPlasmaStore: sdk.NewKVStoreKey("plasma"),
+		BaseApp:              bapp,
+		cdc:                  cdc,
+		txIndex:              0,
+		capKeyMainStore:      sdk.NewKVStoreKey("main"),
+		capKeyUTXOIndexStore: sdk.NewKVStoreKey("utxo_index"),
+		capKeyPlasmaStore:    sdk.NewKVStoreKey("plasma"),
+		txConfig:             eth.DefaultTxConfig(),
 	}
 
 	for _, option := range options {
 		option(app)
 	}
 
+	if app.archiveRetention > 0 {
+		var err error
+		app.archive, err = newSpendArchive(app.archiveDB, app.archiveRetention, cdc)
+		if err != nil {
+			cmn.Exit(err.Error())
+		}
+	}
+
 	// define the utxoMapper
 	app.utxoMapper = utxo.NewBaseMapper(
-		app.capKeyMainStore, // target store
+		app.capKeyMainStore,      // target store
+		app.capKeyUTXOIndexStore, // position index, supply and history
 		cdc,
 	)
 
@@ -98,40 +171,99 @@ func NewChildChain(logger log.Logger, db dbm.DB, traceStore io.Writer, options .
 	)
 
 	app.Router().
-		AddRoute("spend", utxo.NewSpendHandler(app.utxoMapper, app.nextPosition))
+		AddRoute("spend", app.collectFee(app.recordTx(utxo.NewSpendHandler(app.utxoMapper, app.nextPosition)))).
+		AddRoute("confirm", app.confirmSigHandler).
+		AddRoute("deposit", app.depositHandler).
+		AddRoute("exit", app.exitHandler)
+
+	app.QueryRouter().
+		AddRoute(QueryRoute, app.querier)
 
 	app.MountStoresIAVL(app.capKeyMainStore)
+	app.MountStoresIAVL(app.capKeyUTXOIndexStore)
 	app.MountStoresIAVL(app.capKeyPlasmaStore)
 
 	app.SetInitChainer(app.initChainer)
 	app.SetEndBlocker(app.endBlocker)
 
-	// Set Ethereum connection
-	client, err := eth.InitEthConn(app.nodeURL, bapp.Logger)
+	// Set Ethereum connection unless a rootchain was provided or none is used
+	if app.ethConnection == nil && !app.noRootChain {
+		app.connectRootChain()
+	}
+
+	// NOTE: type AnteHandler func(ctx Context, tx Tx) (newCtx Context, result Result, abort bool)
+	anteHandler := auth.NewAnteHandler(app.utxoMapper, app.plasmaStore, app.ethConnection, app.minimumFee, app.maxInputs, app.maxOutputs)
+	if app.mempool != nil {
+		anteHandler = auth.NewMempoolAnteHandler(anteHandler, app.utxoMapper, app.plasmaStore, app.mempool)
+	}
+	app.SetAnteHandler(anteHandler)
+
+	err := app.LoadLatestVersion(app.capKeyMainStore)
+	if err != nil {
+		cmn.Exit(err.Error())
+	}
+	err = app.LoadLatestVersion(app.capKeyUTXOIndexStore)
+	if err != nil {
+		cmn.Exit(err.Error())
+	}
+	err = app.LoadLatestVersion(app.capKeyPlasmaStore)
+	if err != nil {
+		cmn.Exit(err.Error())
+	}
+
+	return app
+}
+
+// connects to the rootchain contract of the eth config. Validators submit committed blocks
+// to it if block submission is enabled
+func (app *ChildChain) connectRootChain() {
+	client := app.ethClient
+	if client == nil {
+		var err error
+		client, err = eth.InitEthConn(app.nodeURL, app.BaseApp.Logger)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	plasmaClient, err := eth.InitPlasma(app.rootchain, app.validatorPrivKey, client, app.BaseApp.Logger, app.blockFinality, app.eventDB)
 	if err != nil {
 		panic(err)
 	}
 
-	plasmaClient, err := eth.InitPlasma(app.rootchain, app.validatorPrivKey, client, app.BaseApp.Logger, app.blockFinality)
+	err = plasmaClient.SetTxConfig(app.txConfig)
 	if err != nil {
 		panic(err)
 	}
 
 	app.ethConnection = plasmaClient
 
-	// NOTE: type AnteHandler func(ctx Context, tx Tx) (newCtx Context, result Result, abort bool)
-	app.SetAnteHandler(auth.NewAnteHandler(app.utxoMapper, app.plasmaStore, app.ethConnection))
+	if app.isValidator && app.submissionDB != "" {
+		app.submitter, err = eth.NewSubmitter(plasmaClient, app.submissionDB, app.batchPolicy, app.BaseApp.Logger)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
 
-	err = app.LoadLatestVersion(app.capKeyMainStore)
-	if err != nil {
-		cmn.Exit(err.Error())
+// Stop halts block submission and the validator's rootchain transactions. Submission
+// records and the spend archive are closed so that a restarted node resumes from them
+func (app *ChildChain) Stop() {
+	if app.submitter != nil {
+		if err := app.submitter.Stop(); err != nil {
+			app.Logger.Error(fmt.Sprintf("Could not close the block submission records - %s", err))
+		}
 	}
-	err = app.LoadLatestVersion(app.capKeyPlasmaStore)
-	if err != nil {
-		cmn.Exit(err.Error())
+
+	if plasma, ok := app.ethConnection.(*eth.Plasma); ok {
+		plasma.Stop()
 	}
 
-	return app
+	if app.archive != nil {
+		if err := app.archive.close(); err != nil {
+			app.Logger.Error(fmt.Sprintf("Could not close the spend archive - %s", err))
+		}
+	}
 }
 
 func (app *ChildChain) initChainer(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
@@ -145,14 +277,59 @@ func (app *ChildChain) initChainer(ctx sdk.Context, req abci.RequestInitChain) a
 		// return sdk.ErrGenesisParse("").TraceCause(err, "")
 	}
 
-	// load the accounts
+	// load the accounts. Spent UTXOs are moved to the history as they were spent
 	for _, gutxo := range genesisState.UTXOs {
-		utxo := ToUTXO(gutxo)
-		app.utxoMapper.ReceiveUTXO(ctx, utxo)
+		if !gutxo.Spent {
+			app.utxoMapper.ReceiveUTXO(ctx, ToUTXO(gutxo))
+			continue
+		}
+
+		spent := ToSpentUTXO(gutxo)
+		spent.UTXO.Valid = true
+		app.utxoMapper.ReceiveUTXO(ctx, spent.UTXO)
+
+		header := ctx.BlockHeader()
+		header.Time = spent.SpentAt
+		if err := app.utxoMapper.SpendUTXO(ctx.WithBlockHeader(header), spent.UTXO.Address, spent.UTXO.Position, spent.Spender); err != nil {
+			panic(err)
+		}
 	}
 
+	// restore the plasma blocks of an exported chain
+	for _, pair := range genesisState.PlasmaStore {
+		app.plasmaStore.Set(ctx, pair.Key, pair.Value)
+	}
+	if genesisState.BlockOffset != 0 {
+		offset := make([]byte, 8)
+		binary.BigEndian.PutUint64(offset, genesisState.BlockOffset)
+		app.plasmaStore.Set(ctx, blockOffsetKey, offset)
+	}
+
+	validator, err := app.cdc.MarshalJSON(genesisState.Validator)
+	if err != nil {
+		panic(err)
+	}
+	app.plasmaStore.Set(ctx, genesisValidatorKey, validator)
+
+	version := types.SignVersionDomain
+	if genesisState.LegacySignatures {
+		version = types.SignVersionLegacy
+	}
+	domain := types.NewSignDomain(version, req.ChainId, app.rootchain)
+	if err := domain.ValidateBasic(); err != nil {
+		panic(err)
+	}
+	app.plasmaStore.Set(ctx, utils.SignDomainKey, domain.Bytes())
+
 	app.validatorAddress = ethcmn.HexToAddress(genesisState.Validator.Address)
 
+	if operator := genesisState.Validator.Operator; operator != "" {
+		if !ethcmn.IsHexAddress(operator) {
+			panic(fmt.Sprintf("invalid operator address: %s", operator))
+		}
+		app.plasmaStore.Set(ctx, utils.OperatorKey, ethcmn.HexToAddress(operator).Bytes())
+	}
+
 	// load the initial stake information
 	return abci.ResponseInitChain{Validators: []abci.ValidatorUpdate{abci.ValidatorUpdate{
 		PubKey: tmtypes.TM2PB.PubKey(genesisState.Validator.ConsPubKey),
@@ -161,37 +338,238 @@ func (app *ChildChain) initChainer(ctx sdk.Context, req abci.RequestInitChain) a
 }
 
 func (app *ChildChain) endBlocker(ctx sdk.Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+	// the plasma block consists of the spends in this block. The root is computed
+	// here, rather than taken from the header, to match the rootchain's merkle tree
+	blknum := app.blockNumber(ctx)
+
+	// the fees of the block are collected into a single output for the validator
+	if app.feeAmount > 0 {
+		feeAddress := app.feeAddress(ctx)
+		if utils.ValidAddress(feeAddress) {
+			app.utxoMapper.ReceiveUTXO(ctx, utxo.NewUTXO(feeAddress.Bytes(), app.feeAmount, types.Denom, types.NewFeePosition(blknum)))
+		} else {
+			app.Logger.Error(fmt.Sprintf("No fee address to collect the fees of block %d", blknum))
+		}
+	}
+
 	// reset txIndex and fee
 	app.txIndex = 0
+	app.feeAmount = 0
+
+	// spends are dropped from the history once exits of their outputs can no longer be challenged.
+	// Block times are agreed on by the validators so every node prunes the same entries. Nodes
+	// archiving spends keep them outside of the state for their own retention
+	pruned := app.utxoMapper.PruneSpentUTXOs(ctx, ctx.BlockHeader().Time.Add(-ExitChallengePeriod))
+	if app.archive != nil {
+		if err := app.archive.add(pruned, ctx.BlockHeader().Time); err != nil {
+			app.Logger.Error(fmt.Sprintf("Could not archive the spends pruned at block %d - %s", blknum, err))
+		}
+	}
 
-	blknumKey := make([]byte, binary.MaxVarintLen64)
-	binary.PutUvarint(blknumKey, uint64(ctx.BlockHeight()))
-	key := append(utils.RootHashPrefix, blknumKey...)
-	if ctx.BlockHeader().DataHash != nil {
-		app.plasmaStore.Set(ctx, key, ctx.BlockHeader().DataHash)
+	txs := app.blockTxs(ctx, blknum)
+
+	var root [32]byte
+	if len(txs) > 0 {
+		copy(root[:], utils.MerkleRoot(txs))
+		app.plasmaStore.Set(ctx, utils.RootHashKey(blknum), root[:])
+	}
+
+	// every block is submitted so that rootchain block numbers match plasma block numbers
+	if app.submitter != nil {
+		// blocks committed while the records were unavailable are recovered from the store
+		for _, missing := range app.submitter.MissingBlocks() {
+			var missingRoot [32]byte
+			copy(missingRoot[:], app.plasmaStore.Get(ctx, utils.RootHashKey(missing)))
+			if err := app.submitter.Enqueue(missing, missingRoot, uint64(len(app.blockTxs(ctx, missing)))); err != nil {
+				app.Logger.Error(fmt.Sprintf("Could not record block %d for submission - %s", missing, err))
+			}
+		}
+
+		err := app.submitter.Enqueue(blknum, root, uint64(len(txs)))
+		if err != nil {
+			app.Logger.Error(fmt.Sprintf("Could not record block %d for submission - %s", blknum, err))
+		}
 	}
+
 	return abci.ResponseEndBlock{}
 }
 
-// RLP decodes the txBytes to a BaseTx
+// RLP decodes the txBytes to a BaseTx or, failing that, a ConfirmSigTx, DepositTx or ExitTx
 func txDecoder(txBytes []byte) (sdk.Tx, sdk.Error) {
 	var tx = types.BaseTx{}
 
 	err := rlp.DecodeBytes(txBytes, &tx)
-	if err != nil {
-		return nil, sdk.ErrTxDecode(err.Error())
+	if err == nil {
+		return tx, nil
+	}
+
+	// rlp decoding is strict on the number of fields so the forms cannot be confused
+	var multiSpendTx = types.MultiSpendTx{}
+	if rlp.DecodeBytes(txBytes, &multiSpendTx) == nil {
+		return multiSpendTx, nil
+	}
+
+	var confirmSigTx = types.ConfirmSigTx{}
+	if rlp.DecodeBytes(txBytes, &confirmSigTx) == nil {
+		return confirmSigTx, nil
+	}
+
+	var depositTx = types.DepositTx{}
+	if rlp.DecodeBytes(txBytes, &depositTx) == nil {
+		return depositTx, nil
+	}
+
+	var exitTx = types.ExitTx{}
+	if rlp.DecodeBytes(txBytes, &exitTx) == nil {
+		return exitTx, nil
+	}
+
+	return nil, sdk.ErrTxDecode(err.Error())
+}
+
+// Records the bytes of every successful spend at its position so that
+// confirmation signatures can later be verified against it. Each input is
+// indexed to the spend so challenges are found without scanning the blocks
+func (app *ChildChain) recordTx(handler sdk.Handler) sdk.Handler {
+	return func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		res := handler(ctx, msg)
+		if !res.IsOK() {
+			return res
+		}
+
+		// the spend handler advanced txIndex past this transaction
+		blknum, txindex := app.blockNumber(ctx), app.txIndex-1
+		app.plasmaStore.Set(ctx, utils.TxBytesKey(blknum, txindex), ctx.TxBytes())
+
+		if spend, ok := msg.(types.Spend); ok {
+			for _, input := range spend.Inputs() {
+				position, ok := input.Position.(types.PlasmaPosition)
+				if !ok {
+					continue
+				}
+				key := utils.SpendKey(position.Blknum, position.TxIndex, position.Oindex, position.DepositNum)
+				app.plasmaStore.Set(ctx, key, utils.SpenderValue(blknum, txindex))
+			}
+		}
+		return res
+	}
+}
+
+// Adds the fee of every successful spend to the fees of the block. The
+// transaction index of the fee output cannot be taken by a spend
+func (app *ChildChain) collectFee(handler sdk.Handler) sdk.Handler {
+	return func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		spend, ok := msg.(types.Spend)
+		if !ok {
+			return sdk.ErrInternal("msg must be a spend").Result()
+		}
+		if app.txIndex >= types.FeeTxIndex {
+			return types.ErrInvalidTransaction(types.DefaultCodespace, "block is full").Result()
+		}
+
+		// the fee of a legacy spend is derived from its inputs, which the handler spends
+		fee := auth.SpendFee(ctx, app.utxoMapper, spend)
+		res := handler(ctx, msg)
+		if res.IsOK() {
+			app.feeAmount += fee
+		}
+		return res
+	}
+}
+
+// Address collecting the fees of every block, as set for the genesis validator
+func (app *ChildChain) feeAddress(ctx sdk.Context) ethcmn.Address {
+	var validator GenesisValidator
+	bz := app.plasmaStore.Get(ctx, genesisValidatorKey)
+	if bz == nil || app.cdc.UnmarshalJSON(bz, &validator) != nil {
+		return ethcmn.Address{}
 	}
-	return tx, nil
+	return ethcmn.HexToAddress(validator.Address)
+}
+
+// Returns the bytes of every spend in block `blknum` ordered by transaction index
+func (app *ChildChain) blockTxs(ctx sdk.Context, blknum uint64) [][]byte {
+	var txs [][]byte
+
+	iter := app.plasmaStore.PrefixIterator(ctx, utils.BlockTxsPrefix(blknum))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		txs = append(txs, iter.Value())
+	}
+
+	return txs
+}
+
+// Stores confirmation signatures verified by the ante handler in the form expected by the rootchain contract
+func (app *ChildChain) confirmSigHandler(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+	confirmSigMsg, ok := msg.(types.ConfirmSigMsg)
+	if !ok {
+		return sdk.ErrInternal("msg must be of type ConfirmSigMsg").Result()
+	}
+
+	app.plasmaStore.Set(ctx, utils.ConfirmSigKey(confirmSigMsg.Blknum, confirmSigMsg.Txindex), confirmSigMsg.ConfirmSigs())
+	return sdk.Result{}
+}
+
+// Creates the output of a deposit admitted by the ante handler and marks the deposit as included
+func (app *ChildChain) depositHandler(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+	depositMsg, ok := msg.(types.DepositMsg)
+	if !ok {
+		return sdk.ErrInternal("msg must be of type DepositMsg").Result()
+	}
+
+	output := utxo.NewUTXO(depositMsg.Owner.Bytes(), depositMsg.Amount, types.Denom, depositMsg.Position())
+	app.utxoMapper.ReceiveUTXO(ctx, output)
+	app.plasmaStore.Set(ctx, utils.DepositKey(depositMsg.DepositNum), depositMsg.Owner.Bytes())
+	return sdk.Result{}
+}
+
+// Marks the output of an exit admitted by the ante handler as exited, or restores it if the exit was challenged
+func (app *ChildChain) exitHandler(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+	exitMsg, ok := msg.(types.ExitMsg)
+	if !ok {
+		return sdk.ErrInternal("msg must be of type ExitMsg").Result()
+	}
+
+	position := exitMsg.Position()
+	output := app.utxoMapper.GetUTXO(ctx, exitMsg.Owner.Bytes(), &position)
+	if exitMsg.State == types.ExitChallenged {
+		if err := app.utxoMapper.ValidateUTXO(ctx, output); err != nil {
+			return err.Result()
+		}
+		return sdk.Result{}
+	}
+
+	output.Exited = true
+	app.utxoMapper.InvalidateUTXO(ctx, output)
+	return sdk.Result{}
 }
 
 // Return the next output position given ctx
 // and secondary flag which indicates if it is for secondary outputs from single tx.
+// Secondary outputs take the following output indices
 func (app *ChildChain) nextPosition(ctx sdk.Context, secondary bool) utxo.Position {
 	if !secondary {
 		app.txIndex++
-		return types.NewPlasmaPosition(uint64(ctx.BlockHeight()), app.txIndex-1, 0, 0)
+		app.oIndex = 0
+		return types.NewPlasmaPosition(app.blockNumber(ctx), app.txIndex-1, 0, 0)
 	}
-	return types.NewPlasmaPosition(uint64(ctx.BlockHeight()), app.txIndex-1, 1, 0)
+	app.oIndex++
+	return types.NewPlasmaPosition(app.blockNumber(ctx), app.txIndex-1, app.oIndex, 0)
+}
+
+// Plasma block number of the block in ctx. Heights restart when a chain is restarted
+// from an exported state while plasma block numbers continue from the export
+func (app *ChildChain) blockNumber(ctx sdk.Context) uint64 {
+	return app.blockOffset(ctx) + uint64(ctx.BlockHeight())
+}
+
+func (app *ChildChain) blockOffset(ctx sdk.Context) uint64 {
+	offset := app.plasmaStore.Get(ctx, blockOffsetKey)
+	if offset == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(offset)
 }
 
 func MakeCodec() *amino.Codec {
@@ -204,11 +582,48 @@ func MakeCodec() *amino.Codec {
 	return cdc
 }
 
+// ExportAppStateJSON exports the committed state in the genesis format. Spent UTXOs and the plasma
+// store are included so that a chain restarted from the export continues the plasma chain
 func (app *ChildChain) ExportAppStateJSON() (appState json.RawMessage, validators []tmtypes.GenesisValidator, err error) {
-	// TODO: Implement
-	// Currently non-functional, just enough to compile
-	tx := types.BaseTx{}
-	appState, err = app.cdc.MarshalJSONIndent(tx, "", "\t")
-	validators = []tmtypes.GenesisValidator{}
+	ctx := app.NewContext(true, abci.Header{})
+
+	var genesisState GenesisState
+	if bz := app.plasmaStore.Get(ctx, genesisValidatorKey); bz != nil {
+		if err = app.cdc.UnmarshalJSON(bz, &genesisState.Validator); err != nil {
+			return nil, nil, err
+		}
+		validators = []tmtypes.GenesisValidator{tmtypes.GenesisValidator{
+			PubKey: genesisState.Validator.ConsPubKey,
+			Power:  1,
+		}}
+	}
+
+	genesisState.BlockOffset = app.blockOffset(ctx) + uint64(app.LastBlockHeight())
+	genesisState.LegacySignatures = auth.SignDomain(ctx, app.plasmaStore).Version == types.SignVersionLegacy
+
+	// spent UTXOs are exported from the history along with their spenders
+	app.utxoMapper.IterateUTXOs(ctx, utxo.AllUTXOs, func(output utxo.UTXO) bool {
+		if output.Valid || output.Exited {
+			genesisState.UTXOs = append(genesisState.UTXOs, FromUTXO(output))
+		}
+		return false
+	})
+	app.utxoMapper.IterateSpentUTXOs(ctx, func(spent utxo.SpentUTXO) bool {
+		genesisState.UTXOs = append(genesisState.UTXOs, FromSpentUTXO(spent))
+		return false
+	})
+
+	pairs := app.plasmaStore.PrefixIterator(ctx, nil)
+	defer pairs.Close()
+	for ; pairs.Valid(); pairs.Next() {
+		// part of the genesis state itself
+		if bytes.Equal(pairs.Key(), genesisValidatorKey) || bytes.Equal(pairs.Key(), blockOffsetKey) || bytes.Equal(pairs.Key(), utils.SignDomainKey) ||
+			bytes.Equal(pairs.Key(), utils.OperatorKey) {
+			continue
+		}
+		genesisState.PlasmaStore = append(genesisState.PlasmaStore, GenesisKVPair{pairs.Key(), pairs.Value()})
+	}
+
+	appState, err = app.cdc.MarshalJSONIndent(genesisState, "", "\t")
 	return appState, validators, err
 }
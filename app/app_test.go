@@ -18,6 +18,8 @@ import (
 	dbm "github.com/tendermint/tendermint/libs/db"
 	"github.com/tendermint/tendermint/libs/log"
 
+	"github.com/AdityaSripal/plasma-mvp-sidechain/auth"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/eth"
 	types "github.com/AdityaSripal/plasma-mvp-sidechain/types"
 	utils "github.com/AdityaSripal/plasma-mvp-sidechain/utils"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
@@ -28,14 +30,11 @@ const (
 	privkey            = "9cd69f009ac86203e54ec50e3686de95ff6126d3b30a19f926a0fe9323c17181"
 	nodeURL            = "ws://127.0.0.1:8545"
 	plasmaContractAddr = "5cae340fb2c2bb0a2f194a95cda8a1ffdc9d2f85"
+	testChainID        = "plasma"
 )
 
-/* Note: Since the headers only contain information about the height
- *       of a block, the updated header is not being set in the context
- *		 and therefore the block hash set in end blocker will be nil.
- *	 	 This is only true for these tests, it works as expected when
- *		 you run a full node via plasmad.
- */
+// domain spends are signed under on the last chain started by InitTestChain
+var testDomain = types.NewSignDomain(types.SignVersionDomain, testChainID, common.HexToAddress(plasmaContractAddr))
 
 func newChildChain() *ChildChain {
 	logger := log.NewTMLogger(log.NewSyncWriter(os.Stdout)).With("module", "sdk/app")
@@ -43,7 +42,9 @@ func newChildChain() *ChildChain {
 	privkeyFile, _ := ioutil.TempFile("", "privateKey")
 	privkeyFile.Write([]byte(privkey))
 	defer os.Remove(privkeyFile.Name())
-	return NewChildChain(logger, db, nil, SetEthConfig(true, privkeyFile.Name(), plasmaContractAddr, nodeURL, "0"))
+	return NewChildChain(logger, db, nil,
+		SetEthConfig(true, privkeyFile.Name(), plasmaContractAddr, nodeURL, "0"),
+		SetRootChain(eth.NewFakeRootChain()))
 }
 
 // Adds a initial utxo at the specified position
@@ -78,9 +79,11 @@ func InitTestChain(cc *ChildChain, valAddr common.Address, addrs ...common.Addre
 
 	pubKey := secp256k1.GenPrivKey().PubKey()
 
+	operatorKey, _ := ethcrypto.HexToECDSA(privkey)
 	genValidator := GenesisValidator{
 		ConsPubKey: pubKey,
 		Address:    valAddr.String(),
+		Operator:   utils.PrivKeyToAddress(operatorKey).Hex(),
 	}
 
 	genState := GenesisState{
@@ -93,8 +96,11 @@ func InitTestChain(cc *ChildChain, valAddr common.Address, addrs ...common.Addre
 		panic(err)
 	}
 
-	initRequest := abci.RequestInitChain{AppStateBytes: appStateBytes}
+	initRequest := abci.RequestInitChain{ChainId: testChainID, AppStateBytes: appStateBytes}
 	cc.InitChain(initRequest)
+
+	// simulated chains are deployed to a different rootchain address
+	testDomain = types.NewSignDomain(types.SignVersionDomain, testChainID, cc.rootchain)
 }
 
 func GenerateSimpleMsg(Owner0, NewOwner0 common.Address, position [4]uint64, amount0 uint64) types.SpendMsg {
@@ -127,8 +133,7 @@ func getInputKeys(mapper utxo.Mapper, inputs ...Input) (res [][]byte) {
 
 // helper for constructing single or double input tx
 func GetTx(msg types.SpendMsg, privKeyA, privKeyB *ecdsa.PrivateKey, two_sigs bool) (tx types.BaseTx) {
-	hash := ethcrypto.Keccak256(msg.GetSignBytes())
-	signHash := utils.SignHash(hash)
+	signHash := utils.SignHash(testDomain.SignHash(msg.GetSignBytes()))
 	var sigs [2][65]byte
 	sig, _ := ethcrypto.Sign(signHash, privKeyA)
 	copy(sigs[0][:], sig)
@@ -142,6 +147,24 @@ func GetTx(msg types.SpendMsg, privKeyA, privKeyB *ecdsa.PrivateKey, two_sigs bo
 	return tx
 }
 
+// signs the sign bytes of a deposit or exit as the operator of the chains started by InitTestChain
+func operatorSig(signBytes []byte) (sig [65]byte) {
+	operatorKey, _ := ethcrypto.HexToECDSA(privkey)
+	bz, _ := ethcrypto.Sign(utils.SignHash(testDomain.SignHash(signBytes)), operatorKey)
+	copy(sig[:], bz)
+	return sig
+}
+
+// deposit transaction signed by the operator
+func signedDepositTx(msg types.DepositMsg) types.DepositTx {
+	return types.NewDepositTx(msg, operatorSig(msg.GetSignBytes()))
+}
+
+// exit transaction signed by the operator
+func signedExitTx(msg types.ExitMsg) types.ExitTx {
+	return types.NewExitTx(msg, operatorSig(msg.GetSignBytes()))
+}
+
 // Attempts to spend a non-existent utxo
 // without depositing first.
 func TestBadSpendMsg(t *testing.T) {
@@ -470,3 +493,262 @@ func TestMultiTxBlocks(t *testing.T) {
 	}
 
 }
+
+// Tests that spends are recorded and their confirmation signatures stored
+func TestConfirmSigTx(t *testing.T) {
+	cc := newChildChain()
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	privKeyB, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	addrB := utils.PrivKeyToAddress(privKeyB)
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+	cc.Commit()
+
+	position := types.NewPlasmaPosition(1, 0, 0, 0)
+	storeInitUTXO(cc, position, addrA)
+	cc.Commit()
+
+	msg := GenerateSimpleMsg(addrA, addrB, [4]uint64{1, 0, 0, 0}, 100)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 5}})
+	dres := cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+
+	ctx := cc.NewContext(false, abci.Header{})
+	require.Equal(t, txBytes, cc.plasmaStore.Get(ctx, utils.TxBytesKey(5, 0)), "spend not recorded")
+
+	// the root is otherwise set once the block ends
+	root := []byte("merkle root")
+	cc.plasmaStore.Set(ctx, utils.RootHashKey(5), root)
+
+	sig, _ := ethcrypto.Sign(utils.SignHash(utils.ConfirmationHash(txBytes, root)), privKeyA)
+	var sigs [2][65]byte
+	copy(sigs[0][:], sig)
+	confirmSigBytes, _ := rlp.EncodeToBytes(types.NewConfirmSigTx(types.NewConfirmSigMsg(5, 0, sigs)))
+
+	dres = cc.DeliverTx(confirmSigBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+
+	require.Equal(t, sig, cc.plasmaStore.Get(ctx, utils.ConfirmSigKey(5, 0)), "confirmation signature not stored")
+}
+
+// Tests that deposits and exits are checked against the rootchain before being admitted
+func TestRootChainChecks(t *testing.T) {
+	cc := newChildChain()
+	rootchain := cc.ethConnection.(*eth.FakeRootChain)
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+	cc.Commit()
+
+	// deposits must exist on the rootchain
+	depositBytes, _ := rlp.EncodeToBytes(signedDepositTx(types.NewDepositMsg(5, addrA, 100, 1)))
+	cres := cc.CheckTx(depositBytes)
+	require.NotEqual(t, sdk.CodeType(0), sdk.CodeType(cres.Code), "admitted a deposit missing from the rootchain")
+
+	events := make(chan eth.Event, 1)
+	sub := rootchain.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	rootchain.Deposit(5, addrA, 100)
+	require.Equal(t, eth.EventDeposit, (<-events).Type)
+
+	cres = cc.CheckTx(depositBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(cres.Code), cres.Log)
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	dres := cc.DeliverTx(depositBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc.Commit()
+
+	// exits must have started on the rootchain
+	position := types.NewPlasmaPosition(0, 0, 0, 5)
+	exitBytes, _ := rlp.EncodeToBytes(signedExitTx(types.NewExitMsg(position, addrA, types.ExitStarted)))
+	cres = cc.CheckTx(exitBytes)
+	require.NotEqual(t, sdk.CodeType(0), sdk.CodeType(cres.Code), "admitted an exit missing from the rootchain")
+
+	rootchain.StartExit([4]uint64{0, 0, 0, 5})
+	require.Equal(t, eth.EventExitStarted, (<-events).Type)
+
+	cres = cc.CheckTx(exitBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(cres.Code), cres.Log)
+
+	// spends of the exited deposit are refused
+	msg := GenerateSimpleMsg(addrA, utils.GenerateAddress(), [4]uint64{0, 0, 0, 5}, 100)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+	cres = cc.CheckTx(txBytes)
+	require.Equal(t, sdk.CodeType(204), sdk.CodeType(cres.Code), cres.Log)
+}
+
+// Tests that the fees of a block are minted to the fee address and can be spent
+func TestFees(t *testing.T) {
+	cc := newChildChain()
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	privKeyFee, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	feeAddr := utils.PrivKeyToAddress(privKeyFee)
+
+	InitTestChain(cc, feeAddr, addrA)
+	cc.Commit()
+
+	// the inputs leave 10 unspent as the fee
+	msg := GenerateSimpleMsg(addrA, addrA, [4]uint64{0, 0, 0, 1}, 90)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	dres := cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc.Commit()
+
+	ctx := cc.NewContext(false, abci.Header{})
+	position := types.NewFeePosition(2)
+	require.Equal(t, types.NewPlasmaPosition(2, 65535, 0, 0), position)
+	require.True(t, position.IsFee())
+	require.False(t, types.NewPlasmaPosition(2, 0, 0, 0).IsFee())
+	expected := utxo.NewUTXO(feeAddr.Bytes(), 10, "Ether", position)
+	require.Equal(t, expected, cc.utxoMapper.GetUTXO(ctx, feeAddr.Bytes(), position), "fees not collected")
+
+	// the fee output is only exitable once spent into a transaction output
+	msg = GenerateSimpleMsg(feeAddr, addrA, [4]uint64{2, 65535, 0, 0}, 10)
+	txBytes, _ = rlp.EncodeToBytes(GetTx(msg, privKeyFee, nil, false))
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 3}})
+	dres = cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 3})
+	cc.Commit()
+
+	ctx = cc.NewContext(false, abci.Header{})
+	require.False(t, cc.utxoMapper.GetUTXO(ctx, feeAddr.Bytes(), position).Valid, "fee output not spent")
+	require.True(t, cc.utxoMapper.GetUTXO(ctx, addrA.Bytes(), types.NewPlasmaPosition(3, 0, 0, 0)).Valid)
+
+	// blocks without fees do not mint an output
+	require.Equal(t, utxo.UTXO{}, cc.utxoMapper.GetUTXO(ctx, feeAddr.Bytes(), types.NewFeePosition(3)))
+
+	// fees move Ether to the validator without changing its supply
+	require.Equal(t, map[string]uint64{types.Denom: 100}, cc.utxoMapper.TotalSupply(ctx))
+	require.NoError(t, utxo.CheckSupply(ctx, cc.utxoMapper))
+}
+
+// Tests that spends with more than two inputs and outputs are delivered
+func TestMultiSpendTx(t *testing.T) {
+	cc := newChildChain()
+
+	var keys [3]*ecdsa.PrivateKey
+	var addrs []common.Address
+	for i := range keys {
+		keys[i], _ = ethcrypto.GenerateKey()
+		addrs = append(addrs, utils.PrivKeyToAddress(keys[i]))
+	}
+	recipient := utils.GenerateAddress()
+
+	InitTestChain(cc, utils.GenerateAddress(), addrs...)
+	cc.Commit()
+
+	var inputs []types.TxInput
+	for i, addr := range addrs {
+		inputs = append(inputs, types.NewTxInput(types.NewPlasmaPosition(0, 0, 0, uint64(i+1)), addr))
+	}
+	outputs := []types.TxOutput{{Owner: recipient, Amount: 100}, {Owner: recipient, Amount: 100}, {Owner: recipient, Amount: 90}}
+	msg := types.NewMultiSpendMsg(inputs, outputs, 10)
+
+	signHash := utils.SignHash(testDomain.SignHash(msg.GetSignBytes()))
+	sigs := make([][65]byte, len(keys))
+	for i, key := range keys {
+		sig, _ := ethcrypto.Sign(signHash, key)
+		copy(sigs[i][:], sig)
+	}
+	txBytes, _ := rlp.EncodeToBytes(types.NewMultiSpendTx(msg, sigs))
+
+	// every input must be signed
+	missingBytes, _ := rlp.EncodeToBytes(types.NewMultiSpendTx(msg, sigs[:2]))
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	dres := cc.DeliverTx(missingBytes)
+	require.NotEqual(t, sdk.CodeType(0), sdk.CodeType(dres.Code), "delivered a spend missing a signature")
+
+	dres = cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc.Commit()
+
+	ctx := cc.NewContext(false, abci.Header{})
+	for i, output := range outputs {
+		position := types.NewPlasmaPosition(2, 0, uint8(i), 0)
+		expected := utxo.NewUTXO(recipient.Bytes(), output.Amount, types.Denom, position)
+		require.Equal(t, expected, cc.utxoMapper.GetUTXO(ctx, recipient.Bytes(), position), fmt.Sprintf("output %d not created", i))
+	}
+	for _, input := range inputs {
+		require.False(t, cc.utxoMapper.GetUTXO(ctx, input.Owner.Bytes(), input.Position()).Valid, "input not spent")
+	}
+	require.Equal(t, txBytes, cc.plasmaStore.Get(ctx, utils.TxBytesKey(2, 0)), "spend not recorded")
+}
+
+// Tests that chains only accept spends signed for them unless started with legacy signatures
+func TestSignDomain(t *testing.T) {
+	privKeyA, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	msg := GenerateSimpleMsg(addrA, utils.GenerateAddress(), [4]uint64{0, 0, 0, 1}, 100)
+
+	legacySig := func() [2][65]byte {
+		var sigs [2][65]byte
+		sig, _ := ethcrypto.Sign(utils.SignHash(ethcrypto.Keccak256(msg.GetSignBytes())), privKeyA)
+		copy(sigs[0][:], sig)
+		return sigs
+	}
+	legacyBytes, _ := rlp.EncodeToBytes(types.NewBaseTx(msg, legacySig()))
+
+	// the domain is the default
+	cc := newChildChain()
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+	cc.Commit()
+
+	domain := types.NewSignDomain(types.SignVersionDomain, testChainID, common.HexToAddress(plasmaContractAddr))
+	require.Equal(t, domain, auth.SignDomain(cc.NewContext(true, abci.Header{}), cc.plasmaStore))
+
+	cres := cc.CheckTx(legacyBytes)
+	require.Equal(t, sdk.ToABCICode(sdk.CodespaceRoot, sdk.CodeUnauthorized), sdk.ABCICodeType(cres.Code), "accepted a spend signed over the legacy bytes")
+
+	var sigs [2][65]byte
+	sig, _ := ethcrypto.Sign(utils.SignHash(types.NewSignDomain(types.SignVersionDomain, "testnet", domain.Rootchain).SignHash(msg.GetSignBytes())), privKeyA)
+	copy(sigs[0][:], sig)
+	otherBytes, _ := rlp.EncodeToBytes(types.NewBaseTx(msg, sigs))
+	cres = cc.CheckTx(otherBytes)
+	require.Equal(t, sdk.ToABCICode(sdk.CodespaceRoot, sdk.CodeUnauthorized), sdk.ABCICodeType(cres.Code), "accepted a spend signed for another chain")
+
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+	cres = cc.CheckTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(cres.Code), cres.Log)
+
+	// chains started with legacy signatures accept the legacy bytes
+	cc = newChildChain()
+	genState := GenesisState{
+		Validator: GenesisValidator{
+			ConsPubKey: secp256k1.GenPrivKey().PubKey(),
+			Address:    utils.GenerateAddress().Hex(),
+		},
+		UTXOs:            []GenesisUTXO{NewGenesisUTXO(addrA.Hex(), "100", [4]string{"0", "0", "0", "1"})},
+		LegacySignatures: true,
+	}
+	appStateBytes, _ := cc.cdc.MarshalJSON(genState)
+	cc.InitChain(abci.RequestInitChain{ChainId: testChainID, AppStateBytes: appStateBytes})
+	cc.Commit()
+
+	cres = cc.CheckTx(legacyBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(cres.Code), cres.Log)
+
+	// and keep them when exported
+	appState, _, err := cc.ExportAppStateJSON()
+	require.NoError(t, err)
+	var exported GenesisState
+	require.NoError(t, cc.cdc.UnmarshalJSON(appState, &exported))
+	require.True(t, exported.LegacySignatures)
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	amino "github.com/tendermint/go-amino"
+)
+
+var (
+	// archived spends keyed by the position of the spent output
+	archivedSpendPrefix = []byte("spend:")
+
+	// archived spends ordered by the time the outputs were spent, used to expire them
+	archivedTimePrefix = []byte("time:")
+)
+
+// spendArchive keeps the spends pruned from state once exits can no longer be challenged, so
+// that a node can answer for them for a retention of its own. It is local to the node and
+// never part of the state agreed on by the validators
+type spendArchive struct {
+	db        *leveldb.DB
+	cdc       *amino.Codec
+	retention time.Duration
+}
+
+// opens the archive stored at `path`. An empty path keeps the archive in memory
+func newSpendArchive(path string, retention time.Duration, cdc *amino.Codec) (*spendArchive, error) {
+	var db *leveldb.DB
+	var err error
+	if path == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(path, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not open the spend archive - %s", err)
+	}
+
+	return &spendArchive{
+		db:        db,
+		cdc:       cdc,
+		retention: retention,
+	}, nil
+}
+
+// add archives the spends pruned from state at `now` and expires the archived spends older
+// than the retention. Blocks replayed after a crash archive the same spends again, which
+// leaves the archive unchanged
+func (archive *spendArchive) add(spends []utxo.SpentUTXO, now time.Time) error {
+	cutoff := now.Add(-archive.retention)
+	batch := new(leveldb.Batch)
+
+	end := archivedTimeKey(cutoff, nil)
+	iter := archive.db.NewIterator(util.BytesPrefix(archivedTimePrefix), nil)
+	for iter.Next() && bytes.Compare(iter.Key(), end) < 0 {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		batch.Delete(append([]byte(nil), iter.Value()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	for _, spent := range spends {
+		if spent.SpentAt.Before(cutoff) {
+			continue
+		}
+
+		bz, err := archive.cdc.MarshalBinaryBare(spent)
+		if err != nil {
+			return err
+		}
+		key := archivedSpendKey(spent.UTXO.Position)
+		batch.Put(key, bz)
+		batch.Put(archivedTimeKey(spent.SpentAt, key), key)
+	}
+
+	return archive.db.Write(batch, nil)
+}
+
+// get returns the archived spend of the output at `position`
+func (archive *spendArchive) get(position utxo.Position) (utxo.SpentUTXO, bool, error) {
+	bz, err := archive.db.Get(archivedSpendKey(position), nil)
+	if err == leveldb.ErrNotFound {
+		return utxo.SpentUTXO{}, false, nil
+	} else if err != nil {
+		return utxo.SpentUTXO{}, false, err
+	}
+
+	var spent utxo.SpentUTXO
+	if err := archive.cdc.UnmarshalBinaryBare(bz, &spent); err != nil {
+		return utxo.SpentUTXO{}, false, err
+	}
+	return spent, true, nil
+}
+
+func (archive *spendArchive) close() error {
+	return archive.db.Close()
+}
+
+// (<archived spend prefix> + <big endian position fields>) maps to an archived spend
+func archivedSpendKey(position utxo.Position) []byte {
+	key := append([]byte{}, archivedSpendPrefix...)
+	for _, field := range position.Get() {
+		key = append(key, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(key[len(key)-8:], field.Uint64())
+	}
+	return key
+}
+
+// (<archived time prefix> + <big endian unix time in nanoseconds> + <spend key>) maps to the spend key,
+// ordering the archive by the time outputs were spent. Times before the unix epoch order first
+func archivedTimeKey(spentAt time.Time, spendKey []byte) []byte {
+	var nanos uint64
+	if spentAt.After(time.Unix(0, 0)) {
+		nanos = uint64(spentAt.UnixNano())
+	}
+
+	key := make([]byte, len(archivedTimePrefix)+8, len(archivedTimePrefix)+8+len(spendKey))
+	copy(key, archivedTimePrefix)
+	binary.BigEndian.PutUint64(key[len(archivedTimePrefix):], nanos)
+	return append(key, spendKey...)
+}
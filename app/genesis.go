@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -17,23 +18,58 @@ import (
 	"github.com/cosmos/cosmos-sdk/codec"
 	"github.com/cosmos/cosmos-sdk/server"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // State to Unmarshal
 type GenesisState struct {
 	Validator GenesisValidator `json:"genvalidator"`
 	UTXOs     []GenesisUTXO    `json:"UTXOs"`
+
+	// Set when restarting from an exported chain. Plasma block number of the last exported
+	// block, which the block numbers of the new chain continue from
+	BlockOffset uint64 `json:"block_offset"`
+
+	// Merkle roots, transaction bytes and confirmation signatures of an exported chain
+	PlasmaStore []GenesisKVPair `json:"plasma_store"`
+
+	// Input owners sign their spends prefixed with the chain ID and rootchain address unless
+	// set, in which case they sign the legacy bytes verified by contracts deployed without a chain ID
+	LegacySignatures bool `json:"legacy_signatures"`
 }
 
 type GenesisValidator struct {
 	ConsPubKey crypto.PubKey `json:"validator_pubkey"`
 	Address    string        `json:"fee_address"`
+
+	// Ethereum address that signs the rootchain deposits and exits included in the sidechain.
+	// Usually the operator of the rootchain contract. Neither can be included if empty
+	Operator string `json:"operator_address"`
 }
 
 type GenesisUTXO struct {
 	Address  string
 	Denom    string
 	Position [4]string
+
+	// Spent UTXOs are exported so that their positions cannot be spent again
+	Spent bool
+
+	// Position and hex encoded hash of the transaction spending a spent UTXO, the index of the
+	// UTXO among its inputs and the time it was spent
+	SpentBy     [4]string
+	SpentTxHash string `json:",omitempty"`
+	SpentInput  uint8
+	SpentAt     time.Time
+
+	// Exited UTXOs are exported so that a challenge of the exit can restore them
+	Exited bool
+}
+
+// GenesisKVPair is an entry of the plasma store
+type GenesisKVPair struct {
+	Key   []byte
+	Value []byte
 }
 
 func NewGenesisUTXO(addr string, amount string, position [4]string) GenesisUTXO {
@@ -50,14 +86,62 @@ func ToUTXO(gutxo GenesisUTXO) utxo.UTXO {
 	addr := common.HexToAddress(gutxo.Address)
 	amount, _ := strconv.ParseUint(gutxo.Denom, 10, 64)
 
-	blkNum, _ := strconv.ParseUint(gutxo.Position[0], 10, 64)
-	txIndex, _ := strconv.ParseUint(gutxo.Position[1], 10, 16)
-	oIndex, _ := strconv.ParseUint(gutxo.Position[2], 10, 8)
-	depNum, _ := strconv.ParseUint(gutxo.Position[3], 10, 64)
+	output := utxo.NewUTXO(addr.Bytes(), amount, "Ether", toPosition(gutxo.Position))
+	output.Valid = !gutxo.Spent && !gutxo.Exited
+	output.Exited = gutxo.Exited
+	return output
+}
+
+// ToSpentUTXO converts a spent genesis UTXO into the spent UTXO kept in the history
+func ToSpentUTXO(gutxo GenesisUTXO) utxo.SpentUTXO {
+	return utxo.SpentUTXO{
+		UTXO: ToUTXO(gutxo),
+		Spender: utxo.Spender{
+			Position:   toPosition(gutxo.SpentBy),
+			TxHash:     common.FromHex(gutxo.SpentTxHash),
+			InputIndex: gutxo.SpentInput,
+		},
+		SpentAt: gutxo.SpentAt,
+	}
+}
+
+// Any failed str conversion defaults to 0
+func toPosition(position [4]string) types.PlasmaPosition {
+	blkNum, _ := strconv.ParseUint(position[0], 10, 64)
+	txIndex, _ := strconv.ParseUint(position[1], 10, 16)
+	oIndex, _ := strconv.ParseUint(position[2], 10, 8)
+	depNum, _ := strconv.ParseUint(position[3], 10, 64)
 
-	position := types.NewPlasmaPosition(blkNum, uint16(txIndex), uint8(oIndex), depNum)
+	return types.NewPlasmaPosition(blkNum, uint16(txIndex), uint8(oIndex), depNum)
+}
 
-	return utxo.NewUTXO(addr.Bytes(), amount, "Ether", position)
+func fromPosition(position utxo.Position) (strs [4]string) {
+	for i, pos := range position.Get() {
+		strs[i] = pos.String()
+	}
+	return strs
+}
+
+// FromUTXO converts a stored UTXO into its genesis form
+func FromUTXO(output utxo.UTXO) GenesisUTXO {
+	gutxo := NewGenesisUTXO(common.BytesToAddress(output.Address).Hex(), strconv.FormatUint(output.Amount, 10), fromPosition(output.Position))
+	gutxo.Spent = !output.Valid && !output.Exited
+	gutxo.Exited = output.Exited
+	return gutxo
+}
+
+// FromSpentUTXO converts a spent UTXO of the history into its genesis form
+func FromSpentUTXO(spent utxo.SpentUTXO) GenesisUTXO {
+	gutxo := FromUTXO(spent.UTXO)
+	if spent.Spender.Position != nil {
+		gutxo.SpentBy = fromPosition(spent.Spender.Position)
+	}
+	if len(spent.Spender.TxHash) > 0 {
+		gutxo.SpentTxHash = hexutil.Encode(spent.Spender.TxHash)
+	}
+	gutxo.SpentInput = spent.Spender.InputIndex
+	gutxo.SpentAt = spent.SpentAt
+	return gutxo
 }
 
 var (
@@ -170,7 +254,7 @@ func PlasmaAppGenStateJSON(cdc *codec.Codec, genDoc tmtypes.GenesisDoc, appGenTx
 
 func NewDefaultGenesisState(pubkey crypto.PubKey) GenesisState {
 	return GenesisState{
-		Validator: GenesisValidator{pubkey, ""},
+		Validator: GenesisValidator{pubkey, "", ""},
 		UTXOs:     nil,
 	}
 }
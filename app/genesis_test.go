@@ -2,10 +2,15 @@ package app
 
 import (
 	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	rlp "github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/tendermint/abci/types"
 	secp256k1 "github.com/tendermint/tendermint/crypto/secp256k1"
+	"github.com/tendermint/tendermint/crypto/tmhash"
 	dbm "github.com/tendermint/tendermint/libs/db"
 	"github.com/tendermint/tendermint/libs/log"
 	tmtypes "github.com/tendermint/tendermint/types"
@@ -13,7 +18,9 @@ import (
 	"os"
 	"testing"
 
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
 )
 
 func TestGenesisState(t *testing.T) {
@@ -23,7 +30,7 @@ func TestGenesisState(t *testing.T) {
 	privkeyFile.Write([]byte(privkey))
 	defer os.Remove(privkeyFile.Name())
 
-	app := NewChildChain(logger, db, nil, SetEthConfig(true, privkeyFile.Name(), plasmaContractAddr, nodeURL, "5"))
+	app := NewChildChain(logger, db, nil, SetEthConfig(true, privkeyFile.Name(), plasmaContractAddr, nodeURL, "5"), SetNoRootChain())
 
 	addrs := []common.Address{utils.GenerateAddress(), utils.GenerateAddress()}
 
@@ -53,7 +60,7 @@ func TestGenesisState(t *testing.T) {
 
 	assert.Equal(t, genState, genState2)
 
-	res := app.InitChain(abci.RequestInitChain{AppStateBytes: appBytes})
+	res := app.InitChain(abci.RequestInitChain{ChainId: testChainID, AppStateBytes: appBytes})
 	expected := abci.ResponseInitChain{
 		Validators: []abci.ValidatorUpdate{abci.ValidatorUpdate{
 			PubKey: tmtypes.TM2PB.PubKey(pubKey),
@@ -62,3 +69,87 @@ func TestGenesisState(t *testing.T) {
 	}
 	assert.Equal(t, expected, res)
 }
+
+func TestExportAppState(t *testing.T) {
+	cc := newChildChain()
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	privKeyB, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	addrB := utils.PrivKeyToAddress(privKeyB)
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+
+	// output created in block 1
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	ctx := cc.NewContext(false, abci.Header{})
+	cc.utxoMapper.ReceiveUTXO(ctx, utxo.NewUTXO(addrA.Bytes(), 100, types.Denom, types.NewPlasmaPosition(1, 0, 0, 0)))
+	cc.EndBlock(abci.RequestEndBlock{Height: 1})
+	cc.Commit()
+
+	// and spent in block 2
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	msg := GenerateSimpleMsg(addrA, addrB, [4]uint64{1, 0, 0, 0}, 100)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+	dres := cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc.Commit()
+
+	appState, validators, err := cc.ExportAppStateJSON()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(validators), "genesis validator not exported")
+
+	var genState GenesisState
+	require.NoError(t, cc.cdc.UnmarshalJSON(appState, &genState))
+	require.Equal(t, uint64(2), genState.BlockOffset)
+	require.Equal(t, 3, len(genState.UTXOs), "spent and unspent utxos must be exported")
+	spentBy := [4]string{"2", "0", "0", "0"}
+	require.Equal(t, spentBy, genState.UTXOs[2].SpentBy, "spender of the spent utxo not exported")
+
+	// restart from the exported state
+	cc2 := newChildChain()
+	res := cc2.InitChain(abci.RequestInitChain{ChainId: testChainID, AppStateBytes: appState})
+	require.Equal(t, tmtypes.TM2PB.PubKey(validators[0].PubKey), res.Validators[0].PubKey)
+
+	// block numbers continue from the export
+	cc2.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1, Time: time.Unix(7200, 0)}})
+	msg = GenerateSimpleMsg(addrB, addrA, [4]uint64{2, 0, 0, 0}, 100)
+	txBytes2, _ := rlp.EncodeToBytes(GetTx(msg, privKeyB, nil, false))
+	dres = cc2.DeliverTx(txBytes2)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc2.EndBlock(abci.RequestEndBlock{Height: 1})
+	cc2.Commit()
+
+	ctx = cc2.NewContext(true, abci.Header{})
+	spent, ok := cc2.utxoMapper.GetSpentUTXO(ctx, addrA.Bytes(), types.NewPlasmaPosition(1, 0, 0, 0))
+	require.True(t, ok, "spent utxo not imported into the history")
+	require.False(t, spent.UTXO.Valid, "spent utxo imported as valid")
+	txHash := tmhash.Sum(txBytes)
+	require.Equal(t, utxo.Spender{Position: types.NewPlasmaPosition(2, 0, 0, 0), TxHash: txHash}, spent.Spender)
+	deposit := cc2.utxoMapper.GetUTXO(ctx, addrA.Bytes(), types.NewPlasmaPosition(0, 0, 0, 1))
+	require.True(t, deposit.Valid)
+	output := cc2.utxoMapper.GetUTXO(ctx, addrA.Bytes(), types.NewPlasmaPosition(3, 0, 0, 0))
+	require.True(t, output.Valid, "output not created at the continued block number")
+
+	require.Equal(t, utils.MerkleRoot([][]byte{txBytes}), cc2.plasmaStore.Get(ctx, utils.RootHashKey(2)), "exported root not imported")
+	require.Equal(t, utils.MerkleRoot([][]byte{txBytes2}), cc2.plasmaStore.Get(ctx, utils.RootHashKey(3)))
+	require.Equal(t, txBytes, cc2.plasmaStore.Get(ctx, utils.TxBytesKey(2, 0)))
+
+	appState, _, err = cc2.ExportAppStateJSON()
+	require.NoError(t, err)
+	require.NoError(t, cc2.cdc.UnmarshalJSON(appState, &genState))
+	require.Equal(t, uint64(3), genState.BlockOffset)
+
+	// spends older than the exit challenge period are pruned at the end of a block
+	cc2.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2, Time: time.Unix(7200, 0).Add(ExitChallengePeriod - time.Hour)}})
+	cc2.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc2.Commit()
+
+	ctx = cc2.NewContext(true, abci.Header{})
+	_, ok = cc2.utxoMapper.GetSpentUTXO(ctx, addrA.Bytes(), types.NewPlasmaPosition(1, 0, 0, 0))
+	require.False(t, ok, "spent utxo not pruned")
+	require.Equal(t, utxo.UTXO{}, cc2.utxoMapper.GetUTXOByPosition(ctx, types.NewPlasmaPosition(1, 0, 0, 0)), "position of the pruned utxo still indexed")
+	_, ok = cc2.utxoMapper.GetSpentUTXO(ctx, addrB.Bytes(), types.NewPlasmaPosition(2, 0, 0, 0))
+	require.True(t, ok, "spend within the exit challenge period pruned")
+}
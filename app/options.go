@@ -3,9 +3,13 @@ package app
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"math/big"
 	"path/filepath"
 	"strconv"
+	"time"
 
+	"github.com/AdityaSripal/plasma-mvp-sidechain/auth"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/eth"
 	ethcmn "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
@@ -43,3 +47,204 @@ func SetEthConfig(isValidator bool, privkeyFile, rootchainAddr, nodeURL, finalit
 		cc.blockFinality = blockFinality
 	}
 }
+
+// SetMinimumFees sets the minimum fee a spend must pay to be admitted into the mempool
+func SetMinimumFees(minimumFees string) func(*ChildChain) {
+	var fee uint64
+	if minimumFees != "" {
+		var err error
+		fee, err = strconv.ParseUint(minimumFees, 10, 64)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return func(cc *ChildChain) {
+		cc.minimumFee = fee
+	}
+}
+
+// SetSpendLimits sets the maximum number of inputs and outputs of a spend admitted into
+// the mempool. Spends are unbounded up to the transaction format if unset
+func SetSpendLimits(maxInputs, maxOutputs string) func(*ChildChain) {
+	var inputs, outputs int
+
+	var err error
+	if maxInputs != "" {
+		inputs, err = strconv.Atoi(maxInputs)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if maxOutputs != "" {
+		outputs, err = strconv.Atoi(maxOutputs)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return func(cc *ChildChain) {
+		cc.maxInputs = inputs
+		cc.maxOutputs = outputs
+	}
+}
+
+// SetMempool bounds the mempool to `size` spends, evicting the spends paying the lowest fee rate
+// once full. Spends of input owners with `maxInvalid` failed spends are rejected for `window`
+// blocks. Unset values fall back to the default mempool configuration
+func SetMempool(size, maxInvalid, window string) func(*ChildChain) {
+	config := auth.DefaultMempoolConfig()
+
+	var err error
+	if size != "" {
+		config.Size, err = strconv.Atoi(size)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if maxInvalid != "" {
+		config.MaxInvalid, err = strconv.Atoi(maxInvalid)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if window != "" {
+		config.Window, err = strconv.ParseInt(window, 10, 64)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return func(cc *ChildChain) {
+		cc.mempool = auth.NewMempool(config)
+	}
+}
+
+// SetEventCache persists the cache of rootchain deposits and exits to `dbPath`.
+// The cache is kept in memory and rebuilt from the rootchain on every start otherwise
+func SetEventCache(dbPath string) func(*ChildChain) {
+	return func(cc *ChildChain) {
+		cc.eventDB = dbPath
+	}
+}
+
+// SetSpendArchive keeps the spends pruned from the history once exits can no longer be challenged
+// in an archive at `dbPath`, local to the node, until `retention` has passed since they were spent,
+// such as "336h". The retention must be at least ExitChallengePeriod. Spends are not archived if unset
+func SetSpendArchive(dbPath, retention string) func(*ChildChain) {
+	var duration time.Duration
+	if retention != "" {
+		var err error
+		duration, err = time.ParseDuration(retention)
+		if err != nil {
+			panic(err)
+		}
+		if duration < ExitChallengePeriod {
+			panic(fmt.Sprintf("Spend retention %s is shorter than the exit challenge period %s", retention, ExitChallengePeriod))
+		}
+	}
+
+	return func(cc *ChildChain) {
+		cc.archiveDB = dbPath
+		cc.archiveRetention = duration
+	}
+}
+
+// SetEthClient connects to the rootchain through `client` rather than dialing the node URL
+// of the eth config, such as a client of an in-process `eth.SimulatedBackend`
+func SetEthClient(client *eth.Client) func(*ChildChain) {
+	return func(cc *ChildChain) {
+		cc.ethClient = client
+	}
+}
+
+// SetRootChain validates against `rootchain` rather than connecting to the contract of the
+// eth config, such as an in-memory `eth.FakeRootChain`. Blocks are not submitted automatically
+func SetRootChain(rootchain eth.RootChain) func(*ChildChain) {
+	return func(cc *ChildChain) {
+		cc.ethConnection = rootchain
+	}
+}
+
+// SetNoRootChain runs without connecting to a rootchain, for read-only tooling such as
+// exporting state. Deposits, exits and confirm signatures are not checked against the
+// rootchain, so such an app must not admit transactions
+func SetNoRootChain() func(*ChildChain) {
+	return func(cc *ChildChain) {
+		cc.noRootChain = true
+	}
+}
+
+// SetBlockSubmission enables automatic submission of committed blocks to the rootchain.
+// Submission progress is persisted to `dbPath`. Up to `maxBatchSize` consecutive blocks are
+// submitted together, waiting at most `maxWait` for a batch to fill. Unset values fall back
+// to the default batch policy. Only takes effect for validators
+func SetBlockSubmission(dbPath, maxBatchSize, maxWait string, skipEmptyBlocks bool) func(*ChildChain) {
+	policy := eth.DefaultBatchPolicy()
+	policy.SkipEmptyBlocks = skipEmptyBlocks
+
+	var err error
+	if maxBatchSize != "" {
+		policy.MaxBatchSize, err = strconv.Atoi(maxBatchSize)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if maxWait != "" {
+		policy.MaxWait, err = time.ParseDuration(maxWait)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return func(cc *ChildChain) {
+		cc.submissionDB = dbPath
+		cc.batchPolicy = policy
+	}
+}
+
+// SetGasConfig determines how validator transactions to the rootchain are priced. `strategy` is one of
+// "fixed", "oracle" or "capped". Transactions unmined after `deadline` are replaced with a gas price
+// raised by `bumpPercent`, never exceeding `maxGasPrice` if set. Prices are in wei. Unset values fall
+// back to the default configuration
+func SetGasConfig(strategy, gasPrice, maxGasPrice, deadline, bumpPercent string) func(*ChildChain) {
+	config := eth.DefaultTxConfig()
+
+	var ok bool
+	var err error
+	if strategy != "" {
+		config.Strategy = eth.GasPriceStrategy(strategy)
+	}
+	if gasPrice != "" {
+		config.GasPrice, ok = new(big.Int).SetString(gasPrice, 10)
+		if !ok {
+			panic(fmt.Sprintf("Invalid gas price: %s", gasPrice))
+		}
+	}
+	if maxGasPrice != "" {
+		config.MaxGasPrice, ok = new(big.Int).SetString(maxGasPrice, 10)
+		if !ok {
+			panic(fmt.Sprintf("Invalid maximum gas price: %s", maxGasPrice))
+		}
+	}
+	if deadline != "" {
+		config.Deadline, err = time.ParseDuration(deadline)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if bumpPercent != "" {
+		config.BumpPercent, err = strconv.ParseInt(bumpPercent, 10, 64)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if err := config.ValidateBasic(); err != nil {
+		panic(err)
+	}
+
+	return func(cc *ChildChain) {
+		cc.txConfig = config
+	}
+}
@@ -3,9 +3,12 @@ package app
 import (
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/AdityaSripal/plasma-mvp-sidechain/eth"
 	ethcmn "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	dbm "github.com/tendermint/tendermint/libs/db"
@@ -27,6 +30,7 @@ func TestSetEthConfig(t *testing.T) {
 	logger := log.NewTMLogger(log.NewSyncWriter(os.Stdout)).With("module", "main")
 	cc := NewChildChain(logger, db, nil,
 		SetEthConfig(true, privkey_file.Name(), plasmaContractAddr, nodeURL, "16"),
+		SetNoRootChain(),
 	)
 
 	private_key, _ := crypto.LoadECDSA(privkey_file.Name())
@@ -40,3 +44,55 @@ func TestSetEthConfig(t *testing.T) {
 
 	require.Equal(t, uint64(16), cc.blockFinality)
 }
+
+func TestSetBlockSubmission(t *testing.T) {
+	cc := &ChildChain{}
+	SetBlockSubmission("submissions.db", "10", "30s", true)(cc)
+
+	require.Equal(t, "submissions.db", cc.submissionDB)
+	require.Equal(t, 10, cc.batchPolicy.MaxBatchSize)
+	require.Equal(t, 30*time.Second, cc.batchPolicy.MaxWait)
+	require.True(t, cc.batchPolicy.SkipEmptyBlocks)
+
+	// unset values default to submitting every block by itself
+	cc = &ChildChain{}
+	SetBlockSubmission("submissions.db", "", "", false)(cc)
+	require.Equal(t, eth.DefaultBatchPolicy(), cc.batchPolicy)
+}
+
+func TestSetSpendArchive(t *testing.T) {
+	cc := &ChildChain{}
+	SetSpendArchive("spends.db", "336h")(cc)
+
+	require.Equal(t, "spends.db", cc.archiveDB)
+	require.Equal(t, 2*ExitChallengePeriod, cc.archiveRetention)
+
+	// spends are not archived if unset
+	cc = &ChildChain{}
+	SetSpendArchive("spends.db", "")(cc)
+	require.Equal(t, time.Duration(0), cc.archiveRetention)
+
+	// spends must be kept for at least the exit challenge period
+	require.Panics(t, func() { SetSpendArchive("spends.db", "1h") })
+}
+
+func TestSetGasConfig(t *testing.T) {
+	cc := &ChildChain{}
+	SetGasConfig("capped", "", "50000000000", "2m", "25")(cc)
+
+	require.Equal(t, eth.GasPriceCapped, cc.txConfig.Strategy)
+	require.Equal(t, big.NewInt(50000000000), cc.txConfig.MaxGasPrice)
+	require.Equal(t, 2*time.Minute, cc.txConfig.Deadline)
+	require.Equal(t, int64(25), cc.txConfig.BumpPercent)
+
+	// unset values default to the oracle strategy
+	cc = &ChildChain{}
+	SetGasConfig("", "", "", "", "")(cc)
+	require.Equal(t, eth.DefaultTxConfig(), cc.txConfig)
+
+	// the fixed strategy requires a gas price
+	require.Panics(t, func() { SetGasConfig("fixed", "", "", "", "") })
+
+	// nodes reject replacements below a 10 percent increase
+	require.Panics(t, func() { SetGasConfig("", "", "", "", "5") })
+}
@@ -0,0 +1,522 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+const (
+	// QueryRoute is the route of custom plasma queries, "custom/plasma/..."
+	QueryRoute = "plasma"
+
+	// QueryProof returns the inclusion proof of a transaction
+	QueryProof = "proof"
+
+	// QueryBlock returns the transactions of a committed block
+	QueryBlock = "block"
+
+	// QuerySpend returns the transaction that spent an output
+	QuerySpend = "spend"
+
+	// QueryUTXO returns the output at a position
+	QueryUTXO = "utxo"
+
+	// QueryOwnerUTXOs returns a page of the outputs owned by an address
+	QueryOwnerUTXOs = "utxos"
+
+	// QueryBlockStatus returns the root of a block and its submission to the rootchain
+	QueryBlockStatus = "block_status"
+
+	// QueryDeposit returns the inclusion of a rootchain deposit
+	QueryDeposit = "deposit"
+
+	// QueryExit returns the exit status of an output
+	QueryExit = "exit"
+
+	// QuerySpentBy returns the input of the transaction that spent an output, from the history
+	QuerySpentBy = "spent_by"
+
+	// number of outputs in a page of QueryOwnerUTXOs if no limit is given, and the largest limit
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// ProofParams identifies the transaction to prove
+type ProofParams struct {
+	Blknum  uint64
+	Txindex uint16
+}
+
+// ProofResponse contains everything the rootchain contract needs to verify the inclusion of a transaction
+type ProofResponse struct {
+	TxBytes  []byte
+	Proof    []byte
+	Root     []byte
+	TotalTxs uint64
+}
+
+// BlockParams identifies the block to query
+type BlockParams struct {
+	Blknum uint64
+}
+
+// BlockResponse contains the recorded transactions of a block and their merkle root.
+// Root is empty if the block has no transactions
+type BlockResponse struct {
+	Txs  [][]byte
+	Root []byte
+}
+
+// SpendParams identifies the spent output
+type SpendParams struct {
+	Position types.PlasmaPosition
+}
+
+// SpendResponse contains everything the rootchain contract needs to challenge an exit of the spent output.
+// Spent is false if the output has not been spent by a recorded transaction
+type SpendResponse struct {
+	Spent       bool
+	Blknum      uint64
+	Txindex     uint16
+	TxBytes     []byte
+	Proof       []byte
+	Root        []byte
+	TotalTxs    uint64
+	ConfirmSigs []byte
+}
+
+// UTXOParams identifies the output to query
+type UTXOParams struct {
+	Position types.PlasmaPosition
+}
+
+// UTXOResponse is an output with its owner in hex, readable by clients without amino
+type UTXOResponse struct {
+	Owner    string
+	Amount   uint64
+	Denom    string
+	Position types.PlasmaPosition
+	Valid    bool
+	Exited   bool
+}
+
+// OwnerUTXOsParams pages through the outputs of Owner, a hex address. Pages start at 1 and
+// hold Limit outputs, 100 if unset. Spent and exited outputs are left out unless IncludeSpent is set
+type OwnerUTXOsParams struct {
+	Owner        string
+	Page         int
+	Limit        int
+	IncludeSpent bool
+}
+
+// OwnerUTXOsResponse contains a page of outputs and the number of outputs across all pages
+type OwnerUTXOsResponse struct {
+	UTXOs []UTXOResponse
+	Total int
+}
+
+// BlockStatusParams identifies the block to query
+type BlockStatusParams struct {
+	Blknum uint64
+}
+
+// BlockStatusResponse contains the merkle root of a block and how far it has progressed through
+// submission to the rootchain. Status is the submission status recorded by this node, empty if the
+// node does not submit blocks. Submitted is only checked against the rootchain by connected nodes
+type BlockStatusResponse struct {
+	Root      []byte
+	NumTxns   uint64
+	Status    string
+	Submitted bool
+}
+
+// DepositParams identifies the deposit to query
+type DepositParams struct {
+	Nonce uint64
+}
+
+// DepositResponse reports whether the deposit with Nonce has been included and, if so, its output.
+// Final is set if the deposit is final on the rootchain, as checked by connected nodes
+type DepositResponse struct {
+	Nonce    uint64
+	Included bool
+	Final    bool
+	UTXO     UTXOResponse
+}
+
+// ExitParams identifies the output to query
+type ExitParams struct {
+	Position types.PlasmaPosition
+}
+
+// ExitResponse reports whether the output at Position has been exited. Exited is the state of the
+// sidechain, ExitedOnRootChain that of the rootchain, as checked by connected nodes
+type ExitResponse struct {
+	Position          types.PlasmaPosition
+	Exited            bool
+	ExitedOnRootChain bool
+}
+
+// SpentByParams identifies the spent output
+type SpentByParams struct {
+	Position types.PlasmaPosition
+}
+
+// SpentByResponse identifies the input of the transaction that spent the output at Position.
+// Spent is false if no spend of the output is recorded, and Owner is empty if no output was found.
+// Spends pruned from the history are only found by nodes archiving them, see SetSpendArchive.
+// Spender is the position of the spending transaction and TxHash the hex encoded tendermint hash of its bytes
+type SpentByResponse struct {
+	Position   types.PlasmaPosition
+	Owner      string
+	Spent      bool
+	Spender    types.PlasmaPosition
+	TxHash     string
+	InputIndex uint8
+	SpentAt    time.Time
+}
+
+func newUTXOResponse(output utxo.UTXO) UTXOResponse {
+	response := UTXOResponse{
+		Owner:  common.BytesToAddress(output.Address).Hex(),
+		Amount: output.Amount,
+		Denom:  output.Denom,
+		Valid:  output.Valid,
+		Exited: output.Exited,
+	}
+	switch position := output.Position.(type) {
+	case types.PlasmaPosition:
+		response.Position = position
+	case *types.PlasmaPosition:
+		response.Position = *position
+	}
+	return response
+}
+
+func (app *ChildChain) querier(ctx sdk.Context, path []string, req abci.RequestQuery) ([]byte, sdk.Error) {
+	if len(path) == 0 {
+		return nil, sdk.ErrUnknownRequest("no plasma query endpoint specified")
+	}
+
+	switch path[0] {
+	case QueryProof:
+		return app.queryProof(ctx, req)
+	case QueryBlock:
+		return app.queryBlock(ctx, req)
+	case QuerySpend:
+		return app.querySpend(ctx, req)
+	case QueryUTXO:
+		return app.queryUTXO(ctx, req)
+	case QueryOwnerUTXOs:
+		return app.queryOwnerUTXOs(ctx, req)
+	case QueryBlockStatus:
+		return app.queryBlockStatus(ctx, req)
+	case QueryDeposit:
+		return app.queryDeposit(ctx, req)
+	case QueryExit:
+		return app.queryExit(ctx, req)
+	case QuerySpentBy:
+		return app.querySpentBy(ctx, req)
+	default:
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("unknown plasma query endpoint: %s", path[0]))
+	}
+}
+
+func (app *ChildChain) queryProof(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params ProofParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+
+	txs := app.blockTxs(ctx, params.Blknum)
+	if int(params.Txindex) >= len(txs) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("no transaction at block %d, index %d", params.Blknum, params.Txindex))
+	}
+
+	res, err := app.cdc.MarshalJSON(ProofResponse{
+		TxBytes:  txs[params.Txindex],
+		Proof:    utils.MerkleProof(txs, int(params.Txindex)),
+		Root:     app.plasmaStore.Get(ctx, utils.RootHashKey(params.Blknum)),
+		TotalTxs: uint64(len(txs)),
+	})
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) queryBlock(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params BlockParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+	if params.Blknum == 0 || params.Blknum > app.blockNumber(ctx) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("block %d has not been committed", params.Blknum))
+	}
+
+	res, err := app.cdc.MarshalJSON(BlockResponse{
+		Txs:  app.blockTxs(ctx, params.Blknum),
+		Root: app.plasmaStore.Get(ctx, utils.RootHashKey(params.Blknum)),
+	})
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) querySpend(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params SpendParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+	if !params.Position.IsValid() {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("invalid position: %v", params.Position))
+	}
+
+	var response SpendResponse
+	blknum, txindex, ok := app.findSpend(ctx, params.Position)
+	if ok {
+		txs := app.blockTxs(ctx, blknum)
+		response = SpendResponse{
+			Spent:       true,
+			Blknum:      blknum,
+			Txindex:     txindex,
+			TxBytes:     txs[txindex],
+			Proof:       utils.MerkleProof(txs, int(txindex)),
+			Root:        app.plasmaStore.Get(ctx, utils.RootHashKey(blknum)),
+			TotalTxs:    uint64(len(txs)),
+			ConfirmSigs: app.plasmaStore.Get(ctx, utils.ConfirmSigKey(blknum, txindex)),
+		}
+	}
+
+	res, err := app.cdc.MarshalJSON(response)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) queryUTXO(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params UTXOParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+
+	output, ok := app.findUTXO(ctx, params.Position)
+	if !ok {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("no output at position %v", params.Position))
+	}
+
+	res, err := app.cdc.MarshalJSON(newUTXOResponse(output))
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) queryOwnerUTXOs(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params OwnerUTXOsParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+	if !common.IsHexAddress(params.Owner) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("invalid owner address: %s", params.Owner))
+	}
+	if params.Page == 0 {
+		params.Page = 1
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultPageLimit
+	}
+	if params.Page < 0 || params.Limit < 0 || params.Limit > maxPageLimit {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("invalid page %d of limit %d, the limit is at most %d", params.Page, params.Limit, maxPageLimit))
+	}
+
+	filter := utxo.ValidUTXOs
+	if params.IncludeSpent {
+		filter = utxo.AllUTXOs
+	}
+	outputs := app.utxoMapper.GetUTXOsForAddress(ctx, common.HexToAddress(params.Owner).Bytes(), filter)
+
+	response := OwnerUTXOsResponse{UTXOs: []UTXOResponse{}, Total: len(outputs)}
+	for i := (params.Page - 1) * params.Limit; i < len(outputs) && len(response.UTXOs) < params.Limit; i++ {
+		response.UTXOs = append(response.UTXOs, newUTXOResponse(outputs[i]))
+	}
+
+	res, err := app.cdc.MarshalJSON(response)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) queryBlockStatus(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params BlockStatusParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+	if params.Blknum == 0 || params.Blknum > app.blockNumber(ctx) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("block %d has not been committed", params.Blknum))
+	}
+
+	response := BlockStatusResponse{
+		Root:    app.plasmaStore.Get(ctx, utils.RootHashKey(params.Blknum)),
+		NumTxns: uint64(len(app.blockTxs(ctx, params.Blknum))),
+	}
+	if app.submitter != nil {
+		if submission, err := app.submitter.Submission(params.Blknum); err == nil {
+			response.Status = submission.Status.String()
+		}
+	}
+	if app.ethConnection != nil {
+		var header [32]byte
+		copy(header[:], response.Root)
+		response.Submitted = app.ethConnection.HasBlockBeenSubmitted(new(big.Int).SetUint64(params.Blknum), header)
+	}
+
+	res, err := app.cdc.MarshalJSON(response)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) queryDeposit(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params DepositParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+	if params.Nonce == 0 {
+		return nil, sdk.ErrUnknownRequest("deposit nonces start at 1")
+	}
+
+	response := DepositResponse{Nonce: params.Nonce}
+	if app.plasmaStore.Get(ctx, utils.DepositKey(params.Nonce)) != nil {
+		response.Included = true
+		output, _ := app.findUTXO(ctx, types.NewPlasmaPosition(0, 0, 0, params.Nonce))
+		response.UTXO = newUTXOResponse(output)
+	}
+	if app.ethConnection != nil {
+		deposit, err := app.ethConnection.GetDeposit(new(big.Int).SetUint64(params.Nonce))
+		response.Final = err == nil && deposit != nil
+	}
+
+	res, err := app.cdc.MarshalJSON(response)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) queryExit(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params ExitParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+
+	output, ok := app.findUTXO(ctx, params.Position)
+	if !ok {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("no output at position %v", params.Position))
+	}
+
+	response := ExitResponse{
+		Position: params.Position,
+		Exited:   output.Exited,
+	}
+	if app.ethConnection != nil {
+		var position [4]*big.Int
+		for i, n := range params.Position.Get() {
+			position[i] = new(big.Int).SetUint64(n.Uint64())
+		}
+		response.ExitedOnRootChain = app.ethConnection.HasTXBeenExited(position)
+	}
+
+	res, err := app.cdc.MarshalJSON(response)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+func (app *ChildChain) querySpentBy(ctx sdk.Context, req abci.RequestQuery) ([]byte, sdk.Error) {
+	var params SpentByParams
+	if err := app.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("incorrectly formatted request data - %s", err))
+	}
+
+	if !params.Position.IsValid() {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("invalid position %v", params.Position))
+	}
+
+	// outputs pruned from the history are answered for from the node's spend archive
+	spent, ok := app.utxoMapper.GetSpentUTXOByPosition(ctx, params.Position)
+	if !ok && app.archive != nil {
+		var err error
+		spent, ok, err = app.archive.get(params.Position)
+		if err != nil {
+			return nil, sdk.ErrInternal(fmt.Sprintf("could not read the spend archive - %s", err))
+		}
+	}
+
+	response := SpentByResponse{Position: params.Position}
+	if ok {
+		response.Owner = common.BytesToAddress(spent.UTXO.Address).Hex()
+		response.Spent = true
+		response.Spender, _ = spent.Spender.Position.(types.PlasmaPosition)
+		response.TxHash = hexutil.Encode(spent.Spender.TxHash)
+		response.InputIndex = spent.Spender.InputIndex
+		response.SpentAt = spent.SpentAt
+	} else if output := app.utxoMapper.GetUTXOByPosition(ctx, params.Position); output.Position != nil {
+		response.Owner = common.BytesToAddress(output.Address).Hex()
+	}
+
+	res, err := app.cdc.MarshalJSON(response)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+
+	return res, nil
+}
+
+// output at `position`, whatever its owner. Spent outputs are found in the history until pruned
+func (app *ChildChain) findUTXO(ctx sdk.Context, position types.PlasmaPosition) (utxo.UTXO, bool) {
+	if !position.IsValid() {
+		return utxo.UTXO{}, false
+	}
+
+	output := app.utxoMapper.GetUTXOByPosition(ctx, position)
+	if output.Position == nil {
+		spent, ok := app.utxoMapper.GetSpentUTXOByPosition(ctx, position)
+		return spent.UTXO, ok
+	}
+	return output, true
+}
+
+// position of the recorded transaction that spends `position`. The spend index is never pruned
+// so that an exit can be challenged for as long as it can be started
+func (app *ChildChain) findSpend(ctx sdk.Context, position types.PlasmaPosition) (uint64, uint16, bool) {
+	value := app.plasmaStore.Get(ctx, utils.SpendKey(position.Blknum, position.TxIndex, position.Oindex, position.DepositNum))
+	if value == nil {
+		return 0, 0, false
+	}
+
+	blknum, txindex := utils.SpenderPosition(value)
+	return blknum, txindex, true
+}
@@ -0,0 +1,325 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	rlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/eth"
+	types "github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	utils "github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+)
+
+func TestQueryProof(t *testing.T) {
+	cc := newChildChain()
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	privKeyB, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	addrB := utils.PrivKeyToAddress(privKeyB)
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+	cc.Commit()
+
+	storeInitUTXO(cc, types.NewPlasmaPosition(1, 0, 0, 0), addrA)
+	cc.Commit()
+	storeInitUTXO(cc, types.NewPlasmaPosition(2, 0, 0, 0), addrB)
+	cc.Commit()
+
+	// two spends in block 5
+	var txs [][]byte
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 5}})
+	for _, msg := range []types.SpendMsg{
+		GenerateSimpleMsg(addrA, addrB, [4]uint64{1, 0, 0, 0}, 100),
+		GenerateSimpleMsg(addrB, addrA, [4]uint64{2, 0, 0, 0}, 100),
+	} {
+		privKey := privKeyA
+		if msg.Owner0 == addrB {
+			privKey = privKeyB
+		}
+		txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKey, nil, false))
+		dres := cc.DeliverTx(txBytes)
+		require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+		txs = append(txs, txBytes)
+	}
+	cc.EndBlock(abci.RequestEndBlock{Height: 5})
+	cc.Commit()
+
+	for index, txBytes := range txs {
+		data, _ := cc.cdc.MarshalJSON(ProofParams{Blknum: 5, Txindex: uint16(index)})
+		res := cc.Query(abci.RequestQuery{Path: "/custom/plasma/proof", Data: data})
+		require.Equal(t, uint32(0), res.Code, res.Log)
+
+		var proof ProofResponse
+		require.NoError(t, cc.cdc.UnmarshalJSON(res.Value, &proof))
+
+		require.Equal(t, txBytes, proof.TxBytes)
+		require.Equal(t, uint64(2), proof.TotalTxs)
+		require.Equal(t, utils.MerkleRoot(txs), proof.Root, "root stored in end blocker does not match")
+		require.True(t, utils.VerifyMerkleProof(txBytes, index, 2, proof.Root, proof.Proof), "invalid proof")
+	}
+
+	// transaction does not exist
+	data, _ := cc.cdc.MarshalJSON(ProofParams{Blknum: 5, Txindex: 2})
+	res := cc.Query(abci.RequestQuery{Path: "/custom/plasma/proof", Data: data})
+	require.NotEqual(t, uint32(0), res.Code, "proof returned for a transaction that does not exist")
+
+	data, _ = cc.cdc.MarshalJSON(BlockParams{Blknum: 5})
+	res = cc.Query(abci.RequestQuery{Path: "/custom/plasma/block", Data: data})
+	require.Equal(t, uint32(0), res.Code, res.Log)
+
+	var block BlockResponse
+	require.NoError(t, cc.cdc.UnmarshalJSON(res.Value, &block))
+	require.Equal(t, txs, block.Txs)
+	require.Equal(t, utils.MerkleRoot(txs), block.Root)
+
+	// block has not been committed
+	data, _ = cc.cdc.MarshalJSON(BlockParams{Blknum: 6})
+	res = cc.Query(abci.RequestQuery{Path: "/custom/plasma/block", Data: data})
+	require.NotEqual(t, uint32(0), res.Code, "block returned before it was committed")
+}
+
+func TestQuerySpend(t *testing.T) {
+	cc := newChildChain()
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	addrB := utils.GenerateAddress()
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+	cc.Commit()
+
+	storeInitUTXO(cc, types.NewPlasmaPosition(1, 0, 0, 0), addrA)
+	cc.Commit()
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 5}})
+	msg := GenerateSimpleMsg(addrA, addrB, [4]uint64{1, 0, 0, 0}, 100)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+	dres := cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 5})
+	cc.Commit()
+
+	query := func(position types.PlasmaPosition) SpendResponse {
+		data, _ := cc.cdc.MarshalJSON(SpendParams{Position: position})
+		res := cc.Query(abci.RequestQuery{Path: "/custom/plasma/spend", Data: data})
+		require.Equal(t, uint32(0), res.Code, res.Log)
+
+		var spend SpendResponse
+		require.NoError(t, cc.cdc.UnmarshalJSON(res.Value, &spend))
+		return spend
+	}
+
+	spend := query(types.NewPlasmaPosition(1, 0, 0, 0))
+	require.True(t, spend.Spent, "spent output not found")
+	require.Equal(t, uint64(5), spend.Blknum)
+	require.Equal(t, uint16(0), spend.Txindex)
+	require.Equal(t, txBytes, spend.TxBytes)
+	require.True(t, utils.VerifyMerkleProof(txBytes, 0, 1, spend.Root, spend.Proof), "invalid proof")
+	require.Empty(t, spend.ConfirmSigs, "confirmation signatures have not been sent")
+
+	// the new output is unspent
+	spend = query(types.NewPlasmaPosition(5, 0, 0, 0))
+	require.False(t, spend.Spent, "unspent output reported as spent")
+}
+
+func TestQuerySpentBy(t *testing.T) {
+	cc := newChildChain()
+	archive, err := newSpendArchive("", 2*ExitChallengePeriod, cc.cdc)
+	require.NoError(t, err)
+	cc.archive = archive
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	addrB := utils.GenerateAddress()
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+	cc.Commit()
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2, Time: time.Unix(1000, 0)}})
+	msg := GenerateSimpleMsg(addrA, addrB, [4]uint64{0, 0, 0, 1}, 100)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+	dres := cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc.Commit()
+
+	query := func(position types.PlasmaPosition) (SpentByResponse, abci.ResponseQuery) {
+		data, _ := cc.cdc.MarshalJSON(SpentByParams{Position: position})
+		res := cc.Query(abci.RequestQuery{Path: "/custom/plasma/" + QuerySpentBy, Data: data})
+
+		var spentBy SpentByResponse
+		if res.Code == 0 {
+			require.NoError(t, cc.cdc.UnmarshalJSON(res.Value, &spentBy))
+		}
+		return spentBy, res
+	}
+
+	spentBy, res := query(types.NewPlasmaPosition(0, 0, 0, 1))
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	txHash := tmhash.Sum(txBytes)
+	require.Equal(t, SpentByResponse{
+		Position:   types.NewPlasmaPosition(0, 0, 0, 1),
+		Owner:      addrA.Hex(),
+		Spent:      true,
+		Spender:    types.NewPlasmaPosition(2, 0, 0, 0),
+		TxHash:     hexutil.Encode(txHash),
+		InputIndex: 0,
+		SpentAt:    spentBy.SpentAt,
+	}, spentBy)
+	require.True(t, time.Unix(1000, 0).Equal(spentBy.SpentAt))
+	spentBy0 := spentBy
+
+	// the new output is unspent
+	spentBy, res = query(types.NewPlasmaPosition(2, 0, 0, 0))
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	require.False(t, spentBy.Spent, "unspent output reported as spent")
+	require.Equal(t, addrB.Hex(), spentBy.Owner)
+
+	// outputs without a recorded spend are reported unspent
+	spentBy, res = query(types.NewPlasmaPosition(3, 0, 0, 0))
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	require.False(t, spentBy.Spent, "returned the spender of an output that does not exist")
+	require.Equal(t, "", spentBy.Owner)
+
+	// the spender of an output pruned from the history is answered for from the archive
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 3, Time: time.Unix(1000, 0).Add(ExitChallengePeriod + time.Second)}})
+	cc.EndBlock(abci.RequestEndBlock{Height: 3})
+	cc.Commit()
+
+	ctx := cc.NewContext(true, abci.Header{})
+	_, ok := cc.utxoMapper.GetSpentUTXOByPosition(ctx, types.NewPlasmaPosition(0, 0, 0, 1))
+	require.False(t, ok, "spend not pruned after the exit challenge period")
+	pruned, res := query(types.NewPlasmaPosition(0, 0, 0, 1))
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	require.Equal(t, spentBy0, pruned)
+
+	// until the archive's retention has passed
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 4, Time: time.Unix(1000, 0).Add(2*ExitChallengePeriod + time.Second)}})
+	cc.EndBlock(abci.RequestEndBlock{Height: 4})
+	cc.Commit()
+
+	expired, res := query(types.NewPlasmaPosition(0, 0, 0, 1))
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	require.False(t, expired.Spent, "archived spend kept past the retention")
+	require.Equal(t, "", expired.Owner)
+}
+
+func TestQueryUTXOs(t *testing.T) {
+	cc := newChildChain()
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+	addrB := utils.GenerateAddress()
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA, addrA, addrA)
+	cc.Commit()
+
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	msg := GenerateSimpleMsg(addrA, addrB, [4]uint64{0, 0, 0, 1}, 100)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+	dres := cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc.Commit()
+
+	query := func(path string, params interface{}, response interface{}) abci.ResponseQuery {
+		data, _ := cc.cdc.MarshalJSON(params)
+		res := cc.Query(abci.RequestQuery{Path: "/custom/plasma/" + path, Data: data})
+		if res.Code == 0 {
+			require.NoError(t, cc.cdc.UnmarshalJSON(res.Value, response))
+		}
+		return res
+	}
+
+	// outputs are found by position alone
+	var output UTXOResponse
+	res := query(QueryUTXO, UTXOParams{Position: types.NewPlasmaPosition(2, 0, 0, 0)}, &output)
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	require.Equal(t, UTXOResponse{Owner: addrB.Hex(), Amount: 100, Denom: types.Denom, Position: types.NewPlasmaPosition(2, 0, 0, 0), Valid: true}, output)
+
+	res = query(QueryUTXO, UTXOParams{Position: types.NewPlasmaPosition(3, 0, 0, 0)}, &output)
+	require.NotEqual(t, uint32(0), res.Code, "returned an output that does not exist")
+
+	// spent outputs are left out unless asked for
+	var page OwnerUTXOsResponse
+	res = query(QueryOwnerUTXOs, OwnerUTXOsParams{Owner: addrA.Hex()}, &page)
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	require.Equal(t, 2, page.Total)
+	require.Len(t, page.UTXOs, 2)
+
+	res = query(QueryOwnerUTXOs, OwnerUTXOsParams{Owner: addrA.Hex(), Page: 2, Limit: 2, IncludeSpent: true}, &page)
+	require.Equal(t, uint32(0), res.Code, res.Log)
+	require.Equal(t, 3, page.Total)
+	require.Len(t, page.UTXOs, 1)
+
+	res = query(QueryOwnerUTXOs, OwnerUTXOsParams{Owner: "owner"}, &page)
+	require.NotEqual(t, uint32(0), res.Code, "listed the outputs of an invalid address")
+}
+
+func TestQueryRootChainStatus(t *testing.T) {
+	cc := newChildChain()
+	rootchain := cc.ethConnection.(*eth.FakeRootChain)
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	addrA := utils.PrivKeyToAddress(privKeyA)
+
+	InitTestChain(cc, utils.GenerateAddress(), addrA)
+	cc.Commit()
+
+	query := func(path string, params interface{}, response interface{}) {
+		data, _ := cc.cdc.MarshalJSON(params)
+		res := cc.Query(abci.RequestQuery{Path: "/custom/plasma/" + path, Data: data})
+		require.Equal(t, uint32(0), res.Code, res.Log)
+		require.NoError(t, cc.cdc.UnmarshalJSON(res.Value, response))
+	}
+
+	var deposit DepositResponse
+	query(QueryDeposit, DepositParams{Nonce: 5}, &deposit)
+	require.False(t, deposit.Included || deposit.Final, "deposit missing from the rootchain")
+
+	events := make(chan eth.Event, 1)
+	sub := rootchain.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	rootchain.Deposit(5, addrA, 100)
+	<-events
+
+	query(QueryDeposit, DepositParams{Nonce: 5}, &deposit)
+	require.True(t, deposit.Final)
+	require.False(t, deposit.Included, "deposit included before it was sent")
+
+	depositBytes, _ := rlp.EncodeToBytes(signedDepositTx(types.NewDepositMsg(5, addrA, 100, 1)))
+	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	dres := cc.DeliverTx(depositBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{Height: 2})
+	cc.Commit()
+
+	query(QueryDeposit, DepositParams{Nonce: 5}, &deposit)
+	require.True(t, deposit.Included)
+	require.Equal(t, addrA.Hex(), deposit.UTXO.Owner)
+	require.True(t, deposit.UTXO.Valid)
+
+	// exits are reported for the rootchain before the sidechain mirrors them
+	position := types.NewPlasmaPosition(0, 0, 0, 5)
+	rootchain.StartExit([4]uint64{0, 0, 0, 5})
+	<-events
+
+	var exit ExitResponse
+	query(QueryExit, ExitParams{Position: position}, &exit)
+	require.True(t, exit.ExitedOnRootChain)
+	require.False(t, exit.Exited)
+
+	var status BlockStatusResponse
+	query(QueryBlockStatus, BlockStatusParams{Blknum: 2}, &status)
+	require.Empty(t, status.Status, "node does not submit blocks")
+	require.Equal(t, uint64(0), status.NumTxns, "deposits are not part of the plasma block")
+}
@@ -2,47 +2,59 @@ package app
 
 import (
 	"crypto/ecdsa"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"testing"
-	"time"
 
 	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
-	//"github.com/AdityaSripal/plasma-mvp-sidechain/eth"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/eth"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
 	utils "github.com/AdityaSripal/plasma-mvp-sidechain/utils"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	rlp "github.com/ethereum/go-ethereum/rlp"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto/tmhash"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"github.com/tendermint/tendermint/libs/log"
 )
 
 const (
 	minExitBond = 10000
+
+	// written by `truffle compile` within contracts/
+	plasmaArtifact = "../contracts/build/contracts/PlasmaMVP.json"
 )
 
-// create a new session with plasma contract
-func newSession(privateKey *ecdsa.PrivateKey, nodeUrl string) (*contracts.PlasmaMVPSession, error) {
-	c, err := rpc.Dial(nodeUrl)
+// deploys the rootchain contract to an in-process chain and starts a childchain against it.
+// Returns a session with the contract for the operator
+func newSimulation(t *testing.T) (*contracts.PlasmaMVPSession, *ChildChain) {
+	bytecode, err := eth.PlasmaMVPBytecode(plasmaArtifact)
 	if err != nil {
-		return nil, err
+		t.Skip("Rootchain contract not compiled -", err)
 	}
-	ec := ethclient.NewClient(c)
 
-	plasmaContract, err := contracts.NewPlasmaMVP(ethcmn.HexToAddress(plasmaContractAddr), ec)
-	if err != nil {
-		return nil, err
-	}
+	privKey, _ := ethcrypto.HexToECDSA(privkey)
+	balance, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	sim := eth.NewSimulatedBackend(core.GenesisAlloc{
+		ethcrypto.PubkeyToAddress(privKey.PublicKey): core.GenesisAccount{Balance: balance},
+	}, 8000000) // enough gas to deploy the rootchain contract
+
+	auth := bind.NewKeyedTransactor(privKey)
+	contractAddr, err := eth.DeployPlasmaMVP(auth, sim, bytecode, testChainID)
+	require.NoError(t, err)
+
+	plasmaContract, err := contracts.NewPlasmaMVP(contractAddr, sim)
+	require.NoError(t, err)
 
 	// Create a session with the contract and operator account
-	auth := bind.NewKeyedTransactor(privateKey)
-	plasmaSession := &contracts.PlasmaMVPSession{
+	session := &contracts.PlasmaMVPSession{
 		Contract: plasmaContract,
 		CallOpts: bind.CallOpts{
 			Pending: true,
@@ -53,43 +65,66 @@ func newSession(privateKey *ecdsa.PrivateKey, nodeUrl string) (*contracts.Plasma
 			GasLimit: 3141592, // aribitrary
 		},
 	}
-	return plasmaSession, nil
+
+	logger := log.NewTMLogger(log.NewSyncWriter(os.Stdout)).With("module", "sdk/app")
+	privkeyFile, _ := ioutil.TempFile("", "privateKey")
+	privkeyFile.Write([]byte(privkey))
+	defer os.Remove(privkeyFile.Name())
+	cc := NewChildChain(logger, dbm.NewMemDB(), nil,
+		SetEthConfig(true, privkeyFile.Name(), contractAddr.Hex(), "", "0"),
+		SetEthClient(eth.NewClient(sim, logger)))
+
+	// the operator of the contract signs the inclusion of deposits
+	InitTestChain(cc, utils.GenerateAddress())
+	cc.Commit()
+
+	return session, cc
+}
+
+// caches the rootchain events of the mined transactions
+func syncRootChain(t *testing.T, cc *ChildChain) {
+	require.NoError(t, cc.ethConnection.(*eth.Plasma).Sync())
 }
 
 // deposit 100 with passed in address
-func deposit() (*contracts.PlasmaMVPSession, *ChildChain, ethcmn.Address, uint64, *ecdsa.PrivateKey, error) {
+func deposit(t *testing.T) (*contracts.PlasmaMVPSession, *ChildChain, ethcmn.Address, uint64, *ecdsa.PrivateKey) {
 	privKey, _ := ethcrypto.HexToECDSA(privkey)
-	session, err := newSession(privKey, nodeURL)
-	if err != nil {
-		return nil, nil, ethcmn.Address{}, 0, nil, err
-	}
-
-	cc := newChildChain()
+	session, cc := newSimulation(t)
 
 	cc.BeginBlock(abci.RequestBeginBlock{})
 	cc.EndBlock(abci.RequestEndBlock{})
 	cc.Commit()
 
 	nonce, err := session.DepositNonce()
-	if err != nil {
-		return nil, nil, ethcmn.Address{}, 0, nil, err
-	}
+	require.NoError(t, err)
 
 	// Deposit 100 eth from the validator
 	session.TransactOpts.Value = big.NewInt(100)
 	addr := ethcrypto.PubkeyToAddress(privKey.PublicKey)
-	if _, err = session.Deposit(addr); err != nil {
-		return nil, nil, ethcmn.Address{}, 0, nil, err
-	}
+	_, err = session.Deposit(addr)
+	require.NoError(t, err)
+	syncRootChain(t, cc)
 
-	time.Sleep(500 * time.Millisecond)
-	return session, cc, addr, uint64(nonce.Int64()), privKey, nil
+	// include the deposit in the sidechain. Checked against the rootchain when admitted
+	d, err := session.Deposits(nonce)
+	require.NoError(t, err)
+	msg := types.NewDepositMsg(nonce.Uint64(), d.Owner, d.Amount.Uint64(), d.EthBlockNum.Uint64())
+	txBytes, _ := rlp.EncodeToBytes(signedDepositTx(msg))
+	cres := cc.CheckTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(cres.Code), cres.Log)
+
+	cc.BeginBlock(abci.RequestBeginBlock{})
+	dres := cc.DeliverTx(txBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+	cc.EndBlock(abci.RequestEndBlock{})
+	cc.Commit()
+
+	return session, cc, addr, nonce.Uint64(), privKey
 }
 
 // AddrA deposits and spend to AddrB
 func TestDepositAndSpend(t *testing.T) {
-	_, cc, addrA, nonce, privKey, err := deposit()
-	require.NoError(t, err)
+	_, cc, addrA, nonce, privKey := deposit(t)
 
 	// Spend Deposit
 	privKeyB, _ := ethcrypto.GenerateKey()
@@ -121,14 +156,13 @@ func TestDepositAndSpend(t *testing.T) {
 // AddrA deposits and exits
 // spend attempt fails
 func TestDepositExit(t *testing.T) {
-	session, cc, addrA, nonce, privKey, err := deposit()
-	require.NoError(t, err)
+	session, cc, addrA, nonce, privKey := deposit(t)
 
 	// exit deposit
 	session.TransactOpts.Value = big.NewInt(minExitBond)
-	_, err = session.StartDepositExit(big.NewInt(int64(nonce)))
+	_, err := session.StartDepositExit(big.NewInt(int64(nonce)))
 	require.NoError(t, err)
-	time.Sleep(500 * time.Millisecond)
+	syncRootChain(t, cc)
 
 	// attempt spend
 	privKeyB, _ := ethcrypto.GenerateKey()
@@ -137,20 +171,16 @@ func TestDepositExit(t *testing.T) {
 	tx := GetTx(msg, privKey, nil, false)
 	txBytes, _ := rlp.EncodeToBytes(tx)
 
-	// Simulate a block
-	cc.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	// exits are checked against the rootchain when admitted into the mempool
+	cres := cc.CheckTx(txBytes)
 
-	// Deliver tx, updates states
-	dres := cc.DeliverTx(txBytes)
-
-	require.Equal(t, sdk.CodeType(204), sdk.CodeType(dres.Code), dres.Log)
+	require.Equal(t, sdk.CodeType(204), sdk.CodeType(cres.Code), cres.Log)
 }
 
 // deposit, spend deposit, exit utxo
 // attempt spend of utxo, assert that this fails
 func TestUTXOExitSpend(t *testing.T) {
-	session, cc, addrA, nonce, privKey, err := deposit()
-	require.NoError(t, err)
+	session, cc, addrA, nonce, privKey := deposit(t)
 
 	// Spend Deposit
 	msg := GenerateSimpleMsg(addrA, addrA, [4]uint64{0, 0, 0, nonce}, 100)
@@ -177,7 +207,7 @@ func TestUTXOExitSpend(t *testing.T) {
 	copy(blockHash[:], txHash[:])
 
 	session.TransactOpts.Value = nil
-	_, err = session.SubmitBlock(
+	_, err := session.SubmitBlock(
 		[][32]byte{blockHash},
 		[]*big.Int{big.NewInt(1)},
 		big.NewInt(blknum),
@@ -192,17 +222,26 @@ func TestUTXOExitSpend(t *testing.T) {
 	session.TransactOpts.Value = big.NewInt(minExitBond)
 	_, err = session.StartTransactionExit([3]*big.Int{big.NewInt(blknum), big.NewInt(0), big.NewInt(0)}, txBytes, []byte{}, confirmSigs[0][:])
 	require.NoError(t, err)
-	time.Sleep(500 * time.Millisecond)
+	syncRootChain(t, cc)
 
 	msg = GenerateSimpleMsg(addrA, addrA, [4]uint64{uint64(blknum), 0, 0, 0}, 100)
 
 	tx = GetTx(msg, privKey, nil, false)
 	txBytes, _ = rlp.EncodeToBytes(tx)
 
-	// Deliver tx, updates states
-	dres = cc.DeliverTx(txBytes)
+	// exits are checked against the rootchain when admitted into the mempool
+	cres := cc.CheckTx(txBytes)
+	require.Equal(t, sdk.CodeType(204), sdk.CodeType(cres.Code), cres.Log)
 
-	require.Equal(t, sdk.CodeType(204), sdk.CodeType(dres.Code), dres.Log)
+	// and mirrored into state so that delivery rejects the spend
+	exitBytes, _ := rlp.EncodeToBytes(signedExitTx(types.NewExitMsg(types.NewPlasmaPosition(uint64(blknum), 0, 0, 0), addrA, types.ExitStarted)))
+	cres = cc.CheckTx(exitBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(cres.Code), cres.Log)
+	dres = cc.DeliverTx(exitBytes)
+	require.Equal(t, sdk.CodeType(0), sdk.CodeType(dres.Code), dres.Log)
+
+	dres = cc.DeliverTx(txBytes)
+	require.NotEqual(t, sdk.CodeType(0), sdk.CodeType(dres.Code), "spent an exited output")
 
 }
 
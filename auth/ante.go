@@ -16,12 +16,36 @@ import (
 )
 
 // NewAnteHandler returns an AnteHandler that checks signatures,
-// confirm signatures, and increments the feeAmount
-func NewAnteHandler(utxoMapper utxo.Mapper, plasmaStore kvstore.KVStore, plasmaClient *eth.Plasma) sdk.AnteHandler {
+// confirm signatures, and that inputs cover the outputs and fee. Spends paying
+// less than `minimumFee` or with more than `maxInputs` inputs or `maxOutputs` outputs
+// are not admitted into the mempool. Limits of 0 are unbounded. Deposits, exits and
+// submitted blocks are only checked against `plasmaClient` if it is not nil
+func NewAnteHandler(utxoMapper utxo.Mapper, plasmaStore kvstore.KVStore, plasmaClient eth.RootChain, minimumFee uint64, maxInputs, maxOutputs int) sdk.AnteHandler {
 	return func(
 		ctx sdk.Context, tx sdk.Tx, simulate bool,
 	) (_ sdk.Context, _ sdk.Result, abort bool) {
 
+		if confirmSigTx, ok := tx.(types.ConfirmSigTx); ok {
+			res := checkConfirmSigs(ctx, plasmaStore, plasmaClient, confirmSigTx.Msg)
+			return ctx, res, !res.IsOK()
+		}
+
+		domain := SignDomain(ctx, plasmaStore)
+		if depositTx, ok := tx.(types.DepositTx); ok {
+			res := checkDeposit(ctx, utxoMapper, plasmaStore, plasmaClient, domain, depositTx)
+			return ctx, res, !res.IsOK()
+		}
+
+		if exitTx, ok := tx.(types.ExitTx); ok {
+			res := checkExit(ctx, utxoMapper, plasmaStore, plasmaClient, domain, exitTx)
+			return ctx, res, !res.IsOK()
+		}
+
+		if multiSpendTx, ok := tx.(types.MultiSpendTx); ok {
+			res := checkMultiSpend(ctx, utxoMapper, plasmaClient, domain, multiSpendTx, minimumFee, maxInputs, maxOutputs)
+			return ctx, res, !res.IsOK()
+		}
+
 		baseTx, ok := tx.(types.BaseTx)
 		if !ok {
 			return ctx, sdk.ErrInternal("tx must be in form of BaseTx").Result(), true
@@ -41,25 +65,25 @@ func NewAnteHandler(utxoMapper utxo.Mapper, plasmaStore kvstore.KVStore, plasmaC
 		}
 		signBytes := spendMsg.GetSignBytes()
 
+		res := checkAdmission(ctx, utxoMapper, spendMsg, minimumFee, maxInputs, maxOutputs)
+		if !res.IsOK() {
+			return ctx, res, true
+		}
+
 		// Verify the first input signature
 		addr0 := common.BytesToAddress(signerAddrs[0].Bytes())
 		position0 := types.PlasmaPosition{spendMsg.Blknum0, spendMsg.Txindex0, spendMsg.Oindex0, spendMsg.DepositNum0}
 
-		res := checkUTXO(ctx, plasmaClient, utxoMapper, position0, addr0)
+		res = checkUTXO(ctx, utxoMapper, position0, addr0)
 		if !res.IsOK() {
 			return ctx, res, true
 		}
-		exitErr := hasTXExited(plasmaClient, position0)
+		exitErr := hasTXExited(ctx, plasmaClient, position0)
 		if exitErr != nil {
 			return ctx, exitErr.Result(), true
 		}
-		if position0.IsDeposit() {
-			deposit, _ := DepositExists(position0.DepositNum, plasmaClient)
-			inputUTXO := utxo.NewUTXO(deposit.Owner.Bytes(), deposit.Amount.Uint64(), types.Denom, position0)
-			utxoMapper.ReceiveUTXO(ctx, inputUTXO)
-		}
 
-		res = processSig(addr0, sigs[0], signBytes)
+		res = processSig(domain, addr0, sigs[0], signBytes)
 		if !res.IsOK() {
 			return ctx, res, true
 		}
@@ -69,59 +93,153 @@ func NewAnteHandler(utxoMapper utxo.Mapper, plasmaStore kvstore.KVStore, plasmaC
 			addr1 := common.BytesToAddress(signerAddrs[1].Bytes())
 			position1 := types.PlasmaPosition{spendMsg.Blknum1, spendMsg.Txindex1, spendMsg.Oindex1, spendMsg.DepositNum1}
 
-			exitErr := hasTXExited(plasmaClient, position1)
+			exitErr := hasTXExited(ctx, plasmaClient, position1)
 			if exitErr != nil {
 				return ctx, exitErr.Result(), true
 			}
 
 			// second input can be less than fee amount
-			res := checkUTXO(ctx, plasmaClient, utxoMapper, position1, addr1)
+			res := checkUTXO(ctx, utxoMapper, position1, addr1)
 			if !res.IsOK() {
 				return ctx, res, true
 			}
-			if position1.IsDeposit() {
-				deposit, _ := DepositExists(position1.DepositNum, plasmaClient)
-				inputUTXO := utxo.NewUTXO(deposit.Owner.Bytes(), deposit.Amount.Uint64(), types.Denom, position1)
-				utxoMapper.ReceiveUTXO(ctx, inputUTXO)
-			}
 
-			res = processSig(addr1, sigs[1], signBytes)
+			res = processSig(domain, addr1, sigs[1], signBytes)
 
 			if !res.IsOK() {
 				return ctx, res, true
 			}
 		}
 
-		/* Check that balances add up */
-		// Add up all inputs
-		totalInput := map[string]uint64{}
-		for _, i := range spendMsg.Inputs() {
-			utxo := utxoMapper.GetUTXO(ctx, i.Owner, i.Position)
-			totalInput[utxo.Denom] += utxo.Amount
+		res = checkBalance(ctx, utxoMapper, spendMsg)
+		if !res.IsOK() {
+			return ctx, res, true
 		}
 
-		// Add up all outputs and fee
-		totalOutput := map[string]uint64{}
-		for _, o := range spendMsg.Outputs() {
-			totalOutput[o.Denom] += o.Amount
+		// TODO: tx tags (?)
+		return ctx, sdk.Result{}, false // continue...
+	}
+}
+
+// Checks every input of a spend with a variable number of inputs and outputs
+// the same way as the inputs of the legacy form
+func checkMultiSpend(ctx sdk.Context, utxoMapper utxo.Mapper, plasmaClient eth.RootChain, domain types.SignDomain, tx types.MultiSpendTx, minimumFee uint64, maxInputs, maxOutputs int) sdk.Result {
+	msg := tx.Msg
+	res := checkAdmission(ctx, utxoMapper, msg, minimumFee, maxInputs, maxOutputs)
+	if !res.IsOK() {
+		return res
+	}
+
+	if len(tx.Signatures) != len(msg.TxIn) {
+		return sdk.ErrUnauthorized(fmt.Sprintf("%d signatures provided for %d inputs", len(tx.Signatures), len(msg.TxIn))).Result()
+	}
+
+	signBytes := msg.GetSignBytes()
+	for i, input := range msg.TxIn {
+		position := input.Position()
+
+		res := checkUTXO(ctx, utxoMapper, position, input.Owner)
+		if !res.IsOK() {
+			return res
+		}
+		exitErr := hasTXExited(ctx, plasmaClient, position)
+		if exitErr != nil {
+			return exitErr.Result()
 		}
 
-		for denom, _ := range totalInput {
-			if totalInput[denom] != totalOutput[denom] {
-				return ctx, utxo.ErrInvalidTransaction(2, "Inputs do not equal Outputs").Result(), true
-			}
+		res = processSig(domain, input.Owner, tx.Signatures[i], signBytes)
+		if !res.IsOK() {
+			return res
 		}
+	}
 
-		// TODO: tx tags (?)
-		return ctx, sdk.Result{}, false // continue...
+	return checkBalance(ctx, utxoMapper, msg)
+}
+
+// Checks the limits this validator enforces when admitting a spend into the mempool
+func checkAdmission(ctx sdk.Context, utxoMapper utxo.Mapper, spend types.Spend, minimumFee uint64, maxInputs, maxOutputs int) sdk.Result {
+	if !ctx.IsCheckTx() {
+		return sdk.Result{}
 	}
+
+	if fee := SpendFee(ctx, utxoMapper, spend); fee < minimumFee {
+		return utxo.ErrInvalidFee(utxo.DefaultCodespace, fmt.Sprintf("fee of %d is below the minimum of %d", fee, minimumFee)).Result()
+	}
+	if inputs := len(spend.Inputs()); maxInputs > 0 && inputs > maxInputs {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("transaction has %d inputs, at most %d are accepted", inputs, maxInputs)).Result()
+	}
+	if outputs := len(spend.Outputs()); maxOutputs > 0 && outputs > maxOutputs {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("transaction has %d outputs, at most %d are accepted", outputs, maxOutputs)).Result()
+	}
+
+	return sdk.Result{}
+}
+
+// Checks that the inputs of every denomination of the spend equal its outputs of that denomination.
+// The fee is paid in Ether
+func checkBalance(ctx sdk.Context, utxoMapper utxo.Mapper, spend types.Spend) sdk.Result {
+	// Add up all inputs
+	totalInput := map[string]uint64{}
+	for _, i := range spend.Inputs() {
+		utxo := utxoMapper.GetUTXO(ctx, i.Owner, i.Position)
+		totalInput[utxo.Denom] += utxo.Amount
+	}
+
+	// Add up all outputs and fee
+	totalOutput := map[string]uint64{types.Denom: SpendFee(ctx, utxoMapper, spend)}
+	for _, o := range spend.Outputs() {
+		totalOutput[o.Denom] += o.Amount
+	}
+
+	// outputs cannot create a denomination that is not spent
+	for denom, amount := range totalOutput {
+		if _, ok := totalInput[denom]; !ok && amount > 0 {
+			return utxo.ErrInvalidDenom(2, fmt.Sprintf("no inputs of denomination %s", denom)).Result()
+		}
+	}
+
+	for denom, _ := range totalInput {
+		if totalInput[denom] != totalOutput[denom] {
+			return utxo.ErrInvalidTransaction(2, fmt.Sprintf("Inputs do not equal Outputs plus fee for denomination %s", denom)).Result()
+		}
+	}
+
+	return sdk.Result{}
 }
 
+// SpendFee returns the fee paid by `spend`. The legacy form has no fee field, as the rootchain
+// contract decodes exactly its 14 fields, so it pays the Ether its inputs leave unspent. Zero
+// if its outputs exceed its inputs
+func SpendFee(ctx sdk.Context, utxoMapper utxo.Mapper, spend types.Spend) uint64 {
+	if msg, ok := spend.(types.MultiSpendMsg); ok {
+		return msg.GetFee()
+	}
+
+	var input, output uint64
+	for _, i := range spend.Inputs() {
+		if in := utxoMapper.GetUTXO(ctx, i.Owner, i.Position); in.Denom == types.Denom {
+			input += in.Amount
+		}
+	}
+	for _, o := range spend.Outputs() {
+		if o.Denom == types.Denom {
+			output += o.Amount
+		}
+	}
+
+	if output > input {
+		return 0
+	}
+	return input - output
+}
+
+// Checks that `sig` is the signature of `addr` over the msg with sign bytes `signBytes`
+// under the sign domain of the chain
 func processSig(
-	addr common.Address, sig [65]byte, signBytes []byte) (
+	domain types.SignDomain, addr common.Address, sig [65]byte, signBytes []byte) (
 	res sdk.Result) {
 
-	hash := ethcrypto.Keccak256(signBytes)
+	hash := domain.SignHash(signBytes)
 	signHash := utils.SignHash(hash)
 	pubKey, err := ethcrypto.SigToPub(signHash, sig[:])
 
@@ -132,32 +250,158 @@ func processSig(
 	return sdk.Result{}
 }
 
+// SignDomain returns the domain spends are signed under, as recorded at genesis. Chains
+// started without a recorded domain sign the legacy bytes
+func SignDomain(ctx sdk.Context, plasmaStore kvstore.KVStore) types.SignDomain {
+	bz := plasmaStore.Get(ctx, utils.SignDomainKey)
+	if bz == nil {
+		return types.SignDomain{}
+	}
+
+	domain, err := types.DecodeSignDomain(bz)
+	if err != nil {
+		panic(err)
+	}
+	return domain
+}
+
+// Operator returns the address authorizing the inclusion of rootchain deposits and exits, as
+// recorded at genesis. Zero if none was recorded
+func Operator(ctx sdk.Context, plasmaStore kvstore.KVStore) common.Address {
+	return common.BytesToAddress(plasmaStore.Get(ctx, utils.OperatorKey))
+}
+
+// Checks that `sig` is the operator's signature over `signBytes`. The operator vouches for the
+// rootchain state that the transaction mirrors, which other nodes cannot check deterministically
+func checkOperatorSig(ctx sdk.Context, plasmaStore kvstore.KVStore, domain types.SignDomain, sig [65]byte, signBytes []byte) sdk.Result {
+	operator := Operator(ctx, plasmaStore)
+	if !utils.ValidAddress(operator) {
+		return sdk.ErrUnauthorized("no operator has been set to authorize rootchain transactions").Result()
+	}
+
+	return processSig(domain, operator, sig, signBytes)
+}
+
+// Checks that the confirmation signatures are from the input owners of the referenced transaction and
+// sign over the root of its block. Signatures are only accepted once that block is on the rootchain
+func checkConfirmSigs(ctx sdk.Context, plasmaStore kvstore.KVStore, plasmaClient eth.RootChain, msg types.ConfirmSigMsg) sdk.Result {
+	txBytes := plasmaStore.Get(ctx, utils.TxBytesKey(msg.Blknum, msg.Txindex))
+	if txBytes == nil {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("no transaction at block %d, index %d", msg.Blknum, msg.Txindex)).Result()
+	}
+	if plasmaStore.Get(ctx, utils.ConfirmSigKey(msg.Blknum, msg.Txindex)) != nil {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, "confirmation signatures already exist").Result()
+	}
+
+	root := plasmaStore.Get(ctx, utils.RootHashKey(msg.Blknum))
+	if root == nil {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("block %d has not been committed", msg.Blknum)).Result()
+	}
+
+	// the rootchain is only queried when admitting the transaction into the mempool
+	// so that delivery does not depend on the state of an ethereum node
+	if ctx.IsCheckTx() && plasmaClient != nil {
+		var header [32]byte
+		copy(header[:], root)
+		if !plasmaClient.HasBlockBeenSubmitted(new(big.Int).SetUint64(msg.Blknum), header) {
+			return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("block %d has not been submitted to the rootchain", msg.Blknum)).Result()
+		}
+	}
+
+	spendTx, err := types.DecodeSpendTx(txBytes)
+	if err != nil {
+		return sdk.ErrInternal(fmt.Sprintf("stored transaction could not be decoded: %s", err)).Result()
+	}
+
+	// the rootchain contract takes at most two confirmation signatures
+	signers := spendTx.GetSpend().GetSigners()
+	if len(signers) > len(msg.Signatures) {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("transaction with %d inputs cannot be confirmed on the rootchain", len(signers))).Result()
+	}
+
+	confirmationHash := utils.ConfirmationHash(txBytes, root)
+	for i, signer := range signers {
+		res := processConfirmSig(common.BytesToAddress(signer), msg.Signatures[i], confirmationHash)
+		if !res.IsOK() {
+			return res
+		}
+	}
+
+	if len(signers) == 1 && msg.Signatures[1] != [65]byte{} {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, "single input transaction has a second confirmation signature").Result()
+	}
+
+	return sdk.Result{}
+}
+
+func processConfirmSig(addr common.Address, sig [65]byte, confirmationHash []byte) sdk.Result {
+	pubKey, err := ethcrypto.SigToPub(utils.SignHash(confirmationHash), sig[:])
+
+	if err != nil || !reflect.DeepEqual(ethcrypto.PubkeyToAddress(*pubKey).Bytes(), addr.Bytes()) {
+		return sdk.ErrUnauthorized(fmt.Sprintf("confirmation signature verification failed for: %X", addr.Bytes())).Result()
+	}
+
+	return sdk.Result{}
+}
+
+// Returns the utxo at the position, or the spent utxo kept in the history if it was spent
+func getUTXO(ctx sdk.Context, mapper utxo.Mapper, addr []byte, position types.PlasmaPosition) utxo.UTXO {
+	output := mapper.GetUTXO(ctx, addr, &position)
+	if reflect.DeepEqual(output, utxo.UTXO{}) {
+		if spent, ok := mapper.GetSpentUTXO(ctx, addr, &position); ok {
+			return spent.UTXO
+		}
+	}
+	return output
+}
+
 // Checks that utxo at the position specified exists, matches the address in the SpendMsg
-// and returns the denomination associated with the utxo
-func checkUTXO(ctx sdk.Context, plasmaClient *eth.Plasma, mapper utxo.Mapper, position types.PlasmaPosition, addr common.Address) sdk.Result {
-	var inputAddress []byte
-	input := mapper.GetUTXO(ctx, addr.Bytes(), &position)
+// and returns the denomination associated with the utxo. Deposits must have been included first
+func checkUTXO(ctx sdk.Context, mapper utxo.Mapper, position types.PlasmaPosition, addr common.Address) sdk.Result {
+	input := getUTXO(ctx, mapper, addr.Bytes(), position)
 	if position.IsDeposit() && reflect.DeepEqual(input, utxo.UTXO{}) {
-		deposit, ok := DepositExists(position.DepositNum, plasmaClient)
+		return utxo.ErrInvalidUTXO(2, fmt.Sprintf("Deposit %d has not been included in the sidechain", position.DepositNum)).Result()
+	}
+	if !input.Valid {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("UTXO trying to be spent, is not valid: %v.", position)).Result()
+	}
+
+	// Verify that utxo owner equals input address in the transaction
+	if !reflect.DeepEqual(input.Address, addr.Bytes()) {
+		return sdk.ErrUnauthorized(fmt.Sprintf("signer does not match utxo owner, signer: %X  owner: %X", addr.Bytes(), input.Address)).Result()
+	}
+	return sdk.Result{}
+}
+
+// Checks that the deposit is signed by the operator and has not been included yet and, when admitting the
+// transaction into the mempool, that it matches a finalized deposit on the rootchain. Delivery only depends
+// on the sidechain state so that every node including the deposit computes the same state
+func checkDeposit(ctx sdk.Context, mapper utxo.Mapper, plasmaStore kvstore.KVStore, plasmaClient eth.RootChain, domain types.SignDomain, tx types.DepositTx) sdk.Result {
+	msg := tx.Msg
+	res := checkOperatorSig(ctx, plasmaStore, domain, tx.Signature, msg.GetSignBytes())
+	if !res.IsOK() {
+		return res
+	}
+
+	position := msg.Position()
+	if plasmaStore.Get(ctx, utils.DepositKey(msg.DepositNum)) != nil || !reflect.DeepEqual(getUTXO(ctx, mapper, msg.Owner.Bytes(), position), utxo.UTXO{}) {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("deposit %d has already been included", msg.DepositNum)).Result()
+	}
+
+	if ctx.IsCheckTx() && plasmaClient != nil {
+		deposit, ok := DepositExists(msg.DepositNum, plasmaClient)
 		if !ok {
-			return utxo.ErrInvalidUTXO(2, "Deposit UTXO does not exist yet").Result()
+			return utxo.ErrInvalidUTXO(2, fmt.Sprintf("Deposit %d does not exist or is not finalized", msg.DepositNum)).Result()
 		}
-		inputAddress = deposit.Owner.Bytes()
-	} else {
-		if !input.Valid {
-			return sdk.ErrUnknownRequest(fmt.Sprintf("UTXO trying to be spent, is not valid: %v.", position)).Result()
+		if deposit.Owner != msg.Owner || deposit.Amount.Uint64() != msg.Amount || deposit.BlockNum.Uint64() != msg.EthBlockNum {
+			return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("deposit %d does not match the rootchain", msg.DepositNum)).Result()
 		}
-		inputAddress = input.Address
 	}
 
-	// Verify that utxo owner equals input address in the transaction
-	if !reflect.DeepEqual(inputAddress, addr.Bytes()) {
-		return sdk.ErrUnauthorized(fmt.Sprintf("signer does not match utxo owner, signer: %X  owner: %X", addr.Bytes(), inputAddress)).Result()
-	}
 	return sdk.Result{}
 }
 
-func DepositExists(nonce uint64, plasmaClient *eth.Plasma) (types.Deposit, bool) {
+func DepositExists(nonce uint64, plasmaClient eth.RootChain) (types.Deposit, bool) {
 	deposit, err := plasmaClient.GetDeposit(big.NewInt(int64(nonce)))
 
 	if err != nil {
@@ -166,18 +410,59 @@ func DepositExists(nonce uint64, plasmaClient *eth.Plasma) (types.Deposit, bool)
 	return *deposit, true
 }
 
-func hasTXExited(plasmaClient *eth.Plasma, pos types.PlasmaPosition) sdk.Error {
-	if plasmaClient == nil {
+// Checks the rootchain for an exit of the input when admitting the transaction into the mempool.
+// Delivery relies on exits mirrored into state by ExitTxs
+func hasTXExited(ctx sdk.Context, plasmaClient eth.RootChain, pos types.PlasmaPosition) sdk.Error {
+	if !ctx.IsCheckTx() || plasmaClient == nil {
 		return nil
 	}
 
+	if rootchainExited(plasmaClient, pos) {
+		return types.ErrInvalidTransaction(types.DefaultCodespace, "Input UTXO has already exited")
+	}
+	return nil
+}
+
+// Checks that the exit is signed by the operator, that the exited output exists and can take the mirrored
+// state and, when admitting the transaction into the mempool, that the state matches the rootchain. Exits
+// of spent outputs are left to be challenged and are not mirrored
+func checkExit(ctx sdk.Context, mapper utxo.Mapper, plasmaStore kvstore.KVStore, plasmaClient eth.RootChain, domain types.SignDomain, tx types.ExitTx) sdk.Result {
+	msg := tx.Msg
+	res := checkOperatorSig(ctx, plasmaStore, domain, tx.Signature, msg.GetSignBytes())
+	if !res.IsOK() {
+		return res
+	}
+
+	position := msg.Position()
+	output := getUTXO(ctx, mapper, msg.Owner.Bytes(), position)
+	if reflect.DeepEqual(output, utxo.UTXO{}) {
+		return utxo.ErrInvalidUTXO(2, fmt.Sprintf("Exited UTXO does not exist: %v", position)).Result()
+	}
+
+	switch msg.State {
+	case types.ExitStarted:
+		if !output.Valid {
+			return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("UTXO has already been spent or exited: %v", position)).Result()
+		}
+	case types.ExitChallenged:
+		if !output.Exited {
+			return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("UTXO has not exited: %v", position)).Result()
+		}
+	}
+
+	if ctx.IsCheckTx() && plasmaClient != nil {
+		if rootchainExited(plasmaClient, position) != (msg.State == types.ExitStarted) {
+			return types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("exit of %v does not match the rootchain", position)).Result()
+		}
+	}
+
+	return sdk.Result{}
+}
+
+func rootchainExited(plasmaClient eth.RootChain, pos types.PlasmaPosition) bool {
 	var positions [4]*big.Int
 	for i, num := range pos.Get() {
 		positions[i] = big.NewInt(int64(num.Uint64()))
 	}
-	exited := plasmaClient.HasTXBeenExited(positions)
-	if exited {
-		return types.ErrInvalidTransaction(types.DefaultCodespace, "Input UTXO has already exited")
-	}
-	return nil
+	return plasmaClient.HasTXBeenExited(positions)
 }
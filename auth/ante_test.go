@@ -11,6 +11,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	rlp "github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/tendermint/abci/types"
@@ -20,13 +21,13 @@ import (
 )
 
 func setup() (sdk.Context, utxo.Mapper, kvstore.KVStore) {
-	ms, capKey, plasmaCapKey := utxo.SetupMultiStore()
+	ms, capKey, plasmaCapKey, indexKey := utxo.SetupMultiStore()
 
 	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
 	cdc := utxo.MakeCodec()
 	types.RegisterAmino(cdc)
 
-	mapper := utxo.NewBaseMapper(capKey, cdc)
+	mapper := utxo.NewBaseMapper(capKey, indexKey, cdc)
 	plasmaStore := kvstore.NewKVStore(plasmaCapKey)
 
 	return ctx, mapper, plasmaStore
@@ -73,6 +74,13 @@ func GetTx(msg types.SpendMsg, privKey0, privKey1 *ecdsa.PrivateKey, two_sigs bo
 	return tx
 }
 
+// helper for signing deposits and exits as the operator
+func operatorSig(privKey *ecdsa.PrivateKey, signBytes []byte) (sig [65]byte) {
+	bz, _ := ethcrypto.Sign(utils.SignHash(ethcrypto.Keccak256(signBytes)), privKey)
+	copy(sig[:], bz)
+	return sig
+}
+
 // helper for constructing input addresses
 func getInputAddr(addr0, addr1 common.Address, two bool) [][]byte {
 	if two {
@@ -96,7 +104,7 @@ func TestNoSigs(t *testing.T) {
 	mapper.ReceiveUTXO(ctx, utxo1)
 	mapper.ReceiveUTXO(ctx, utxo2)
 
-	handler := NewAnteHandler(mapper, plasmaStore, nil)
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0)
 	_, res, abort := handler(ctx, tx, false)
 
 	assert.Equal(t, true, abort, "did not abort with no signatures")
@@ -121,7 +129,7 @@ func TestNotEnoughSigs(t *testing.T) {
 	mapper.ReceiveUTXO(ctx, utxo1)
 	mapper.ReceiveUTXO(ctx, utxo2)
 
-	handler := NewAnteHandler(mapper, plasmaStore, nil)
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0)
 	_, res, abort := handler(ctx, tx, false)
 
 	assert.Equal(t, true, abort, "did not abort with incorrect number of signatures")
@@ -218,7 +226,7 @@ func TestDifferentCases(t *testing.T) {
 		owner_index1 := utils.GetIndex(tc.input1.owner_index)
 		tx := GetTx(msg, keys[tc.input0.owner_index], keys[owner_index1], tc.input1.owner_index != -1)
 
-		handler := NewAnteHandler(mapper, plasmaStore, nil)
+		handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0)
 		_, res, abort := handler(ctx, tx, false)
 
 		assert.Equal(t, true, abort, fmt.Sprintf("did not abort on utxo that does not exist. Case: %d", index))
@@ -251,3 +259,288 @@ func TestDifferentCases(t *testing.T) {
 		}
 	}
 }
+
+// Tests verification of confirmation signatures against the stored transaction
+func TestConfirmSigs(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+
+	privKeyA, _ := ethcrypto.GenerateKey()
+	privKeyB, _ := ethcrypto.GenerateKey()
+
+	msg := GenSpendMsg()
+	msg.Owner0 = utils.PrivKeyToAddress(privKeyA)
+	msg.Owner1 = utils.PrivKeyToAddress(privKeyB)
+	txBytes, _ := rlp.EncodeToBytes(GetTx(msg, privKeyA, privKeyB, true))
+	root := tmhash.Sum([]byte("root"))
+
+	confirmationHash := utils.ConfirmationHash(txBytes, root)
+	sign := func(privKey *ecdsa.PrivateKey) (sig [65]byte) {
+		bz, _ := ethcrypto.Sign(utils.SignHash(confirmationHash), privKey)
+		copy(sig[:], bz)
+		return sig
+	}
+
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0)
+	confirmSigTx := types.NewConfirmSigTx(types.NewConfirmSigMsg(2, 0, [2][65]byte{sign(privKeyA), sign(privKeyB)}))
+
+	// transaction does not exist
+	_, res, abort := handler(ctx, confirmSigTx, false)
+	require.True(t, abort, "confirmed a transaction that does not exist")
+
+	plasmaStore.Set(ctx, utils.TxBytesKey(2, 0), txBytes)
+
+	// block root does not exist
+	_, res, abort = handler(ctx, confirmSigTx, false)
+	require.True(t, abort, "confirmed a transaction in an uncommitted block")
+
+	plasmaStore.Set(ctx, utils.RootHashKey(2), root)
+
+	cases := []struct {
+		sigs  [2][65]byte
+		abort bool
+	}{
+		// Case 0: signatures in the wrong order
+		{[2][65]byte{sign(privKeyB), sign(privKeyA)}, true},
+
+		// Case 1: missing second signature
+		{[2][65]byte{sign(privKeyA), [65]byte{}}, true},
+
+		// Case 2: signatures from both input owners
+		{[2][65]byte{sign(privKeyA), sign(privKeyB)}, false},
+	}
+
+	for index, tc := range cases {
+		confirmSigTx = types.NewConfirmSigTx(types.NewConfirmSigMsg(2, 0, tc.sigs))
+		_, res, abort = handler(ctx, confirmSigTx, false)
+		require.Equal(t, tc.abort, abort, fmt.Sprintf("Case: %d. %s", index, res.Log))
+	}
+
+	// confirmation signatures are only accepted once
+	plasmaStore.Set(ctx, utils.ConfirmSigKey(2, 0), confirmSigTx.Msg.ConfirmSigs())
+	_, res, abort = handler(ctx, confirmSigTx, false)
+	require.True(t, abort, "accepted confirmation signatures twice")
+
+	// single input transactions have a single confirmation signature
+	msg.Owner1 = common.Address{}
+	txBytes, _ = rlp.EncodeToBytes(GetTx(msg, privKeyA, nil, false))
+	plasmaStore.Set(ctx, utils.TxBytesKey(2, 1), txBytes)
+	confirmationHash = utils.ConfirmationHash(txBytes, root)
+
+	confirmSigTx = types.NewConfirmSigTx(types.NewConfirmSigMsg(2, 1, [2][65]byte{sign(privKeyA), sign(privKeyA)}))
+	_, res, abort = handler(ctx, confirmSigTx, false)
+	require.True(t, abort, "accepted a second confirmation signature for a single input")
+
+	confirmSigTx = types.NewConfirmSigTx(types.NewConfirmSigMsg(2, 1, [2][65]byte{sign(privKeyA), [65]byte{}}))
+	_, res, abort = handler(ctx, confirmSigTx, false)
+	require.False(t, abort, res.Log)
+}
+
+// Tests that deposits are included once and only spent after inclusion
+func TestDepositInclusion(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+
+	privKey, _ := ethcrypto.GenerateKey()
+	addr := utils.PrivKeyToAddress(privKey)
+	operatorKey, _ := ethcrypto.GenerateKey()
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0)
+
+	// deposit has not been included
+	msg := GenSpendMsg()
+	msg.Blknum0, msg.DepositNum0, msg.Owner0 = 0, 1, addr
+	msg.Blknum1, msg.Txindex1, msg.Owner1 = 0, 0, common.Address{}
+	msg.Amount0, msg.Amount1 = 100, 0
+	_, res, abort := handler(ctx, GetTx(msg, privKey, nil, false), false)
+	require.True(t, abort, "spent a deposit that has not been included")
+
+	depositMsg := types.NewDepositMsg(1, addr, 100, 1)
+	depositTx := types.NewDepositTx(depositMsg, operatorSig(operatorKey, depositMsg.GetSignBytes()))
+	_, res, abort = handler(ctx, depositTx, false)
+	require.True(t, abort, "included a deposit without an operator")
+
+	// only the operator can include deposits
+	plasmaStore.Set(ctx, utils.OperatorKey, utils.PrivKeyToAddress(operatorKey).Bytes())
+	_, res, abort = handler(ctx, types.NewDepositTx(depositMsg, operatorSig(privKey, depositMsg.GetSignBytes())), false)
+	require.True(t, abort, "included a deposit signed by its owner")
+
+	// delivery does not depend on the rootchain
+	_, res, abort = handler(ctx, depositTx, false)
+	require.False(t, abort, res.Log)
+
+	mapper.ReceiveUTXO(ctx, utxo.NewUTXO(addr.Bytes(), 100, types.Denom, types.NewPlasmaPosition(0, 0, 0, 1)))
+	plasmaStore.Set(ctx, utils.DepositKey(1), addr.Bytes())
+
+	_, res, abort = handler(ctx, depositTx, false)
+	require.True(t, abort, "included a deposit twice")
+
+	// included under a different owner
+	otherMsg := types.NewDepositMsg(1, utils.GenerateAddress(), 100, 1)
+	_, res, abort = handler(ctx, types.NewDepositTx(otherMsg, operatorSig(operatorKey, otherMsg.GetSignBytes())), false)
+	require.True(t, abort, "included a deposit twice")
+
+	_, res, abort = handler(ctx, GetTx(msg, privKey, nil, false), false)
+	require.False(t, abort, res.Log)
+}
+
+// Tests that exits are only mirrored onto outputs that can take the new state
+func TestExitMirroring(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+
+	addr := utils.GenerateAddress()
+	position := types.NewPlasmaPosition(1, 0, 1, 0)
+	operatorKey, _ := ethcrypto.GenerateKey()
+	plasmaStore.Set(ctx, utils.OperatorKey, utils.PrivKeyToAddress(operatorKey).Bytes())
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0)
+
+	startedMsg := types.NewExitMsg(position, addr, types.ExitStarted)
+	challengedMsg := types.NewExitMsg(position, addr, types.ExitChallenged)
+	started := types.NewExitTx(startedMsg, operatorSig(operatorKey, startedMsg.GetSignBytes()))
+	challenged := types.NewExitTx(challengedMsg, operatorSig(operatorKey, challengedMsg.GetSignBytes()))
+
+	_, res, abort := handler(ctx, started, false)
+	require.True(t, abort, "exit of an output that does not exist")
+
+	output := utxo.NewUTXO(addr.Bytes(), 100, types.Denom, position)
+	mapper.ReceiveUTXO(ctx, output)
+
+	// only the operator can mirror exits
+	_, res, abort = handler(ctx, types.NewExitTx(startedMsg, [65]byte{}), false)
+	require.True(t, abort, "mirrored an unsigned exit")
+	otherKey, _ := ethcrypto.GenerateKey()
+	_, res, abort = handler(ctx, types.NewExitTx(startedMsg, operatorSig(otherKey, startedMsg.GetSignBytes())), false)
+	require.True(t, abort, "mirrored an exit not signed by the operator")
+
+	_, res, abort = handler(ctx, challenged, false)
+	require.True(t, abort, "challenged an exit that was not mirrored")
+	_, res, abort = handler(ctx, started, false)
+	require.False(t, abort, res.Log)
+
+	output.Exited = true
+	mapper.InvalidateUTXO(ctx, output)
+
+	_, res, abort = handler(ctx, started, false)
+	require.True(t, abort, "mirrored an exit twice")
+	_, res, abort = handler(ctx, challenged, false)
+	require.False(t, abort, res.Log)
+
+	require.Nil(t, mapper.ValidateUTXO(ctx, mapper.GetUTXO(ctx, addr.Bytes(), position)))
+	output = mapper.GetUTXO(ctx, addr.Bytes(), position)
+	require.True(t, output.Valid && !output.Exited, "challenged exit not restored")
+
+	// spent outputs are not mirrored
+	mapper.SpendUTXO(ctx, addr.Bytes(), position, utxo.Spender{Position: types.NewPlasmaPosition(2, 0, 0, 0)})
+	_, res, abort = handler(ctx, started, false)
+	require.True(t, abort, "mirrored the exit of a spent output")
+	_, res, abort = handler(ctx, challenged, false)
+	require.True(t, abort, "restored a spent output")
+}
+
+// Tests that inputs must cover the outputs and fee and that the minimum fee is only enforced in CheckTx
+func TestFees(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+	checkCtx := sdk.NewContext(ctx.MultiStore(), abci.Header{}, true, log.NewNopLogger())
+
+	privKey, _ := ethcrypto.GenerateKey()
+	addr := utils.PrivKeyToAddress(privKey)
+	mapper.ReceiveUTXO(ctx, utxo.NewUTXO(addr.Bytes(), 100, types.Denom, types.NewPlasmaPosition(1, 0, 0, 0)))
+
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 10, 0, 0)
+	msg := types.SpendMsg{Blknum0: 1, Owner0: addr, Newowner0: utils.GenerateAddress(), Amount0: 95}
+
+	_, res, abort := handler(ctx, GetTx(msg, privKey, nil, false), false)
+	require.False(t, abort, res.Log)
+
+	_, res, abort = handler(checkCtx, GetTx(msg, privKey, nil, false), false)
+	require.True(t, abort, "admitted a spend below the minimum fee")
+	require.Equal(t, sdk.ToABCICode(utxo.DefaultCodespace, utxo.CodeInvalidFee), res.Code, res.Log)
+
+	// the legacy form pays what its inputs leave unspent
+	msg.Amount0 = 90
+	require.Equal(t, uint64(10), SpendFee(ctx, mapper, msg))
+	_, res, abort = handler(checkCtx, GetTx(msg, privKey, nil, false), false)
+	require.False(t, abort, res.Log)
+
+	msg.Amount0 = 110
+	require.Equal(t, uint64(0), SpendFee(ctx, mapper, msg))
+	_, res, abort = handler(ctx, GetTx(msg, privKey, nil, false), false)
+	require.True(t, abort, "outputs not covered by the inputs")
+}
+
+// Tests that spends with a variable number of inputs are checked and limited in CheckTx
+func TestMultiSpend(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+	checkCtx := sdk.NewContext(ctx.MultiStore(), abci.Header{}, true, log.NewNopLogger())
+
+	var keys [3]*ecdsa.PrivateKey
+	var inputs []types.TxInput
+	for i := range keys {
+		keys[i], _ = ethcrypto.GenerateKey()
+		addr := utils.PrivKeyToAddress(keys[i])
+		position := types.NewPlasmaPosition(1, uint16(i), 0, 0)
+		mapper.ReceiveUTXO(ctx, utxo.NewUTXO(addr.Bytes(), 100, types.Denom, position))
+		inputs = append(inputs, types.NewTxInput(position, addr))
+	}
+	msg := types.NewMultiSpendMsg(inputs, []types.TxOutput{{Owner: utils.GenerateAddress(), Amount: 300}}, 0)
+
+	sign := func(keys ...*ecdsa.PrivateKey) types.MultiSpendTx {
+		signHash := utils.SignHash(ethcrypto.Keccak256(msg.GetSignBytes()))
+		sigs := make([][65]byte, len(keys))
+		for i, key := range keys {
+			sig, _ := ethcrypto.Sign(signHash, key)
+			copy(sigs[i][:], sig)
+		}
+		return types.NewMultiSpendTx(msg, sigs)
+	}
+
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 2, 0)
+
+	_, res, abort := handler(ctx, sign(keys[0], keys[1]), false)
+	require.True(t, abort, "accepted a spend missing a signature")
+
+	_, res, abort = handler(ctx, sign(keys[0], keys[2], keys[1]), false)
+	require.True(t, abort, "accepted signatures in the wrong order")
+
+	_, res, abort = handler(ctx, sign(keys[0], keys[1], keys[2]), false)
+	require.False(t, abort, res.Log)
+
+	// the input limit is only enforced when admitting the transaction
+	_, res, abort = handler(checkCtx, sign(keys[0], keys[1], keys[2]), false)
+	require.True(t, abort, "admitted a spend above the input limit")
+	require.Equal(t, sdk.ToABCICode(types.DefaultCodespace, types.CodeInvalidTransaction), res.Code, res.Log)
+
+	msg.TxOut[0].Amount = 301
+	_, res, abort = handler(ctx, sign(keys[0], keys[1], keys[2]), false)
+	require.True(t, abort, "outputs not covered by the inputs")
+}
+
+// Tests that spends are only valid under the sign domain of the chain
+func TestSignDomain(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+	handler := NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0)
+
+	privKey, _ := ethcrypto.GenerateKey()
+	addr := utils.PrivKeyToAddress(privKey)
+	position := types.NewPlasmaPosition(1, 0, 0, 0)
+	mapper.ReceiveUTXO(ctx, utxo.NewUTXO(addr.Bytes(), 100, types.Denom, position))
+
+	msg := types.SpendMsg{Blknum0: 1, Owner0: addr, Newowner0: utils.GenerateAddress(), Amount0: 100}
+	sign := func(domain types.SignDomain) types.BaseTx {
+		var sigs [2][65]byte
+		sig, _ := ethcrypto.Sign(utils.SignHash(domain.SignHash(msg.GetSignBytes())), privKey)
+		copy(sigs[0][:], sig)
+		return types.NewBaseTx(msg, sigs)
+	}
+
+	rootchain := utils.GenerateAddress()
+	domain := types.NewSignDomain(types.SignVersionDomain, "plasma", rootchain)
+	plasmaStore.Set(ctx, utils.SignDomainKey, domain.Bytes())
+
+	_, res, abort := handler(ctx, sign(types.SignDomain{}), false)
+	require.True(t, abort, "accepted a signature over the legacy bytes")
+	require.Equal(t, sdk.ToABCICode(sdk.CodespaceRoot, sdk.CodeUnauthorized), res.Code, res.Log)
+
+	_, res, abort = handler(ctx, sign(types.NewSignDomain(types.SignVersionDomain, "testnet", rootchain)), false)
+	require.True(t, abort, "accepted a signature of another chain")
+
+	_, res, abort = handler(ctx, sign(domain), false)
+	require.False(t, abort, res.Log)
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+
+	types "github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/x/kvstore"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+)
+
+// MempoolConfig bounds the spends admitted into the mempool of a node
+type MempoolConfig struct {
+	// Maximum number of spends admitted at once. Unbounded if 0. Must be below the size of the
+	// tendermint mempool, which rejects transactions without checking them once it is full
+	Size int
+
+	// Number of failed spends signed by an input owner after which further spends of the owner
+	// are rejected. Unlimited if 0
+	MaxInvalid int
+
+	// Number of blocks an owner is rejected for, counted from the owner's first failed spend.
+	// Failed spends are forgotten afterwards
+	Window int64
+}
+
+// DefaultMempoolConfig leaves room for 1000 transactions in the default tendermint mempool
+func DefaultMempoolConfig() MempoolConfig {
+	return MempoolConfig{
+		Size:       4000,
+		MaxInvalid: 10,
+		Window:     100,
+	}
+}
+
+type pendingTx struct {
+	hash    string
+	feeRate float64
+	evicted bool
+
+	// height at which the spend was last admitted, rechecked or evicted
+	checkedAt int64
+
+	// position in the fee rate heap, -1 once evicted
+	index int
+}
+
+// feeRates is a min-heap of the admitted spends by fee rate
+type feeRates []*pendingTx
+
+func (f feeRates) Len() int           { return len(f) }
+func (f feeRates) Less(i, j int) bool { return f[i].feeRate < f[j].feeRate }
+
+func (f feeRates) Swap(i, j int) {
+	f[i], f[j] = f[j], f[i]
+	f[i].index = i
+	f[j].index = j
+}
+
+func (f *feeRates) Push(x interface{}) {
+	pending := x.(*pendingTx)
+	pending.index = len(*f)
+	*f = append(*f, pending)
+}
+
+func (f *feeRates) Pop() interface{} {
+	old := *f
+	pending := old[len(old)-1]
+	old[len(old)-1] = nil
+	pending.index = -1
+	*f = old[:len(old)-1]
+	return pending
+}
+
+type offender struct {
+	invalid int
+	since   int64
+}
+
+// Mempool tracks the spends admitted into the tendermint mempool to bound its size by fee rate.
+// It only decides which spends are admitted and kept: tendermint 0.26 reaps its mempool in
+// arrival order and offers the application no way to reorder it, so spends cannot be ordered
+// by fee rate within a block. An evicted spend stays in the tendermint mempool, and can be
+// included in a block, until it fails its recheck after the next block
+type Mempool struct {
+	config MempoolConfig
+	lock   *sync.Mutex
+
+	txs       map[string]*pendingTx
+	admitted  feeRates
+	offenders map[common.Address]*offender
+	height    int64
+}
+
+// NewMempool creates an empty mempool
+func NewMempool(config MempoolConfig) *Mempool {
+	return &Mempool{
+		config:    config,
+		lock:      &sync.Mutex{},
+		txs:       make(map[string]*pendingTx),
+		offenders: make(map[common.Address]*offender),
+	}
+}
+
+// NewMempoolAnteHandler wraps `anteHandler` to admit spends into the mempool by fee rate. Once
+// `mempool` is full, a spend is only admitted by evicting the spend paying the lowest fee rate,
+// which then fails its recheck after the next block. Spends are not reordered within a block.
+// Input owners whose signed spends keep failing the checks are rejected for a window of blocks. Fees of legacy spends are derived from
+// the inputs in `utxoMapper`, signatures are checked under the sign domain recorded in `plasmaStore`
+func NewMempoolAnteHandler(anteHandler sdk.AnteHandler, utxoMapper utxo.Mapper, plasmaStore kvstore.KVStore, mempool *Mempool) sdk.AnteHandler {
+	return func(
+		ctx sdk.Context, tx sdk.Tx, simulate bool,
+	) (_ sdk.Context, _ sdk.Result, abort bool) {
+
+		spendTx, ok := tx.(types.SpendTx)
+		if !ok || simulate {
+			return anteHandler(ctx, tx, simulate)
+		}
+
+		hash := string(tmhash.Sum(ctx.TxBytes()))
+		if !ctx.IsCheckTx() {
+			mempool.delivered(hash)
+			return anteHandler(ctx, tx, simulate)
+		}
+
+		return mempool.checkTx(ctx, hash, utxoMapper, SignDomain(ctx, plasmaStore), spendTx, anteHandler)
+	}
+}
+
+func (mp *Mempool) checkTx(ctx sdk.Context, hash string, utxoMapper utxo.Mapper, domain types.SignDomain, tx types.SpendTx, anteHandler sdk.AnteHandler) (sdk.Context, sdk.Result, bool) {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	if ctx.BlockHeight() != mp.height {
+		mp.height = ctx.BlockHeight()
+		mp.forgetOffenders()
+		mp.forgetStale()
+	}
+
+	// admitted spends are rechecked after every block
+	if pending, ok := mp.txs[hash]; ok {
+		if pending.evicted {
+			mp.remove(hash)
+			return ctx, utxo.ErrInvalidFee(utxo.DefaultCodespace, "evicted by spends paying a higher fee rate").Result(), true
+		}
+
+		newCtx, res, abort := anteHandler(ctx, tx, false)
+		if abort {
+			mp.remove(hash)
+		} else {
+			pending.checkedAt = mp.height
+		}
+		return newCtx, res, abort
+	}
+
+	spend := tx.GetSpend()
+	owners := make([]common.Address, len(spend.GetSigners()))
+	for i, signer := range spend.GetSigners() {
+		owners[i] = common.BytesToAddress(signer)
+	}
+
+	for _, owner := range owners {
+		if mp.limited(owner) {
+			return ctx, types.ErrInvalidTransaction(types.DefaultCodespace, fmt.Sprintf("too many invalid transactions from %X", owner.Bytes())).Result(), true
+		}
+	}
+
+	newCtx, res, abort := anteHandler(ctx, tx, false)
+	if abort {
+		// only failures signed by the owner count against it, so that
+		// spends cannot be blocked by forging failures in their name
+		signBytes := spend.GetSignBytes()
+		sigs := tx.SignerSignatures()
+		recorded := make(map[common.Address]bool)
+		for i, owner := range owners {
+			if i < len(sigs) && !recorded[owner] && processSig(domain, owner, sigs[i], signBytes).IsOK() {
+				mp.recordInvalid(owner)
+				recorded[owner] = true
+			}
+		}
+		return newCtx, res, abort
+	}
+
+	feeRate := float64(SpendFee(ctx, utxoMapper, spend)) / float64(len(ctx.TxBytes()))
+	if mp.config.Size > 0 && mp.admitted.Len() >= mp.config.Size {
+		if mp.admitted.Len() == 0 || mp.admitted[0].feeRate >= feeRate {
+			return ctx, utxo.ErrInvalidFee(utxo.DefaultCodespace, "mempool is full, a higher fee rate is required").Result(), true
+		}
+		lowest := heap.Pop(&mp.admitted).(*pendingTx)
+		lowest.evicted = true
+		lowest.checkedAt = mp.height
+	}
+
+	pending := &pendingTx{hash: hash, feeRate: feeRate, checkedAt: mp.height}
+	mp.txs[hash] = pending
+	heap.Push(&mp.admitted, pending)
+	return newCtx, res, abort
+}
+
+// Removes a spend included in a block, evicted or not
+func (mp *Mempool) delivered(hash string) {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+
+	mp.remove(hash)
+}
+
+func (mp *Mempool) remove(hash string) {
+	pending, ok := mp.txs[hash]
+	if !ok {
+		return
+	}
+	if !pending.evicted {
+		heap.Remove(&mp.admitted, pending.index)
+	}
+	delete(mp.txs, hash)
+}
+
+func (mp *Mempool) limited(owner common.Address) bool {
+	o, ok := mp.offenders[owner]
+	return ok && mp.config.MaxInvalid > 0 && o.invalid >= mp.config.MaxInvalid
+}
+
+func (mp *Mempool) recordInvalid(owner common.Address) {
+	o, ok := mp.offenders[owner]
+	if !ok {
+		o = &offender{since: mp.height}
+		mp.offenders[owner] = o
+	}
+	o.invalid++
+}
+
+// Forgets spends, evicted or not, that were not rechecked at the height following their last
+// check. Tendermint rechecks every spend it keeps after each block, so these were dropped from
+// its mempool without being delivered and would otherwise be tracked forever
+func (mp *Mempool) forgetStale() {
+	for hash, pending := range mp.txs {
+		if pending.checkedAt < mp.height-1 {
+			mp.remove(hash)
+		}
+	}
+}
+
+// Forgets the failed spends of owners whose window has passed
+func (mp *Mempool) forgetOffenders() {
+	for owner, o := range mp.offenders {
+		if mp.height-o.since >= mp.config.Window {
+			delete(mp.offenders, owner)
+		}
+	}
+}
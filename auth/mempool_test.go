@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	types "github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	utils "github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	rlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// Creates an owner of a utxo of 100 at block `blknum` along with a signed spend of it paying `fee`
+func mempoolSpend(ctx sdk.Context, mapper utxo.Mapper, blknum, fee uint64) (*ecdsa.PrivateKey, types.BaseTx) {
+	privKey, _ := ethcrypto.GenerateKey()
+	addr := utils.PrivKeyToAddress(privKey)
+	mapper.ReceiveUTXO(ctx, utxo.NewUTXO(addr.Bytes(), 100, types.Denom, types.NewPlasmaPosition(blknum, 0, 0, 0)))
+
+	msg := types.SpendMsg{Blknum0: blknum, Owner0: addr, Newowner0: utils.GenerateAddress(), Amount0: 100 - fee}
+	return privKey, GetTx(msg, privKey, nil, false)
+}
+
+func checkMempoolTx(handler sdk.AnteHandler, ctx sdk.Context, height int64, tx types.BaseTx) (sdk.Result, bool) {
+	txBytes, _ := rlp.EncodeToBytes(tx)
+	checkCtx := sdk.NewContext(ctx.MultiStore(), abci.Header{Height: height}, true, log.NewNopLogger()).WithTxBytes(txBytes)
+	_, res, abort := handler(checkCtx, tx, false)
+	return res, abort
+}
+
+func TestMempoolEviction(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+	mempool := NewMempool(MempoolConfig{Size: 1})
+	handler := NewMempoolAnteHandler(NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0), mapper, plasmaStore, mempool)
+
+	_, lowTx := mempoolSpend(ctx, mapper, 1, 1)
+	_, highTx := mempoolSpend(ctx, mapper, 2, 5)
+	_, freeTx := mempoolSpend(ctx, mapper, 3, 0)
+
+	res, abort := checkMempoolTx(handler, ctx, 1, lowTx)
+	require.False(t, abort, res.Log)
+
+	// a higher fee rate takes the place of the lowest
+	res, abort = checkMempoolTx(handler, ctx, 1, highTx)
+	require.False(t, abort, res.Log)
+
+	res, abort = checkMempoolTx(handler, ctx, 1, freeTx)
+	require.True(t, abort, "admitted a spend into a full mempool")
+	require.Equal(t, sdk.ToABCICode(utxo.DefaultCodespace, utxo.CodeInvalidFee), res.Code, res.Log)
+
+	// the evicted spend fails its recheck, the admitted one passes
+	res, abort = checkMempoolTx(handler, ctx, 2, lowTx)
+	require.True(t, abort, "evicted spend passed its recheck")
+	res, abort = checkMempoolTx(handler, ctx, 2, highTx)
+	require.False(t, abort, res.Log)
+
+	// delivered spends leave the mempool
+	txBytes, _ := rlp.EncodeToBytes(highTx)
+	_, res, abort = handler(ctx.WithTxBytes(txBytes), highTx, false)
+	require.False(t, abort, res.Log)
+
+	res, abort = checkMempoolTx(handler, ctx, 3, freeTx)
+	require.False(t, abort, res.Log)
+	require.Len(t, mempool.txs, 1)
+}
+
+// Tests that spends are forgotten once delivered or no longer rechecked
+func TestMempoolForgetEvicted(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+	mempool := NewMempool(MempoolConfig{Size: 1})
+	handler := NewMempoolAnteHandler(NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0), mapper, plasmaStore, mempool)
+
+	_, lowTx := mempoolSpend(ctx, mapper, 1, 1)
+	_, midTx := mempoolSpend(ctx, mapper, 2, 3)
+	_, highTx := mempoolSpend(ctx, mapper, 3, 5)
+
+	// an evicted spend reaped before its recheck is removed on delivery
+	res, abort := checkMempoolTx(handler, ctx, 1, lowTx)
+	require.False(t, abort, res.Log)
+	res, abort = checkMempoolTx(handler, ctx, 1, midTx)
+	require.False(t, abort, res.Log)
+	require.True(t, mempool.txs[string(tmhash.Sum(mustEncode(lowTx)))].evicted)
+
+	_, res, abort = handler(ctx.WithTxBytes(mustEncode(lowTx)), lowTx, false)
+	require.False(t, abort, res.Log)
+	require.Len(t, mempool.txs, 1)
+
+	// spends that are never rechecked, evicted or not, are forgotten after the following height
+	res, abort = checkMempoolTx(handler, ctx, 2, highTx)
+	require.False(t, abort, res.Log)
+	require.Len(t, mempool.txs, 2)
+	res, abort = checkMempoolTx(handler, ctx, 4, highTx)
+	require.False(t, abort, res.Log)
+	require.Len(t, mempool.txs, 1)
+	require.Equal(t, 1, mempool.admitted.Len())
+}
+
+func mustEncode(tx types.BaseTx) []byte {
+	txBytes, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		panic(err)
+	}
+	return txBytes
+}
+
+func TestMempoolRateLimit(t *testing.T) {
+	ctx, mapper, plasmaStore := setup()
+	mempool := NewMempool(MempoolConfig{MaxInvalid: 2, Window: 10})
+	handler := NewMempoolAnteHandler(NewAnteHandler(mapper, plasmaStore, nil, 0, 0, 0), mapper, plasmaStore, mempool)
+
+	privKey, tx := mempoolSpend(ctx, mapper, 1, 0)
+
+	// spends not signed by the owner do not count against it
+	forgerKey, _ := ethcrypto.GenerateKey()
+	invalid := tx.Msg
+	invalid.Amount0 = 1000
+	for i := 0; i < 3; i++ {
+		_, abort := checkMempoolTx(handler, ctx, 1, GetTx(invalid, forgerKey, nil, false))
+		require.True(t, abort)
+	}
+	res, abort := checkMempoolTx(handler, ctx, 1, tx)
+	require.False(t, abort, res.Log)
+
+	// signed spends failing the checks do
+	for i := 0; i < 2; i++ {
+		_, abort = checkMempoolTx(handler, ctx, 2, GetTx(invalid, privKey, nil, false))
+		require.True(t, abort)
+	}
+
+	msg := tx.Msg
+	msg.Amount0 = 99
+	res, abort = checkMempoolTx(handler, ctx, 3, GetTx(msg, privKey, nil, false))
+	require.True(t, abort, "admitted a spend of a rate limited owner")
+	require.Equal(t, sdk.ToABCICode(types.DefaultCodespace, 204), res.Code, res.Log)
+
+	// failures are forgotten after the window
+	res, abort = checkMempoolTx(handler, ctx, 12, GetTx(msg, privKey, nil, false))
+	require.False(t, abort, res.Log)
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
-	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	rlp "github.com/ethereum/go-ethereum/rlp"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 
@@ -69,7 +68,101 @@ func (ctx ClientContext) SignBuildBroadcast(addrs [2]common.Address, msg types.S
 	return ctx.BroadcastTx(txBytes)
 }
 
+// sign and build the spend transaction from the msg, in the legacy form if it fits.
+// `inputTotal` is the amount of the outputs spent by the msg
+func (ctx ClientContext) SignBuildBroadcastSpend(msg types.MultiSpendMsg, inputTotal uint64, dir string) (res *ctypes.ResultBroadcastTxCommit, err error) {
+	domain, err := ctx.GetSignDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := types.NewSpendTx(msg, inputTotal, func(owner common.Address, signBytes []byte) (sig [65]byte, err error) {
+		bz, err := ctx.signHash(owner, domain.SignHash(signBytes), dir)
+		if err != nil {
+			return sig, err
+		}
+		copy(sig[:], bz)
+		return sig, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	txBytes, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.BroadcastTx(txBytes)
+}
+
+// sign the msg under the sign domain of the chain
 func (ctx ClientContext) GetSignature(addr common.Address, msg utxo.SpendMsg, dir string) (sig []byte, err error) {
+	domain, err := ctx.GetSignDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.signHash(addr, domain.SignHash(msg.GetSignBytes()), dir)
+}
+
+// query the domain spends are signed under. Chains without a recorded domain sign the legacy bytes
+func (ctx ClientContext) GetSignDomain() (domain types.SignDomain, err error) {
+	bz, err := ctx.QueryStore(utils.SignDomainKey, ctx.PlasmaStore)
+	if err != nil || len(bz) == 0 {
+		return types.SignDomain{}, err
+	}
+
+	return types.DecodeSignDomain(bz)
+}
+
+// sign the msg of a rootchain deposit or exit as the operator, under the sign domain of the chain
+func (ctx ClientContext) GetOperatorSignature(addr common.Address, signBytes []byte, dir string) (sig [65]byte, err error) {
+	domain, err := ctx.GetSignDomain()
+	if err != nil {
+		return sig, err
+	}
+
+	bz, err := ctx.signHash(addr, domain.SignHash(signBytes), dir)
+	if err != nil {
+		return sig, err
+	}
+	copy(sig[:], bz)
+	return sig, nil
+}
+
+// sign the confirmation hash of a transaction included in a block
+func (ctx ClientContext) GetConfirmSignature(addr common.Address, confirmationHash []byte, dir string) (sig []byte, err error) {
+	return ctx.signHash(addr, confirmationHash, dir)
+}
+
+// sign and build the confirmation signature transaction for the transaction at (blknum, txindex)
+func (ctx ClientContext) SignBuildBroadcastConfirmSigs(addrs [2]common.Address, blknum uint64, txindex uint16, confirmationHash []byte, dir string) (res *ctypes.ResultBroadcastTxCommit, err error) {
+	var sigs [2][65]byte
+	for i, addr := range addrs {
+		if !utils.ValidAddress(addr) {
+			continue
+		}
+
+		sig, err := ctx.GetConfirmSignature(addr, confirmationHash, dir)
+		if err != nil {
+			return nil, err
+		}
+		copy(sigs[i][:], sig)
+	}
+
+	tx := types.NewConfirmSigTx(types.NewConfirmSigMsg(blknum, txindex, sigs))
+
+	txBytes, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.BroadcastTx(txBytes)
+}
+
+// sign the hash with the account's key in the keystore, prefixed as an ethereum signed message
+func (ctx ClientContext) signHash(addr common.Address, hash []byte, dir string) (sig []byte, err error) {
 
 	passphrase, err := ctx.GetPassphraseFromStdin(addr)
 	if err != nil {
@@ -85,8 +178,6 @@ func (ctx ClientContext) GetSignature(addr common.Address, msg utxo.SpendMsg, di
 		return nil, err
 	}
 
-	bz := msg.GetSignBytes()
-	hash := ethcrypto.Keccak256(bz)
 	signHash := utils.SignHash(hash)
 
 	sig, err = ks.SignHashWithPassphrase(acct, passphrase, signHash)
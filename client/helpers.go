@@ -53,6 +53,24 @@ func BuildMsg(inaddr0, inaddr1, addr0, addr1 common.Address, position0, position
 	}
 }
 
+// Build MultiSpendMsg of the spend paying `fee`
+// Unused inputs and outputs of the spend are left out
+func BuildMultiSpendMsg(msg types.SpendMsg, fee uint64) types.MultiSpendMsg {
+	inputs := []types.TxInput{
+		types.NewTxInput(types.NewPlasmaPosition(msg.Blknum0, msg.Txindex0, msg.Oindex0, msg.DepositNum0), msg.Owner0),
+	}
+	if position := types.NewPlasmaPosition(msg.Blknum1, msg.Txindex1, msg.Oindex1, msg.DepositNum1); position.IsValid() {
+		inputs = append(inputs, types.NewTxInput(position, msg.Owner1))
+	}
+
+	outputs := []types.TxOutput{{msg.Newowner0, msg.Amount0}}
+	if msg.Amount1 != 0 {
+		outputs = append(outputs, types.TxOutput{msg.Newowner1, msg.Amount1})
+	}
+
+	return types.NewMultiSpendMsg(inputs, outputs, fee)
+}
+
 // initialize a keystore in the specified directory
 func GetKeyStore(dir string) *keystore.KeyStore {
 	if ks == nil {
@@ -113,12 +131,17 @@ func ParsePositions(posStr string) (position [2]types.PlasmaPosition, err error)
 	return position, nil
 }
 
+// Parses the two output amounts followed by the fee
 // Amounts will default to 0 if not provided
-func ParseAmounts(amtStr string) (amount [2]uint64, err error) {
-	for i, v := range strings.Split(amtStr, ",") {
+func ParseAmounts(amtStr string) (amount [3]uint64, err error) {
+	values := strings.Split(amtStr, ",")
+	if len(values) > len(amount) {
+		return [3]uint64{}, errors.New("at most two amounts and a fee can be provided")
+	}
+	for i, v := range values {
 		amount[i], err = strconv.ParseUint(strings.TrimSpace(v), 0, 64)
 		if err != nil {
-			return [2]uint64{}, err
+			return [3]uint64{}, err
 		}
 	}
 	return amount, nil
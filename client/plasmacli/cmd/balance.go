@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/app"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
-	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 )
@@ -24,22 +26,58 @@ var balanceCmd = &cobra.Command{
 
 		ethAddr := common.HexToAddress(args[0])
 
-		res, err2 := ctx.QuerySubspace(ethAddr.Bytes(), ctx.UTXOStore)
-		if err2 != nil {
-			return err2
-		}
-
-		for _, pair := range res {
-			var resUTXO utxo.UTXO
-			err := ctx.Codec.UnmarshalBinaryBare(pair.Value, &resUTXO)
+		totals := make(map[string]uint64)
+		var denoms []string
+		var seen int
+		for page := 1; ; page++ {
+			res, err := queryOwnerUTXOs(ctx, ethAddr, page)
 			if err != nil {
 				return err
 			}
-			if resUTXO.Valid {
-				fmt.Printf("Position: %v \nAmount: %d \n", resUTXO.Position, resUTXO.Amount)
+
+			for _, output := range res.UTXOs {
+				fmt.Printf("Position: %v \nAmount: %d \nDenom: %s \n", output.Position, output.Amount, output.Denom)
+				if _, ok := totals[output.Denom]; !ok {
+					denoms = append(denoms, output.Denom)
+				}
+				totals[output.Denom] += output.Amount
+			}
+
+			seen += len(res.UTXOs)
+			if len(res.UTXOs) == 0 || seen >= res.Total {
+				break
 			}
 		}
 
+		sort.Strings(denoms)
+		for _, denom := range denoms {
+			fmt.Printf("Total %s: %d \n", denom, totals[denom])
+		}
+
 		return nil
 	},
 }
+
+// query a page of the unspent outputs of `owner`
+func queryOwnerUTXOs(ctx context.ClientContext, owner common.Address, page int) (*app.OwnerUTXOsResponse, error) {
+	data, err := ctx.Codec.MarshalJSON(app.OwnerUTXOsParams{
+		Owner: owner.Hex(),
+		Page:  page,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("custom/%s/%s", app.QueryRoute, app.QueryOwnerUTXOs)
+	res, err := ctx.QueryWithData(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos app.OwnerUTXOsResponse
+	if err := ctx.Codec.UnmarshalJSON(res, &utxos); err != nil {
+		return nil, err
+	}
+
+	return &utxos, nil
+}
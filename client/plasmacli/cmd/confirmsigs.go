@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(confirmSigsCmd)
+	confirmSigsCmd.Flags().String(client.FlagNode, "tcp://localhost:26657", "<host>:<port> to tendermint rpc interface for this chain")
+}
+
+var confirmSigsCmd = &cobra.Command{
+	Use:   "confirm-sigs <blknum.txindex>",
+	Short: "Query the confirmation signatures of a transaction",
+	Long:  "Query the confirmation signatures of a transaction in the form required to exit its outputs on the rootchain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// bound when run so the flags of other commands are not shadowed
+		viper.BindPFlags(cmd.Flags())
+		ctx := context.NewClientContextFromViper()
+
+		position, err := client.ParsePositions(args[0])
+		if err != nil {
+			return err
+		}
+		blknum, txindex := position[0].Blknum, position[0].TxIndex
+
+		sigs, err := queryConfirmSigs(ctx, blknum, txindex)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Confirmation Signatures: 0x%x\n", sigs)
+		return nil
+	},
+}
+
+// query the confirmation signatures of the transaction at (blknum, txindex)
+func queryConfirmSigs(ctx context.ClientContext, blknum uint64, txindex uint16) ([]byte, error) {
+	sigs, err := ctx.QueryStore(utils.ConfirmSigKey(blknum, txindex), ctx.PlasmaStore)
+	if err != nil {
+		return nil, err
+	}
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("no confirmation signatures for block %d, index %d", blknum, txindex)
+	}
+
+	return sigs, nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/app"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(proofCmd)
+	proofCmd.Flags().String(client.FlagNode, "tcp://localhost:26657", "<host>:<port> to tendermint rpc interface for this chain")
+}
+
+var proofCmd = &cobra.Command{
+	Use:   "proof <blknum.txindex>",
+	Short: "Query the merkle inclusion proof of a transaction",
+	Long:  "Query the transaction bytes, merkle proof and block root needed to exit or challenge with a transaction on the rootchain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// bound when run so the flags of other commands are not shadowed
+		viper.BindPFlags(cmd.Flags())
+		ctx := context.NewClientContextFromViper()
+
+		position, err := client.ParsePositions(args[0])
+		if err != nil {
+			return err
+		}
+
+		proof, err := queryProof(ctx, position[0].Blknum, position[0].TxIndex)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Transaction Bytes: 0x%x\n", proof.TxBytes)
+		fmt.Printf("Proof: 0x%x\n", proof.Proof)
+		fmt.Printf("Root: 0x%x\n", proof.Root)
+		fmt.Printf("Total Transactions: %d\n", proof.TotalTxs)
+		return nil
+	},
+}
+
+// query the inclusion proof of the transaction at (blknum, txindex)
+func queryProof(ctx context.ClientContext, blknum uint64, txindex uint16) (*app.ProofResponse, error) {
+	data, err := ctx.Codec.MarshalJSON(app.ProofParams{
+		Blknum:  blknum,
+		Txindex: txindex,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("custom/%s/%s", app.QueryRoute, app.QueryProof)
+	res, err := ctx.QueryWithData(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var proof app.ProofResponse
+	if err := ctx.Codec.UnmarshalJSON(res, &proof); err != nil {
+		return nil, err
+	}
+
+	return &proof, nil
+}
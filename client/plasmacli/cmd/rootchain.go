@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagEthNode   = "eth-node"
+	flagRootchain = "rootchain"
+	flagBond      = "bond"
+
+	// minExitBond set by the contract's constructor. The contract does not expose it
+	defaultExitBond = "10000"
+)
+
+func init() {
+	rootCmd.AddCommand(rootchainCmd)
+	rootchainCmd.PersistentFlags().String(flagEthNode, "ws://127.0.0.1:8545", "<host>:<port> of the ethereum node")
+	rootchainCmd.PersistentFlags().String(flagRootchain, "", "Address of the rootchain contract")
+	rootchainCmd.PersistentFlags().String(client.FlagNode, "tcp://localhost:26657", "<host>:<port> to tendermint rpc interface for this chain")
+	rootchainCmd.PersistentFlags().String(client.FlagAddress, "", "Address to sign with")
+}
+
+var rootchainCmd = &cobra.Command{
+	Use:   "rootchain",
+	Short: "Interact with the rootchain contract",
+	Long:  "Deposit, exit, challenge, finalize and withdraw on the rootchain. Transactions are signed with accounts in the keystore",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound when run so the flags of other commands are not shadowed
+		return viper.BindPFlags(cmd.Flags())
+	},
+}
+
+// connect to the rootchain contract
+func rootchainSession() (*contracts.PlasmaMVPSession, *ethclient.Client, error) {
+	rootchain := viper.GetString(flagRootchain)
+	if !common.IsHexAddress(rootchain) {
+		return nil, nil, errors.New("must provide the address of the rootchain contract")
+	}
+
+	ec, err := ethclient.Dial(viper.GetString(flagEthNode))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contract, err := contracts.NewPlasmaMVP(common.HexToAddress(rootchain), ec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := &contracts.PlasmaMVPSession{
+		Contract: contract,
+		CallOpts: bind.CallOpts{
+			Pending: true,
+		},
+	}
+
+	return session, ec, nil
+}
+
+// connect to the rootchain contract with transactions signed by the account given by the address flag.
+// Gas is estimated per transaction
+func rootchainTransactor(ctx context.ClientContext, value *big.Int) (*contracts.PlasmaMVPSession, *ethclient.Client, error) {
+	addr, err := client.StrToAddress(viper.GetString(client.FlagAddress))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return accountTransactor(ctx, addr, value)
+}
+
+// connect to the rootchain contract with transactions signed by `addr`
+func accountTransactor(ctx context.ClientContext, addr common.Address, value *big.Int) (*contracts.PlasmaMVPSession, *ethclient.Client, error) {
+	session, ec, err := rootchainSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ks := client.GetKeyStore(viper.GetString(FlagHomeDir))
+	acct, err := ks.Find(accounts.Account{Address: addr})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	passphrase, err := ctx.GetPassphraseFromStdin(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session.TransactOpts = bind.TransactOpts{
+		From:  addr,
+		Value: value,
+		Signer: func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != addr {
+				return nil, errors.New("not authorized to sign this account")
+			}
+			sig, err := ks.SignHashWithPassphrase(acct, passphrase, signer.Hash(tx).Bytes())
+			if err != nil {
+				return nil, err
+			}
+			return tx.WithSignature(signer, sig)
+		},
+	}
+
+	return session, ec, nil
+}
+
+// block until the transaction is mined and report if it reverted
+func waitMined(ec *ethclient.Client, tx *types.Transaction) error {
+	fmt.Printf("Sent transaction %s\n", tx.Hash().Hex())
+
+	receipt, err := bind.WaitMined(gocontext.Background(), ec, tx)
+	if err != nil {
+		return err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	}
+
+	fmt.Printf("Transaction mined. Gas used: %d\n", receipt.GasUsed)
+	return nil
+}
+
+// parse a wei amount
+func parseWei(amount string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount: %s", amount)
+	}
+	return value, nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootchainCmd.AddCommand(rootchainBalanceCmd)
+}
+
+var rootchainBalanceCmd = &cobra.Command{
+	Use:   "balance <address>",
+	Short: "Query the withdrawable balance of an address on the rootchain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, err := client.StrToAddress(args[0])
+		if err != nil {
+			return err
+		}
+
+		session, _, err := rootchainSession()
+		if err != nil {
+			return err
+		}
+
+		balance, err := session.BalanceOf(addr)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Withdrawable Balance: %s wei\n", balance)
+		fmt.Printf("Exit Bond: %s wei\n", defaultExitBond)
+		return nil
+	},
+}
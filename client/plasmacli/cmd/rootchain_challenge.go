@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	rlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootchainCmd.AddCommand(challengeCmd)
+}
+
+var challengeCmd = &cobra.Command{
+	Use:   "challenge <exiting blknum.txindex.oindex.depositnonce> <challenging blknum.txindex>",
+	Short: "Challenge an exit with the transaction that spent it",
+	Long:  "Challenge an exit with the sidechain transaction that spent the exiting output. The transaction bytes, merkle proof and the exit owner's confirmation signature are fetched from the sidechain. The bond is awarded to the signing address",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		exiting, err := client.ParsePositions(args[0])
+		if err != nil {
+			return err
+		}
+		challenging, err := client.ParsePositions(args[1])
+		if err != nil {
+			return err
+		}
+		exitingPos, challengingPos := exiting[0], challenging[0]
+
+		proof, err := queryProof(ctx, challengingPos.Blknum, challengingPos.TxIndex)
+		if err != nil {
+			return err
+		}
+
+		confirmSigs, err := queryConfirmSigs(ctx, challengingPos.Blknum, challengingPos.TxIndex)
+		if err != nil {
+			return err
+		}
+
+		session, ec, err := rootchainTransactor(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		tx, err := challengeExit(session, exitingPos, challengingPos, proof.TxBytes, proof.Proof, confirmSigs)
+		if err != nil {
+			return err
+		}
+
+		return waitMined(ec, tx)
+	},
+}
+
+// challenge the exit of `exitingPos` with the transaction at `challengingPos` that spends it
+func challengeExit(session *contracts.PlasmaMVPSession, exitingPos, challengingPos types.PlasmaPosition,
+	txBytes, proof, confirmSigs []byte) (*ethtypes.Transaction, error) {
+
+	var spend types.BaseTx
+	if err := rlp.DecodeBytes(txBytes, &spend); err != nil {
+		return nil, err
+	}
+
+	// the exit owner's confirmation signature is at the index of the spent input
+	index := spend.Msg.InputIndex(exitingPos)
+	if index == -1 {
+		return nil, fmt.Errorf("transaction at block %d, index %d does not spend %v", challengingPos.Blknum, challengingPos.TxIndex, exitingPos)
+	}
+	if len(confirmSigs) < 65*(index+1) {
+		return nil, fmt.Errorf("missing confirmation signature for input %d", index)
+	}
+
+	exitingTxPos := [4]*big.Int{
+		new(big.Int).SetUint64(exitingPos.Blknum),
+		new(big.Int).SetUint64(uint64(exitingPos.TxIndex)),
+		new(big.Int).SetUint64(uint64(exitingPos.Oindex)),
+		new(big.Int).SetUint64(exitingPos.DepositNum),
+	}
+	challengingTxPos := [2]*big.Int{
+		new(big.Int).SetUint64(challengingPos.Blknum),
+		new(big.Int).SetUint64(uint64(challengingPos.TxIndex)),
+	}
+
+	return session.ChallengeExit(exitingTxPos, challengingTxPos, txBytes, proof, confirmSigs[65*index:65*(index+1)])
+}
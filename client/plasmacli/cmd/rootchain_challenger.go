@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	gocontext "context"
+	"fmt"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/app"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagFromBlock = "from-block"
+
+func init() {
+	rootchainCmd.AddCommand(challengerCmd)
+	challengerCmd.Flags().Int64(flagFromBlock, -1, "Ethereum block to check exits from before watching for new ones. Only new exits are checked if negative")
+}
+
+var challengerCmd = &cobra.Command{
+	Use:   "challenger",
+	Short: "Challenge exits of outputs that have been spent on the sidechain",
+	Long:  "Watch the rootchain for started exits and challenge every exit of an output that was spent on the sidechain. A challenge requires the exit owner's confirmation signature of the spending transaction. Bonds are awarded to the signing address. Requires a websocket connection to the ethereum node",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		session, ec, err := rootchainTransactor(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		txExits := make(chan *contracts.PlasmaMVPStartedTransactionExit)
+		txSub, err := session.Contract.WatchStartedTransactionExit(&bind.WatchOpts{}, txExits)
+		if err != nil {
+			return err
+		}
+		defer txSub.Unsubscribe()
+
+		depositExits := make(chan *contracts.PlasmaMVPStartedDepositExit)
+		depositSub, err := session.Contract.WatchStartedDepositExit(&bind.WatchOpts{}, depositExits)
+		if err != nil {
+			return err
+		}
+		defer depositSub.Unsubscribe()
+
+		if from := viper.GetInt64(flagFromBlock); from >= 0 {
+			if err := checkPastExits(ctx, session, ec, uint64(from)); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("Watching for exits")
+		for {
+			select {
+			case exit := <-txExits:
+				position := types.NewPlasmaPosition(exit.Position[0].Uint64(), uint16(exit.Position[1].Uint64()), uint8(exit.Position[2].Uint64()), 0)
+				checkExit(ctx, session, ec, position)
+			case exit := <-depositExits:
+				checkExit(ctx, session, ec, types.NewPlasmaPosition(0, 0, 0, exit.Nonce.Uint64()))
+			case err := <-txSub.Err():
+				return err
+			case err := <-depositSub.Err():
+				return err
+			}
+		}
+	},
+}
+
+// check the exits started since ethereum block `from`
+func checkPastExits(ctx context.ClientContext, session *contracts.PlasmaMVPSession, ec *ethclient.Client, from uint64) error {
+	opts := &bind.FilterOpts{Start: from, Context: gocontext.Background()}
+
+	txExits, err := session.Contract.FilterStartedTransactionExit(opts)
+	if err != nil {
+		return err
+	}
+	for txExits.Next() {
+		exit := txExits.Event
+		position := types.NewPlasmaPosition(exit.Position[0].Uint64(), uint16(exit.Position[1].Uint64()), uint8(exit.Position[2].Uint64()), 0)
+		checkExit(ctx, session, ec, position)
+	}
+	if err := txExits.Error(); err != nil {
+		return err
+	}
+
+	depositExits, err := session.Contract.FilterStartedDepositExit(opts)
+	if err != nil {
+		return err
+	}
+	for depositExits.Next() {
+		checkExit(ctx, session, ec, types.NewPlasmaPosition(0, 0, 0, depositExits.Event.Nonce.Uint64()))
+	}
+	return depositExits.Error()
+}
+
+// challenge the exit of `position` if the output was spent on the sidechain. Failures are reported
+// and do not stop the challenger
+func checkExit(ctx context.ClientContext, session *contracts.PlasmaMVPSession, ec *ethclient.Client, position types.PlasmaPosition) {
+	spend, err := querySpend(ctx, position)
+	if err != nil {
+		fmt.Printf("Could not check exit of %v - %s\n", position, err)
+		return
+	}
+	if !spend.Spent {
+		fmt.Printf("Exit of %v is valid\n", position)
+		return
+	}
+
+	fmt.Printf("Exit of %v was spent at block %d, index %d. Challenging\n", position, spend.Blknum, spend.Txindex)
+	challengingPos := types.NewPlasmaPosition(spend.Blknum, spend.Txindex, 0, 0)
+	tx, err := challengeExit(session, position, challengingPos, spend.TxBytes, spend.Proof, spend.ConfirmSigs)
+	if err != nil {
+		fmt.Printf("Could not challenge exit of %v - %s\n", position, err)
+		return
+	}
+
+	if err := waitMined(ec, tx); err != nil {
+		fmt.Printf("Challenge of exit of %v failed - %s\n", position, err)
+	}
+}
+
+// query the recorded transaction that spent the output at `position`
+func querySpend(ctx context.ClientContext, position types.PlasmaPosition) (*app.SpendResponse, error) {
+	data, err := ctx.Codec.MarshalJSON(app.SpendParams{Position: position})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("custom/%s/%s", app.QueryRoute, app.QuerySpend)
+	res, err := ctx.QueryWithData(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var spend app.SpendResponse
+	if err := ctx.Codec.UnmarshalJSON(res, &spend); err != nil {
+		return nil, err
+	}
+
+	return &spend, nil
+}
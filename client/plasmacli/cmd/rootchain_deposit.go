@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagOwner = "owner"
+
+func init() {
+	rootchainCmd.AddCommand(depositCmd)
+	depositCmd.Flags().String(flagOwner, "", "Owner of the deposit on the sidechain. Defaults to the signing address")
+}
+
+var depositCmd = &cobra.Command{
+	Use:   "deposit <amount>",
+	Short: "Deposit wei into the rootchain contract",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		amount, err := parseWei(args[0])
+		if err != nil {
+			return err
+		}
+
+		session, ec, err := rootchainTransactor(ctx, amount)
+		if err != nil {
+			return err
+		}
+
+		owner := session.TransactOpts.From
+		if ownerStr := viper.GetString(flagOwner); ownerStr != "" {
+			owner, err = client.StrToAddress(ownerStr)
+			if err != nil {
+				return err
+			}
+		}
+
+		tx, err := session.Deposit(owner)
+		if err != nil {
+			return err
+		}
+
+		return waitMined(ec, tx)
+	},
+}
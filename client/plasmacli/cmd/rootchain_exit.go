@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootchainCmd.AddCommand(exitDepositCmd)
+	exitDepositCmd.Flags().String(flagBond, defaultExitBond, "Exit bond in wei. Any excess is added to the withdrawable balance")
+
+	rootchainCmd.AddCommand(exitTxCmd)
+	exitTxCmd.Flags().String(flagBond, defaultExitBond, "Exit bond in wei. Any excess is added to the withdrawable balance")
+}
+
+var exitDepositCmd = &cobra.Command{
+	Use:   "exit-deposit <nonce>",
+	Short: "Start an exit of a deposit",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		nonce, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		bond, err := parseWei(viper.GetString(flagBond))
+		if err != nil {
+			return err
+		}
+
+		session, ec, err := rootchainTransactor(ctx, bond)
+		if err != nil {
+			return err
+		}
+
+		tx, err := session.StartDepositExit(new(big.Int).SetUint64(nonce))
+		if err != nil {
+			return err
+		}
+
+		return waitMined(ec, tx)
+	},
+}
+
+var exitTxCmd = &cobra.Command{
+	Use:   "exit-tx <blknum.txindex.oindex>",
+	Short: "Start an exit of a transaction output",
+	Long:  "Start an exit of a transaction output. The transaction bytes, merkle proof and confirmation signatures are fetched from the sidechain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		position, err := client.ParsePositions(args[0])
+		if err != nil {
+			return err
+		}
+		pos := position[0]
+		if pos.Blknum == 0 {
+			return fmt.Errorf("deposits are exited with exit-deposit")
+		}
+		if pos.IsFee() {
+			return fmt.Errorf("fee outputs have no transaction on the rootchain and must be spent before exiting")
+		}
+
+		proof, err := queryProof(ctx, pos.Blknum, pos.TxIndex)
+		if err != nil {
+			return err
+		}
+
+		confirmSigs, err := queryConfirmSigs(ctx, pos.Blknum, pos.TxIndex)
+		if err != nil {
+			return err
+		}
+
+		bond, err := parseWei(viper.GetString(flagBond))
+		if err != nil {
+			return err
+		}
+
+		session, ec, err := rootchainTransactor(ctx, bond)
+		if err != nil {
+			return err
+		}
+
+		tx, err := startTransactionExit(session, pos, proof.TxBytes, proof.Proof, confirmSigs)
+		if err != nil {
+			return err
+		}
+
+		return waitMined(ec, tx)
+	},
+}
+
+// start an exit of the transaction output at `pos`
+func startTransactionExit(session *contracts.PlasmaMVPSession, pos types.PlasmaPosition, txBytes, proof, confirmSigs []byte) (*ethtypes.Transaction, error) {
+	txPos := [3]*big.Int{
+		new(big.Int).SetUint64(pos.Blknum),
+		new(big.Int).SetUint64(uint64(pos.TxIndex)),
+		new(big.Int).SetUint64(uint64(pos.Oindex)),
+	}
+	return session.StartTransactionExit(txPos, txBytes, proof, confirmSigs)
+}
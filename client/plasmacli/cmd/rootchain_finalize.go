@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagDeposits = "deposits"
+
+func init() {
+	rootchainCmd.AddCommand(finalizeCmd)
+	finalizeCmd.Flags().Bool(flagDeposits, false, "Finalize deposit exits instead of transaction exits")
+}
+
+var finalizeCmd = &cobra.Command{
+	Use:   "finalize",
+	Short: "Finalize exits that have passed the challenge period",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		session, ec, err := rootchainTransactor(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		var tx *types.Transaction
+		if viper.GetBool(flagDeposits) {
+			tx, err = session.FinalizeDepositExits()
+		} else {
+			tx, err = session.FinalizeTransactionExits()
+		}
+		if err != nil {
+			return err
+		}
+
+		return waitMined(ec, tx)
+	},
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/ethereum/go-ethereum/common"
+	rlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootchainCmd.AddCommand(includeDepositCmd)
+	rootchainCmd.AddCommand(includeExitCmd)
+}
+
+var includeDepositCmd = &cobra.Command{
+	Use:   "include-deposit <nonce>",
+	Short: "Include a rootchain deposit in the sidechain so that it can be spent",
+	Long:  "Read the deposit with the given nonce from the rootchain contract and send it to the sidechain, signed by the operator account given by --address. The sidechain only accepts the deposit once it is finalized on the rootchain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		nonce, ok := new(big.Int).SetString(args[0], 10)
+		if !ok || nonce.Sign() <= 0 {
+			return fmt.Errorf("invalid deposit nonce: %s", args[0])
+		}
+
+		session, _, err := rootchainSession()
+		if err != nil {
+			return err
+		}
+
+		deposit, err := session.Deposits(nonce)
+		if err != nil {
+			return err
+		}
+		if deposit.CreatedAt.Sign() == 0 {
+			return fmt.Errorf("deposit %s does not exist", nonce)
+		}
+
+		operator, err := client.StrToAddress(viper.GetString(client.FlagAddress))
+		if err != nil {
+			return err
+		}
+
+		msg := types.NewDepositMsg(nonce.Uint64(), deposit.Owner, deposit.Amount.Uint64(), deposit.EthBlockNum.Uint64())
+		sig, err := ctx.GetOperatorSignature(operator, msg.GetSignBytes(), viper.GetString(FlagHomeDir))
+		if err != nil {
+			return err
+		}
+
+		txBytes, err := rlp.EncodeToBytes(types.NewDepositTx(msg, sig))
+		if err != nil {
+			return err
+		}
+
+		res, err := ctx.BroadcastTx(txBytes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Included deposit %s of %s wei owned by %s at block %d\n", nonce, deposit.Amount, deposit.Owner.Hex(), res.Height)
+		return nil
+	},
+}
+
+var includeExitCmd = &cobra.Command{
+	Use:   "include-exit <blknum.txindex.oindex.depositnonce>",
+	Short: "Mirror the rootchain exit of an output into the sidechain",
+	Long:  "Read the exit of the output at the given position from the rootchain contract and send its state to the sidechain, signed by the operator account given by --address. Started exits mark the output as exited, challenged exits restore it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		position, err := client.ParsePositions(args[0])
+		if err != nil {
+			return err
+		}
+		pos := position[0]
+		if !pos.IsValid() {
+			return fmt.Errorf("invalid position: %s", args[0])
+		}
+
+		session, _, err := rootchainSession()
+		if err != nil {
+			return err
+		}
+
+		var exit struct {
+			Amount    *big.Int
+			CreatedAt *big.Int
+			Owner     common.Address
+			State     uint8
+		}
+		if pos.IsDeposit() {
+			exit, err = session.DepositExits(new(big.Int).SetUint64(pos.DepositNum))
+		} else {
+			exit, err = session.TxExits(exitPriority(pos))
+		}
+		if err != nil {
+			return err
+		}
+
+		var state uint8
+		switch exit.State {
+		case 0:
+			return fmt.Errorf("%v has not been exited", pos)
+		case types.ExitChallenged:
+			state = types.ExitChallenged
+		default: // pending or finalized
+			state = types.ExitStarted
+		}
+
+		operator, err := client.StrToAddress(viper.GetString(client.FlagAddress))
+		if err != nil {
+			return err
+		}
+
+		msg := types.NewExitMsg(pos, exit.Owner, state)
+		sig, err := ctx.GetOperatorSignature(operator, msg.GetSignBytes(), viper.GetString(FlagHomeDir))
+		if err != nil {
+			return err
+		}
+
+		txBytes, err := rlp.EncodeToBytes(types.NewExitTx(msg, sig))
+		if err != nil {
+			return err
+		}
+
+		res, err := ctx.BroadcastTx(txBytes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Mirrored exit of %v at block %d\n", pos, res.Height)
+		return nil
+	},
+}
+
+// priority of a transaction exit in the rootchain contract, which also keys the exit
+func exitPriority(pos types.PlasmaPosition) *big.Int {
+	priority := new(big.Int).SetUint64(pos.Blknum)
+	priority.Mul(priority, big.NewInt(1000000))
+	priority.Add(priority, new(big.Int).SetUint64(uint64(pos.TxIndex)*10+uint64(pos.Oindex)))
+	return priority
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootchainCmd.AddCommand(withdrawCmd)
+}
+
+var withdrawCmd = &cobra.Command{
+	Use:   "withdraw",
+	Short: "Withdraw the balance of finalized exits and returned bonds",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		session, ec, err := rootchainTransactor(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		tx, err := session.Withdraw()
+		if err != nil {
+			return err
+		}
+
+		return waitMined(ec, tx)
+	},
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
 	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"strings"
@@ -79,11 +80,20 @@ var sendTxCmd = &cobra.Command{
 		// Get amounts
 		amtStr := viper.GetString(flagAmounts)
 		amounts, err := client.ParseAmounts(amtStr)
+		if err != nil {
+			return err
+		}
 		if utils.ZeroAddress(addr2) && amounts[1] != 0 {
 			return fmt.Errorf("You are trying to send %d amount to the nil address. Please input the zero address if you would like to burn your amount", amounts[1])
 		}
 		msg := client.BuildMsg(from[0], from[1], addr1, addr2, position[0], position[1], amounts[0], amounts[1])
-		res, err := ctx.SignBuildBroadcast(from, msg, dir)
+		spend := client.BuildMultiSpendMsg(msg, amounts[2])
+		input, err := inputTotal(ctx, spend)
+		if err != nil {
+			return err
+		}
+
+		res, err := ctx.SignBuildBroadcastSpend(spend, input, dir)
 		if err != nil {
 			return err
 		}
@@ -91,3 +101,18 @@ var sendTxCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// the amount of the outputs spent by `spend`. The legacy form pays what its inputs
+// leave unspent as its fee, so the spend is only built if it equals the amounts plus fee
+func inputTotal(ctx context.ClientContext, spend types.MultiSpendMsg) (uint64, error) {
+	var input uint64
+	for _, in := range spend.TxIn {
+		output, err := queryUTXO(ctx, in.Position())
+		if err != nil {
+			return 0, err
+		}
+		input += output.Amount
+	}
+
+	return input, nil
+}
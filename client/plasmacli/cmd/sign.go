@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+	signCmd.Flags().String(client.FlagNode, "tcp://localhost:26657", "<host>:<port> to tendermint rpc interface for this chain")
+}
+
+var signCmd = &cobra.Command{
+	Use:   "sign <blknum.txindex>",
+	Short: "Sign and send confirmation signatures for a transaction",
+	Long:  "Sign and send confirmation signatures for a transaction. Every input owner must be in the keystore. The transaction's block must have been submitted to the rootchain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// bound when run so the flags of other commands are not shadowed
+		viper.BindPFlags(cmd.Flags())
+		ctx := context.NewClientContextFromViper()
+
+		// get the directory for our keystore
+		dir := viper.GetString(FlagHomeDir)
+
+		position, err := client.ParsePositions(args[0])
+		if err != nil {
+			return err
+		}
+		blknum, txindex := position[0].Blknum, position[0].TxIndex
+
+		txBytes, err := ctx.QueryStore(utils.TxBytesKey(blknum, txindex), ctx.PlasmaStore)
+		if err != nil {
+			return err
+		}
+		if len(txBytes) == 0 {
+			return fmt.Errorf("no transaction at block %d, index %d", blknum, txindex)
+		}
+
+		root, err := ctx.QueryStore(utils.RootHashKey(blknum), ctx.PlasmaStore)
+		if err != nil {
+			return err
+		}
+		if len(root) == 0 {
+			return fmt.Errorf("block %d has not been committed", blknum)
+		}
+
+		tx, err := types.DecodeSpendTx(txBytes)
+		if err != nil {
+			return err
+		}
+
+		// the input owners confirm the transaction. The rootchain takes at most two signatures
+		signers := tx.GetSpend().GetSigners()
+		if len(signers) > 2 {
+			return fmt.Errorf("transaction with %d inputs cannot be confirmed on the rootchain", len(signers))
+		}
+		var owners [2]common.Address
+		for i, signer := range signers {
+			owners[i] = common.BytesToAddress(signer)
+		}
+		ks := client.GetKeyStore(dir)
+		for _, owner := range owners {
+			if utils.ValidAddress(owner) && !ks.HasAddress(owner) {
+				return errors.Errorf("no account for: %s", owner.Hex())
+			}
+		}
+
+		res, err := ctx.SignBuildBroadcastConfirmSigs(owners, blknum, txindex, utils.ConfirmationHash(txBytes, root), dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Committed at block %d. Hash %s\n", res.Height, res.Hash.String())
+		return nil
+	},
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/app"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(utxoCmd)
+	utxoCmd.PersistentFlags().String(client.FlagNode, "tcp://localhost:26657", "<host>:<port> to tendermint rpc interface for this chain")
+	utxoCmd.AddCommand(spentByCmd)
+}
+
+var utxoCmd = &cobra.Command{
+	Use:   "utxo",
+	Short: "Query the outputs of the sidechain",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound when run so the flags of other commands are not shadowed
+		return viper.BindPFlags(cmd.Flags())
+	},
+}
+
+var spentByCmd = &cobra.Command{
+	Use:   "spent-by <blknum.txindex.oindex.depositnonce>",
+	Short: "Query the transaction that spent an output",
+	Long:  "Query the position and hash of the transaction that spent an output and the index of the output among its inputs. Spends of outputs pruned from the history are kept",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.NewClientContextFromViper()
+
+		position, err := client.ParsePositions(args[0])
+		if err != nil {
+			return err
+		}
+
+		spentBy, err := querySpentBy(ctx, position[0])
+		if err != nil {
+			return err
+		}
+
+		if !spentBy.Spent {
+			fmt.Printf("No spend of output %v is recorded\n", spentBy.Position)
+			return nil
+		}
+		fmt.Printf("Owner: %s\n", spentBy.Owner)
+		fmt.Printf("Spent By: %v\n", spentBy.Spender)
+		fmt.Printf("Transaction Hash: %s\n", spentBy.TxHash)
+		fmt.Printf("Input Index: %d\n", spentBy.InputIndex)
+		fmt.Printf("Spent At: %s\n", spentBy.SpentAt)
+		return nil
+	},
+}
+
+// query the input of the transaction that spent the output at `position`
+func querySpentBy(ctx context.ClientContext, position types.PlasmaPosition) (*app.SpentByResponse, error) {
+	data, err := ctx.Codec.MarshalJSON(app.SpentByParams{Position: position})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("custom/%s/%s", app.QueryRoute, app.QuerySpentBy)
+	res, err := ctx.QueryWithData(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var spentBy app.SpentByResponse
+	if err := ctx.Codec.UnmarshalJSON(res, &spentBy); err != nil {
+		return nil, err
+	}
+
+	return &spentBy, nil
+}
+
+// query the output at `position`
+func queryUTXO(ctx context.ClientContext, position types.PlasmaPosition) (*app.UTXOResponse, error) {
+	data, err := ctx.Codec.MarshalJSON(app.UTXOParams{Position: position})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("custom/%s/%s", app.QueryRoute, app.QueryUTXO)
+	res, err := ctx.QueryWithData(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var output app.UTXOResponse
+	if err := ctx.Codec.UnmarshalJSON(res, &output); err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
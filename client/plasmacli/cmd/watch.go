@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/app"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/client/context"
+	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+	"github.com/AdityaSripal/plasma-mvp-sidechain/x/utxo"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagWatchDir           = "watch-dir"
+	flagWithholdingTimeout = "withholding-timeout"
+
+	// interval between attempts to retrieve a submitted block from the sidechain
+	blockRetryInterval = 5 * time.Second
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().String(flagEthNode, "ws://127.0.0.1:8545", "<host>:<port> of the ethereum node")
+	watchCmd.Flags().String(flagRootchain, "", "Address of the rootchain contract")
+	watchCmd.Flags().String(client.FlagNode, "tcp://localhost:26657", "<host>:<port> to tendermint rpc interface for this chain")
+	watchCmd.Flags().String(flagBond, defaultExitBond, "Bond of each exit in wei. Any excess is added to the withdrawable balance")
+	watchCmd.Flags().String(flagWatchDir, os.ExpandEnv("$HOME/.plasmacli/watch"), "Directory to store the exit data of watched addresses")
+	watchCmd.Flags().Duration(flagWithholdingTimeout, time.Minute, "Time a submitted block may be unavailable from the sidechain before it is considered withheld")
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <address>",
+	Short: "Exit the outputs of an address if the sidechain misbehaves",
+	Long: `Verify every block submitted to the rootchain against the transactions served by the sidechain.
+The transaction bytes, merkle proofs and confirmation signatures of the address's outputs are stored
+locally as blocks are verified. If a submitted block is withheld or invalid, exits are started for
+every stored output, signed by the watched address. Requires a websocket connection to the ethereum node`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// bound when run so the flags of other commands are not shadowed
+		viper.BindPFlags(cmd.Flags())
+		ctx := context.NewClientContextFromViper()
+
+		addr, err := client.StrToAddress(args[0])
+		if err != nil {
+			return err
+		}
+
+		bond, err := parseWei(viper.GetString(flagBond))
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(viper.GetString(flagWatchDir), addr.Hex()+".json")
+		exits, err := loadExits(path)
+		if err != nil {
+			return err
+		}
+
+		session, ec, err := accountTransactor(ctx, addr, bond)
+		if err != nil {
+			return err
+		}
+
+		blocks := make(chan *contracts.PlasmaMVPBlockSubmitted)
+		sub, err := session.Contract.WatchBlockSubmitted(&bind.WatchOpts{}, blocks)
+		if err != nil {
+			return err
+		}
+		defer sub.Unsubscribe()
+
+		// the outputs must be stored before the next submission in case it is invalid
+		if exits, err = updateExits(ctx, addr, exits, path); err != nil {
+			fmt.Printf("Could not update exit data - %s\n", err)
+		}
+
+		fmt.Printf("Watching %s\n", addr.Hex())
+		timeout := viper.GetDuration(flagWithholdingTimeout)
+		for {
+			select {
+			case block := <-blocks:
+				if err := verifyBlock(ctx, block, timeout); err != nil {
+					fmt.Printf("Block %s is invalid - %s\n", block.BlockNumber, err)
+					return exitAll(session, ec, exits)
+				}
+
+				fmt.Printf("Verified block %s\n", block.BlockNumber)
+				if exits, err = updateExits(ctx, addr, exits, path); err != nil {
+					fmt.Printf("Could not update exit data - %s\n", err)
+				}
+			case err := <-sub.Err():
+				return err
+			}
+		}
+	},
+}
+
+// everything needed to exit an output without the sidechain. Deposits only need their position
+type exitData struct {
+	Position    types.PlasmaPosition
+	TxBytes     []byte
+	Proof       []byte
+	ConfirmSigs []byte
+}
+
+// check that the sidechain serves the transactions of a submitted block and that they match the submitted root.
+// The block is retried until `timeout` before it is considered withheld
+func verifyBlock(ctx context.ClientContext, submitted *contracts.PlasmaMVPBlockSubmitted, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	block, err := queryBlock(ctx, submitted.BlockNumber.Uint64())
+	for err != nil {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("withheld by the sidechain - %s", err)
+		}
+		time.Sleep(blockRetryInterval)
+		block, err = queryBlock(ctx, submitted.BlockNumber.Uint64())
+	}
+
+	if uint64(len(block.Txs)) != submitted.NumTxns.Uint64() {
+		return fmt.Errorf("%s transactions submitted, %d served by the sidechain", submitted.NumTxns, len(block.Txs))
+	}
+
+	var root [32]byte
+	copy(root[:], utils.MerkleRoot(block.Txs))
+	if root != submitted.Root {
+		return fmt.Errorf("submitted root 0x%x does not match the served transactions", submitted.Root)
+	}
+
+	for index, txBytes := range block.Txs {
+		tx, err := types.DecodeSpendTx(txBytes)
+		if err != nil {
+			return fmt.Errorf("transaction %d cannot be decoded - %s", index, err)
+		}
+		if err := tx.GetSpend().ValidateBasic(); err != nil {
+			return fmt.Errorf("transaction %d is malformed - %s", index, err)
+		}
+	}
+
+	return nil
+}
+
+// replace the stored exit data with the unspent outputs of `addr`. Outputs whose transaction has not
+// been confirmed cannot be exited and are skipped until it is
+func updateExits(ctx context.ClientContext, addr common.Address, stored []exitData, path string) ([]exitData, error) {
+	res, err := ctx.QuerySubspace(addr.Bytes(), ctx.UTXOStore)
+	if err != nil {
+		return stored, err
+	}
+
+	known := make(map[types.PlasmaPosition]exitData)
+	for _, exit := range stored {
+		known[exit.Position] = exit
+	}
+
+	var exits []exitData
+	for _, pair := range res {
+		var output utxo.UTXO
+		if err := ctx.Codec.UnmarshalBinaryBare(pair.Value, &output); err != nil {
+			return stored, err
+		}
+		if !output.Valid {
+			continue
+		}
+
+		position := plasmaPosition(output.Position)
+		if position.IsFee() {
+			fmt.Printf("Fee output %v cannot be exited until it is spent\n", position)
+			continue
+		}
+		if position.IsDeposit() {
+			exits = append(exits, exitData{Position: position})
+			continue
+		}
+		if exit, ok := known[position]; ok {
+			exits = append(exits, exit)
+			continue
+		}
+
+		proof, err := queryProof(ctx, position.Blknum, position.TxIndex)
+		if err != nil {
+			return stored, err
+		}
+		confirmSigs, err := queryConfirmSigs(ctx, position.Blknum, position.TxIndex)
+		if err != nil {
+			fmt.Printf("Output %v cannot be exited until its transaction is confirmed\n", position)
+			continue
+		}
+
+		exits = append(exits, exitData{position, proof.TxBytes, proof.Proof, confirmSigs})
+	}
+
+	if err := saveExits(path, exits); err != nil {
+		return exits, err
+	}
+
+	return exits, nil
+}
+
+// start exits of every stored output. Failures are reported and do not stop the remaining exits
+func exitAll(session *contracts.PlasmaMVPSession, ec *ethclient.Client, exits []exitData) error {
+	var failed int
+	for _, exit := range exits {
+		fmt.Printf("Exiting %v\n", exit.Position)
+
+		var tx *ethtypes.Transaction
+		var err error
+		if exit.Position.IsDeposit() {
+			tx, err = session.StartDepositExit(new(big.Int).SetUint64(exit.Position.DepositNum))
+		} else {
+			tx, err = startTransactionExit(session, exit.Position, exit.TxBytes, exit.Proof, exit.ConfirmSigs)
+		}
+		if err == nil {
+			err = waitMined(ec, tx)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("Could not exit %v - %s\n", exit.Position, err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d exits could not be started", failed, len(exits))
+	}
+	fmt.Printf("Started exits of %d outputs\n", len(exits))
+	return nil
+}
+
+func loadExits(path string) ([]exitData, error) {
+	bz, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var exits []exitData
+	if err := json.Unmarshal(bz, &exits); err != nil {
+		return nil, fmt.Errorf("corrupted exit data at %s - %s", path, err)
+	}
+	return exits, nil
+}
+
+// written to a temporary file first so a crash never leaves partial exit data
+func saveExits(path string, exits []exitData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	bz, err := json.MarshalIndent(exits, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path+".tmp", bz, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// query the recorded transactions of block `blknum`
+func queryBlock(ctx context.ClientContext, blknum uint64) (*app.BlockResponse, error) {
+	data, err := ctx.Codec.MarshalJSON(app.BlockParams{Blknum: blknum})
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("custom/%s/%s", app.QueryRoute, app.QueryBlock)
+	res, err := ctx.QueryWithData(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var block app.BlockResponse
+	if err := ctx.Codec.UnmarshalJSON(res, &block); err != nil {
+		return nil, err
+	}
+
+	return &block, nil
+}
+
+func plasmaPosition(position utxo.Position) types.PlasmaPosition {
+	pos := position.Get()
+	return types.NewPlasmaPosition(pos[0].Uint64(), uint16(pos[1].Uint64()), uint8(pos[2].Uint64()), pos[3].Uint64())
+}
@@ -90,7 +90,8 @@ func InitCmd(ctx *server.Context, cdc *codec.Codec, appInit server.AppInit) *cob
 			plasmaConfig := plasmacfg.DefaultConfig()
 			plasmacfg.WriteConfigFile(filepath.Join(config.RootDir, "config", "plasma.toml"), plasmaConfig)
 
-			fmt.Printf("Add an ethereum address to 'fee_address' to collect fees as a validator\n\n")
+			fmt.Printf("Add an ethereum address to 'fee_address' to collect fees as a validator\n")
+			fmt.Printf("Add the rootchain operator's address to 'operator_address' to include deposits and exits\n\n")
 			return displayInfo(cdc, toPrint)
 		},
 	}
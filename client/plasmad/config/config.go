@@ -8,8 +8,25 @@ type Config struct {
 	EthNodeURL       string
 	EthMinFees       string
 	EthBlockFinality string
+
+	MaxTxInputs  string
+	MaxTxOutputs string
+
+	MempoolSize          string
+	MempoolMaxInvalid    string
+	MempoolInvalidWindow string
+
+	SubmissionMaxBatchSize    string
+	SubmissionMaxWait         string
+	SubmissionSkipEmptyBlocks bool
+
+	GasPriceStrategy string
+	GasPrice         string
+	MaxGasPrice      string
+	TxDeadline       string
+	GasBumpPercent   string
 }
 
 func DefaultConfig() *Config {
-	return &Config{false, "", "", "", "0", "0"}
+	return &Config{false, "", "", "", "0", "0", "16", "16", "4000", "10", "100", "1", "0s", false, "oracle", "", "", "5m", "10"}
 }
@@ -30,7 +30,51 @@ ethereum_nodeurl = "{{.EthNodeURL}}"
 minimum_fees = "{{.EthMinFees}}"
 
 # Number of Ethereum blocks until a submitted block header is considered final
-ethereum_finality = "{{.EthBlockFinality}}"`
+ethereum_finality = "{{.EthBlockFinality}}"
+
+##### mempool options #####
+# Maximum number of inputs of a transaction admitted into the mempool. Unbounded if empty or 0
+max_tx_inputs = "{{.MaxTxInputs}}"
+
+# Maximum number of outputs of a transaction admitted into the mempool. Unbounded if empty or 0
+max_tx_outputs = "{{.MaxTxOutputs}}"
+
+# Maximum number of transactions admitted into the mempool. Once full, transactions paying the lowest
+# fee per byte are evicted when rechecked after the next block. Transactions are still included in
+# blocks in arrival order. Must be below the size of the tendermint mempool
+mempool_size = "{{.MempoolSize}}"
+
+# Number of invalid transactions signed by an input owner after which the owner's transactions are rejected
+mempool_max_invalid = "{{.MempoolMaxInvalid}}"
+
+# Number of blocks an owner's transactions are rejected for after too many invalid transactions
+mempool_invalid_window = "{{.MempoolInvalidWindow}}"
+
+##### block submission options #####
+# Maximum number of block headers submitted to the rootchain in a single transaction
+submission_max_batch_size = "{{.SubmissionMaxBatchSize}}"
+
+# Maximum time a block waits for its batch to fill up before it is submitted (e.g. "30s")
+submission_max_wait = "{{.SubmissionMaxWait}}"
+
+# Boolean specifying if empty blocks should only be submitted along with a block containing transactions
+submission_skip_empty_blocks = "{{.SubmissionSkipEmptyBlocks}}"
+
+##### gas options #####
+# Strategy used to price validator transactions to the rootchain: "fixed", "oracle" or "capped"
+gas_price_strategy = "{{.GasPriceStrategy}}"
+
+# Gas price in wei used by the fixed strategy
+gas_price = "{{.GasPrice}}"
+
+# Maximum gas price in wei for the capped strategy and for replaced transactions. Unbounded if empty
+max_gas_price = "{{.MaxGasPrice}}"
+
+# Time a rootchain transaction may remain unmined before it is replaced with a higher gas price (e.g. "5m")
+tx_deadline = "{{.TxDeadline}}"
+
+# Percentage the gas price is raised by when replacing a transaction. Must be at least 10
+gas_bump_percent = "{{.GasBumpPercent}}"`
 
 var configTemplate *template.Template
 
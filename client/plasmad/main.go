@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -47,14 +50,55 @@ func newApp(logger log.Logger, db dbm.DB, traceStore io.Writer) abci.Application
 	nodeURL := viper.GetString("ethereum_nodeurl")
 	key_file = viper.GetString(cli.HomeFlag) + "/config/" + key_file
 	finality := viper.GetString("ethereum_finality")
+	minimumFees := viper.GetString("minimum_fees")
+	maxTxInputs := viper.GetString("max_tx_inputs")
+	maxTxOutputs := viper.GetString("max_tx_outputs")
+	mempoolSize := viper.GetString("mempool_size")
+	mempoolMaxInvalid := viper.GetString("mempool_max_invalid")
+	mempoolWindow := viper.GetString("mempool_invalid_window")
+	eventDB := filepath.Join(viper.GetString(cli.HomeFlag), "data", "rootchain.db")
+	submissionDB := filepath.Join(viper.GetString(cli.HomeFlag), "data", "submissions.db")
+	archiveDB := filepath.Join(viper.GetString(cli.HomeFlag), "data", "spends.db")
+	spendRetention := viper.GetString("spend_retention")
+	maxBatchSize := viper.GetString("submission_max_batch_size")
+	maxWait := viper.GetString("submission_max_wait")
+	skipEmptyBlocks := viper.GetBool("submission_skip_empty_blocks")
+	gasPriceStrategy := viper.GetString("gas_price_strategy")
+	gasPrice := viper.GetString("gas_price")
+	maxGasPrice := viper.GetString("max_gas_price")
+	txDeadline := viper.GetString("tx_deadline")
+	gasBumpPercent := viper.GetString("gas_bump_percent")
 
-	return app.NewChildChain(logger, db, traceStore,
+	papp := app.NewChildChain(logger, db, traceStore,
 		app.SetEthConfig(isValidator, key_file, rootchain, nodeURL, finality),
+		app.SetMinimumFees(minimumFees),
+		app.SetSpendLimits(maxTxInputs, maxTxOutputs),
+		app.SetMempool(mempoolSize, mempoolMaxInvalid, mempoolWindow),
+		app.SetEventCache(eventDB),
+		app.SetBlockSubmission(submissionDB, maxBatchSize, maxWait, skipEmptyBlocks),
+		app.SetSpendArchive(archiveDB, spendRetention),
+		app.SetGasConfig(gasPriceStrategy, gasPrice, maxGasPrice, txDeadline, gasBumpPercent),
 	)
+	stopOnSignal(papp)
+
+	return papp
+}
+
+// the abci application is never told that the node is shutting down, so rootchain
+// submission is stopped on the same signals the node stops on
+func stopOnSignal(papp *app.ChildChain) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ch
+		papp.Stop()
+	}()
 }
 
-// non-functional
+// exports the state of a stopped node. No connection to the rootchain is needed
 func exportAppState(logger log.Logger, db dbm.DB, traceStore io.Writer) (json.RawMessage, []tmtypes.GenesisValidator, error) {
-	papp := app.NewChildChain(logger, db, traceStore)
+	papp := app.NewChildChain(logger, db, traceStore,
+		app.SetNoRootChain(),
+	)
 	return papp.ExportAppStateJSON()
 }
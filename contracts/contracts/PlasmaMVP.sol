@@ -38,6 +38,10 @@ contract PlasmaMVP {
 
     address operator;
 
+    // keccak256 of the chain ID of the sidechain. Input owners sign over it so that
+    // a spend signed for one chain cannot be exited from another chain's contract
+    bytes32 public chainIdHash;
+
     // child chain
     uint256 public lastCommittedBlock;
     uint256 public depositNonce;
@@ -98,9 +102,11 @@ contract PlasmaMVP {
         _;
     }
 
-    constructor() public
+    // @param chainId the chain ID of the sidechain committing to this contract
+    constructor(string chainId) public
     {
         operator = msg.sender;
+        chainIdHash = keccak256(bytes(chainId));
 
         lastCommittedBlock = 0;
         depositNonce = 1;
@@ -169,11 +175,14 @@ contract PlasmaMVP {
     //   NewOwner, Denom1, NewOwner, Denom2, Fee],
     //  [Signature1, Signature2]]
     //
+    // Input owners sign keccak256(uint8(1), address(this), chainIdHash, rlp(txList)),
+    // the sign bytes of the sidechain's domain
+    //
     // @param txBytes rlp encoded transaction
     // @notice this function will revert if the txBytes are malformed
     function decodeTransaction(bytes txBytes)
         internal
-        pure
+        view
         returns (RLPReader.RLPItem[] memory txList, RLPReader.RLPItem[] memory sigList, bytes32 txHash)
     {
         RLPReader.RLPItem[] memory spendMsg = txBytes.toRlpItem().toList();
@@ -186,7 +195,7 @@ contract PlasmaMVP {
         require(sigList.length == 2, "two signatures must be present");
 
         // bytes the signatures are over
-        txHash = keccak256(spendMsg[0].toRlpBytes());
+        txHash = keccak256(abi.encodePacked(uint8(1), address(this), chainIdHash, spendMsg[0].toRlpBytes()));
     }
 
     // @param txPos             location of the transaction [blkNum, txIndex, outputIndex]
@@ -22,5 +22,7 @@ function generate(path) {
 
     
     fs.writeFileSync(`abi/${contract.contractName}.abi`, JSON.stringify(contract.abi))
-    shell.exec(`abigen --abi abi/${contract.contractName}.abi --pkg wrappers --type ${contract.contractName} --out wrappers/${snakeCasedFilename}.go`);
+    // the creation bytecode lets the go tests deploy the contract to a simulated chain
+    fs.writeFileSync(`abi/${contract.contractName}.bin`, contract.bytecode)
+    shell.exec(`abigen --abi abi/${contract.contractName}.abi --bin abi/${contract.contractName}.bin --pkg wrappers --type ${contract.contractName} --out wrappers/${snakeCasedFilename}.go`);
 }
@@ -1,5 +1,6 @@
 let PlasmaMVP = artifacts.require("PlasmaMVP");
 
 module.exports = function(deployer, network, accounts) {
-	deployer.deploy(PlasmaMVP, {from: accounts[0]});
+	// must match the chain ID of the sidechain's genesis
+	deployer.deploy(PlasmaMVP, process.env.CHAIN_ID || "plasma", {from: accounts[0]});
 };
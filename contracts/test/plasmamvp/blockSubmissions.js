@@ -2,6 +2,7 @@ let assert = require('chai').assert;
 
 let PlasmaMVP = artifacts.require("PlasmaMVP");
 
+let { chainId } = require('./plasmamvp_helpers.js');
 let { toHex, catchError } = require('../utilities.js');
 
 contract('[PlasmaMVP] Block Submissions', async (accounts) => {
@@ -9,7 +10,7 @@ contract('[PlasmaMVP] Block Submissions', async (accounts) => {
     let authority = accounts[0];
     let minExitBond = 10000;
     beforeEach(async () => {
-        instance = await PlasmaMVP.new({from: authority});
+        instance = await PlasmaMVP.new(chainId, {from: authority});
     });
 
     it("Submit block from authority", async () => {
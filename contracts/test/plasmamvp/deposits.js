@@ -3,7 +3,7 @@ let assert = require('chai').assert;
 
 let PlasmaMVP = artifacts.require("PlasmaMVP");
 
-let { fastForward, proof, zeroHashes, sha256String, generateMerkleRootAndProof } = require('./plasmamvp_helpers.js');
+let { chainId, txSignHash, fastForward, proof, zeroHashes, sha256String, generateMerkleRootAndProof } = require('./plasmamvp_helpers.js');
 let { catchError, toHex } = require('../utilities.js');
 
 contract('[PlasmaMVP] Deposits', async (accounts) => {
@@ -13,7 +13,7 @@ contract('[PlasmaMVP] Deposits', async (accounts) => {
 
     let authority = accounts[0];
     beforeEach(async () => {
-        instance = await PlasmaMVP.new({from: authority});
+        instance = await PlasmaMVP.new(chainId, {from: authority});
     });
 
     it("Catches Deposit event", async () => {
@@ -132,8 +132,7 @@ contract('[PlasmaMVP] Deposits', async (accounts) => {
         // construct transcation with first input as the deposit
         let msg = Array(17).fill(0);
         msg[3] = nonce; msg[12] = accounts[1]; msg[13] = 100;
-        let encodedMsg = RLP.encode(msg);
-        let hashedEncodedMsg = web3.sha3(encodedMsg.toString('hex'), {encoding: 'hex'});
+        let hashedEncodedMsg = txSignHash(instance, msg);
 
         // create signature by deposit owner. Second signature should be zero
         let sigList = Array(2).fill(0);
@@ -194,7 +193,7 @@ contract('[PlasmaMVP] Deposits', async (accounts) => {
         let txList = Array(17).fill(0);
         txList[3] = nonce; txList[16] = 5; // fee
         txList[12] = accounts[1]; txList[13] = 100;
-        let txHash = web3.sha3(RLP.encode(txList).toString('hex'), {encoding: 'hex'});
+        let txHash = txSignHash(instance, txList);
         let sigs = [toHex(await web3.eth.sign(accounts[2], txHash)), toHex(Buffer.alloc(65).toString('hex'))];
 
         let txBytes = [txList, sigs];
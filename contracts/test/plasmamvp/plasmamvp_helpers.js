@@ -79,8 +79,21 @@ let generateMerkleRootAndProof = function(leaves, index) {
     }
 };
 
+// Chain ID the contract is deployed with in tests
+let chainId = "plasma";
+
+// Hash input owners sign for a transaction exited from `instance`.
+// The rlp encoded txList is prefixed with the sign version, the contract
+// address and the keccak256 hash of the chain ID.
+let txSignHash = function(instance, txList) {
+    let signBytes = "0x01" + instance.address.slice(2) + web3.sha3(chainId).slice(2) + RLP.encode(txList).toString('hex');
+    return web3.sha3(signBytes, {encoding: 'hex'});
+};
+
 
 module.exports = {
+    chainId,
+    txSignHash,
     fastForward,
     sha256String,
     generateMerkleRootAndProof
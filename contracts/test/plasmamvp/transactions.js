@@ -4,6 +4,8 @@ let assert = require('chai').assert
 let PlasmaMVP = artifacts.require('PlasmaMVP');
 
 let {
+    chainId,
+    txSignHash,
     fastForward,
     sha256String,
     generateMerkleRootAndProof
@@ -26,7 +28,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
     let proof;
     let sigs, confirmSignatures;
     beforeEach(async () => {
-        instance = await PlasmaMVP.new({from: authority});
+        instance = await PlasmaMVP.new(chainId, {from: authority});
 
         depositNonce = (await instance.depositNonce.call()).toNumber();
         await instance.deposit(authority, {from: authority, value: amount*2});
@@ -36,7 +38,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList[3] = depositNonce;
         txList[12] = accounts[1]; txList[13] = amount;
         txList[14] = authority; txList[15] = amount;
-        let txHash = web3.sha3(RLP.encode(txList).toString('hex'), {encoding: 'hex'});
+        let txHash = txSignHash(instance, txList);
 
         let sigs = [toHex(await web3.eth.sign(authority, txHash)), toHex(Buffer.alloc(65).toString('hex'))];
 
@@ -63,7 +65,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
     });
 
     it("Allows only the utxo owner to start an exit (hardcoded)", async () => {
-        instance = await PlasmaMVP.new({from: authority});
+        instance = await PlasmaMVP.new(chainId, {from: authority});
 
         // utxo information
         // this utxo input and the merkle root of its block were generated
@@ -89,7 +91,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
     });
 
     it("Can challenge a spend of a utxo (hardcoded)", async () => {
-        instance = await PlasmaMVP.new({from: authority});
+        instance = await PlasmaMVP.new(chainId, {from: authority});
 
         // utxo information
         // this utxo input and the merkle root of its block were generated
@@ -215,7 +217,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList[13] = depositAmount - feeAmount;
         txList[16] = feeAmount;
 
-        let txHash = web3.sha3(RLP.encode(txList).toString('hex'), {encoding: 'hex'});
+        let txHash = txSignHash(instance, txList);
         let sigs = [toHex(await web3.eth.sign(authority, txHash)), toHex(Buffer.alloc(65).toString('hex'))];
         let txBytes = [txList, sigs];
         txBytes = RLP.encode(txBytes).toString('hex');
@@ -254,7 +256,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList2[6] = txPos[0]; txList2[7] = txPos[1]; txList2[8] = 1;
         txList2[12] = accounts[2]; txList2[13] = amount - 5;
         txList2[14] = accounts[1]; txList2[15] = amount;
-        let txHash2 = web3.sha3(RLP.encode(txList2).toString('hex'), {encoding: 'hex'});
+        let txHash2 = txSignHash(instance, txList2);
 
         let sigs2 = [toHex(await web3.eth.sign(accounts[1], txHash2)), toHex(await web3.eth.sign(authority, txHash2))];
         let txBytes2 = [txList2, sigs2];
@@ -321,7 +323,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList[13] = depositAmount - feeAmount;
         txList[16] = feeAmount;
 
-        let txHash = web3.sha3(RLP.encode(txList).toString('hex'), {encoding: 'hex'});
+        let txHash = txSignHash(instance, txList);
         let sigs = [toHex(await web3.eth.sign(authority, txHash)), toHex(Buffer.alloc(65).toString('hex'))];
         let txBytes = [txList, sigs];
         txBytes = RLP.encode(txBytes).toString('hex');
@@ -338,7 +340,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList2[12] = accounts[2]; txList2[13] = feeAmount; // first output
 
         // create signature by deposit owner. Second signature should be zero
-        let txHash2 = web3.sha3(RLP.encode(txList2).toString('hex'), {encoding: 'hex'});
+        let txHash2 = txSignHash(instance, txList2);
         let sigs2 = [toHex(await web3.eth.sign(authority, txHash2)), toHex(Buffer.alloc(65).toString('hex'))]
 
         let newTxBytes2 = [txList2, sigs2];
@@ -435,7 +437,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList2[12] = accounts[2]; txList2[13] = amount; // first output
 
         // create signature by deposit owner. Second signature should be zero
-        let txHash = web3.sha3(RLP.encode(txList2).toString('hex'), {encoding: 'hex'});
+        let txHash = txSignHash(instance, txList2);
         let sigs = [toHex(await web3.eth.sign(accounts[1], txHash)), toHex(Buffer.alloc(65).toString('hex'))]
 
         let newTxBytes = [txList2, sigs];
@@ -497,7 +499,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         // construct transcation with second input as the deposit
         let txList2 = Array(17).fill(0);
         txList2[9] = nonce; txList2[12] = accounts[1]; txList2[13] = 100;
-        let txHash = web3.sha3(RLP.encode(txList2, {encoding: 'hex'}));
+        let txHash = txSignHash(instance, txList2);
 
         // create signature by deposit owner. Second signature should be zero
         let sigs = [toHex(Buffer.alloc(65).toString('hex')), toHex(await web3.eth.sign(accounts[2], txHash))];
@@ -532,7 +534,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList1[14] = accounts[1]; txList1[15] = amount/2; // second utxo
 
         // include this tx the next block
-        let txHash1 = web3.sha3(RLP.encode(txList1).toString('hex'), {encoding: 'hex'});
+        let txHash1 = txSignHash(instance, txList1);
         let sigs1 = [toHex(await web3.eth.sign(accounts[1], txHash1)), toHex(Buffer.alloc(65).toString('hex'))];
 
         let txBytes1 = RLP.encode([txList1, sigs1]).toString('hex');
@@ -550,7 +552,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         // accounts[1] spends the first output to accounts[2]
         let txList2 = Array(17).fill(0);
         txList2[0] = blockNum1; txList2[12] = accounts[2]; txList2[12] = amount/2;
-        let txHash2 = web3.sha3(RLP.encode(txList2).toString('hex'), {encoding: 'hex'});
+        let txHash2 = txSignHash(instance, txList2);
 
         // include this tx the next block
         let sigs2 = [toHex(await web3.eth.sign(accounts[1], txHash2)), toHex(Buffer.alloc(65).toString('hex'))];
@@ -586,7 +588,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList1[0] = txPos[0]; txList1[1] = txPos[1]; txList1[2] = txPos[2]; // first input
         txList1[12] = accounts[1]; txList1[13] = amount/2; // first output
         txList1[14] = accounts[1]; txList1[15] = amount/2; // second output
-        let txHash1 = web3.sha3(RLP.encode(txList1).toString('hex'), {encoding: 'hex'});
+        let txHash1 = txSignHash(instance, txList1);
         let sigs1 = [toHex(await web3.eth.sign(accounts[1], txHash1)), toHex(Buffer.alloc(65).toString('hex'))];
         let txBytes1 = RLP.encode([txList1, sigs1]).toString('hex');
 
@@ -605,7 +607,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         txList2[0] = blockNum1; txList2[2] = 1; // first input
         txList2[12] = accounts[1]; txList2[13] = amount / 4; // first output
         txList2[14] = accounts[2]; txList2[15] = amount / 4; // second output
-        let txHash2 = web3.sha3(RLP.encode(txList2).toString('hex'), {encoding: 'hex'});
+        let txHash2 = txSignHash(instance, txList2);
         let sigs2 = [toHex(await web3.eth.sign(accounts[1], txHash2)), toHex(Buffer.alloc(65).toString('hex'))];
         let txBytes2 = RLP.encode([txList2, sigs2]).toString('hex');
 
@@ -688,7 +690,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         // deposit is the first input. authority sends entire deposit to accounts[1]
         let txList2 = Array(17).fill(0);
         txList2[3] = depositNonce; txList2[12] = accounts[1]; txList2[13] = amount;
-        let txHash2 = web3.sha3(RLP.encode(txList2).toString('hex'), {encoding: 'hex'});
+        let txHash2 = txSignHash(instance, txList2);
 
         let sigs2 = [toHex(await web3.eth.sign(authority, txHash2)), toHex(Buffer.alloc(65).toString('hex'))];
 
@@ -746,7 +748,7 @@ contract('[PlasmaMVP] Transactions', async (accounts) => {
         // deposit is the first input. authority sends entire deposit to accounts[1]
         let txList2 = Array(17).fill(0);
         txList2[3] = depositNonce; txList2[12] = accounts[1]; txList2[13] = amount;
-        let txHash2 = web3.sha3(RLP.encode(txList2).toString('hex'), {encoding: 'hex'});
+        let txHash2 = txSignHash(instance, txList2);
 
         let sigs2 = [toHex(await web3.eth.sign(authority, txHash2)), toHex(Buffer.alloc(65).toString('hex'))];
 
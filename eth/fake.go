@@ -0,0 +1,143 @@
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	plasmaTypes "github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// FakeRootChain is an in-memory RootChain for tests. Deposits, exits and challenges are
+// added directly, each in its own ethereum block, and are final immediately
+type FakeRootChain struct {
+	lock *sync.Mutex
+
+	deposits map[uint64]plasmaTypes.Deposit
+	exits    map[[4]uint64]bool
+	blocks   map[uint64][32]byte
+
+	ethBlockNum uint64
+	events      event.Feed
+}
+
+// NewFakeRootChain creates an empty rootchain
+func NewFakeRootChain() *FakeRootChain {
+	return &FakeRootChain{
+		lock:     &sync.Mutex{},
+		deposits: make(map[uint64]plasmaTypes.Deposit),
+		exits:    make(map[[4]uint64]bool),
+		blocks:   make(map[uint64][32]byte),
+	}
+}
+
+// Deposit records a deposit of `amount` wei for `owner` with the given nonce
+func (rc *FakeRootChain) Deposit(nonce uint64, owner common.Address, amount uint64) plasmaTypes.Deposit {
+	rc.lock.Lock()
+	rc.ethBlockNum++
+	deposit := plasmaTypes.Deposit{
+		Owner:    owner,
+		Amount:   sdk.NewUint(amount),
+		BlockNum: sdk.NewUint(rc.ethBlockNum),
+	}
+	rc.deposits[nonce] = deposit
+	rc.lock.Unlock()
+
+	rc.send(EventDeposit, [4]uint64{0, 0, 0, nonce})
+	return deposit
+}
+
+// StartExit exits [blknum, txindex, oindex, depositnonce]
+func (rc *FakeRootChain) StartExit(position [4]uint64) {
+	rc.lock.Lock()
+	rc.ethBlockNum++
+	rc.exits[position] = true
+	rc.lock.Unlock()
+
+	rc.send(EventExitStarted, position)
+}
+
+// ChallengeExit cancels the exit of [blknum, txindex, oindex, depositnonce]
+func (rc *FakeRootChain) ChallengeExit(position [4]uint64) {
+	rc.lock.Lock()
+	rc.ethBlockNum++
+	delete(rc.exits, position)
+	rc.lock.Unlock()
+
+	rc.send(EventExitChallenged, position)
+}
+
+func (rc *FakeRootChain) send(eventType EventType, position [4]uint64) {
+	var pos [4]*big.Int
+	for i, p := range position {
+		pos[i] = new(big.Int).SetUint64(p)
+	}
+
+	rc.lock.Lock()
+	blockNum := rc.ethBlockNum
+	rc.lock.Unlock()
+
+	rc.events.Send(Event{Type: eventType, Position: pos, EthBlockNum: blockNum})
+}
+
+// GetDeposit returns the deposit with the given nonce
+func (rc *FakeRootChain) GetDeposit(nonce *big.Int) (*plasmaTypes.Deposit, error) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	deposit, ok := rc.deposits[nonce.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("deposit does not exist")
+	}
+
+	return &deposit, nil
+}
+
+// HasTXBeenExited indicates if the position has an unchallenged exit
+func (rc *FakeRootChain) HasTXBeenExited(position [4]*big.Int) bool {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	return rc.exits[[4]uint64{position[0].Uint64(), position[1].Uint64(), position[2].Uint64(), position[3].Uint64()}]
+}
+
+// HasBlockBeenSubmitted indicates if the block with the given header has been submitted
+func (rc *FakeRootChain) HasBlockBeenSubmitted(blockNum *big.Int, header [32]byte) bool {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	root, ok := rc.blocks[blockNum.Uint64()]
+	return ok && root == header
+}
+
+// SubmitBlock records the headers. No transaction is returned
+func (rc *FakeRootChain) SubmitBlock(headers [][32]byte, numTxns []*big.Int, blockNum *big.Int) (*types.Transaction, error) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	next := uint64(1)
+	for num := range rc.blocks {
+		if num >= next {
+			next = num + 1
+		}
+	}
+	if blockNum.Uint64() != next {
+		return nil, fmt.Errorf("block %d does not follow the last submitted block", blockNum)
+	}
+
+	rc.ethBlockNum++
+	for i, header := range headers {
+		rc.blocks[next+uint64(i)] = header
+	}
+
+	return nil, nil
+}
+
+// SubscribeEvents sends deposits and exits to `ch` as they are added
+func (rc *FakeRootChain) SubscribeEvents(ch chan<- Event) event.Subscription {
+	return rc.events.Subscribe(ch)
+}
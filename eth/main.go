@@ -2,9 +2,10 @@ package eth
 
 import (
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -13,13 +14,28 @@ import (
 	"math/big"
 )
 
+// Backend is the ethereum endpoint used by the client. Satisfied by an `ethclient.Client`
+// connected to a node as well as by the in-process `SimulatedBackend`
+type Backend interface {
+	bind.ContractBackend
+
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
 // Client defines wrappers to a remote endpoint
 type Client struct {
-	rpc    *rpc.Client
-	ec     *ethclient.Client
+	rpc    *rpc.Client // nil if not connected to a node
+	ec     Backend
 	logger log.Logger
 }
 
+// NewClient wraps an existing backend, such as a `SimulatedBackend`
+func NewClient(backend Backend, logger log.Logger) *Client {
+	return &Client{nil, backend, logger}
+}
+
 // Instantiate a connection and bind the go plasma contract wrapper with this client
 func InitEthConn(nodeUrl string, logger log.Logger) (*Client, error) {
 	// Connect to a remote etheruem client
@@ -56,25 +72,22 @@ func (client *Client) SubscribeToHeads() (<-chan *types.Header, error) {
 	return c, nil
 }
 
+// CurrentBlockNum returns the number of the latest ethereum block
 func (client *Client) CurrentBlockNum() (*big.Int, error) {
-	var res json.RawMessage
-	err := client.rpc.Call(&res, "eth_blockNumber")
-
-	var hexStr string
-	if err = json.Unmarshal(res, &hexStr); err != nil {
-		return nil, fmt.Errorf("Error unmarshaling blockNumber response - %s", err)
-	}
-
-	bytes, err := hex.DecodeString(hexStr)
+	header, err := client.ec.HeaderByNumber(context.Background(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("Error converting hex string to bytes - %x", hexStr)
+		return nil, fmt.Errorf("Error retrieving the latest header - %s", err)
 	}
 
-	return new(big.Int).SetBytes(bytes), nil
+	return header.Number, nil
 }
 
 // used for testing when running against a local client like ganache
 func (client *Client) accounts() ([]common.Address, error) {
+	if client.rpc == nil {
+		return nil, fmt.Errorf("not connected to a node")
+	}
+
 	var res json.RawMessage
 	err := client.rpc.Call(&res, "eth_accounts")
 	if err != nil {
@@ -3,6 +3,7 @@ package eth
 import (
 	"context"
 	"crypto/ecdsa"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
@@ -11,70 +12,89 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/comparer"
-	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 	"github.com/tendermint/tendermint/libs/log"
 	"math/big"
+	"sort"
 	"sync"
 )
 
 // Plasma holds related unexported members
 type Plasma struct {
-	session *contracts.PlasmaMVPSession
-	client  *Client
-	logger  log.Logger
+	session   *contracts.PlasmaMVPSession
+	txManager *TxManager
+	client    *Client
+	logger    log.Logger
 
-	memdb *memdb.DB
-	db    *leveldb.DB
+	// cache of rootchain deposits and exits
+	db *leveldb.DB
 
-	blockNum      *big.Int
 	ethBlockNum   *big.Int
 	finalityBound uint64
 
 	lock *sync.Mutex
+	// serializes changes to the cache so rollbacks and backfills see a consistent view
+	cacheLock *sync.Mutex
+
+	// feed of the cached deposits and exits
+	events event.Feed
+	// events cached or rolled back while holding the cache lock. Sent once it is released
+	unsent []Event
 }
 
-// InitPlasma binds the go wrapper to the deployed contract. This private key provides authentication for the operator
-func InitPlasma(contractAddr common.Address, privateKey *ecdsa.PrivateKey, client *Client, logger log.Logger, finalityBound uint64) (*Plasma, error) {
+// InitPlasma binds the go wrapper to the deployed contract. This private key provides authentication for the operator.
+// Deposits and exits are cached in the LevelDB at `dbPath`, which is backfilled with the events emitted since it was
+// last running. An empty path keeps the cache in memory
+func InitPlasma(contractAddr common.Address, privateKey *ecdsa.PrivateKey, client *Client, logger log.Logger, finalityBound uint64, dbPath string) (*Plasma, error) {
 	plasmaContract, err := contracts.NewPlasmaMVP(contractAddr, client.ec)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a session with the contract and operator account
-	auth := bind.NewKeyedTransactor(privateKey)
+	// Create a session with the contract for calls. Operator transactions are sent through
+	// the tx manager, which tracks the operator nonce
 	plasmaSession := &contracts.PlasmaMVPSession{
 		Contract: plasmaContract,
 		CallOpts: bind.CallOpts{
 			Pending: true,
 		},
-		TransactOpts: bind.TransactOpts{
-			From:     auth.From,
-			Signer:   auth.Signer,
-			GasLimit: 3141592, // aribitrary. TODO: check this
-		},
 	}
 
-	// TODO: deal with syncing issues
-	lastCommittedBlock, err := plasmaSession.LastCommittedBlock()
+	_, err = plasmaSession.LastCommittedBlock()
 	if err != nil {
 		return nil, fmt.Errorf("Contract session not correctly established - %s", err)
 	}
 
+	// operator transactions are priced, tracked and replaced by the tx manager
+	txManager, err := NewTxManager(contractAddr, privateKey, client, finalityBound, DefaultTxConfig(), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var db *leveldb.DB
+	if dbPath == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(dbPath, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not open the rootchain event cache - %s", err)
+	}
+
 	plasma := &Plasma{
-		session: plasmaSession,
-		client:  client,
-		// capacity argument is advisory and not enforced in the memdb implementation
-		// TODO: flush the in-memory DB to a local one to bound memory consumption
-		memdb:  memdb.New(comparer.DefaultComparer, 1),
-		logger: logger,
+		session:   plasmaSession,
+		txManager: txManager,
+		client:    client,
+		db:        db,
+		logger:    logger,
 
 		ethBlockNum:   big.NewInt(-1),
-		blockNum:      lastCommittedBlock,
 		finalityBound: finalityBound,
 
-		lock: &sync.Mutex{},
+		lock:      &sync.Mutex{},
+		cacheLock: &sync.Mutex{},
 	}
 
 	// listen to new ethereum block headers
@@ -84,22 +104,26 @@ func InitPlasma(contractAddr common.Address, privateKey *ecdsa.PrivateKey, clien
 		return nil, err
 	}
 
-	// start listeners
+	// start listeners. Subscriptions are made before backfilling so no event is missed in between
 	go watchEthBlocks(plasma, ethCh)
-	go watchDeposits(plasma)
-	go watchExits(plasma)
+	if err := watchDeposits(plasma); err != nil {
+		return nil, err
+	}
+	if err := watchExits(plasma); err != nil {
+		return nil, err
+	}
+
+	if err := plasma.backfill(); err != nil {
+		return nil, fmt.Errorf("Could not backfill rootchain events - %s", err)
+	}
 
 	return plasma, nil
 }
 
-// SubmitBlock proxy. TODO: handle batching with a timmer interrupt
-func (plasma *Plasma) SubmitBlock(header [32]byte, numTxns *big.Int) (*types.Transaction, error) {
-	plasma.blockNum = plasma.blockNum.Add(plasma.blockNum, big.NewInt(1))
-
-	tx, err := plasma.session.SubmitBlock(
-		[][32]byte{header},
-		[]*big.Int{numTxns},
-		plasma.blockNum)
+// SubmitBlock proxy. Submits consecutive headers where `blockNum` is the number of the first
+// header and must follow the contract's last committed block
+func (plasma *Plasma) SubmitBlock(headers [][32]byte, numTxns []*big.Int, blockNum *big.Int) (*types.Transaction, error) {
+	tx, err := plasma.txManager.Transact(nil, "submitBlock", headers, numTxns, blockNum)
 
 	if err != nil {
 		return nil, err
@@ -108,17 +132,29 @@ func (plasma *Plasma) SubmitBlock(header [32]byte, numTxns *big.Int) (*types.Tra
 	return tx, nil
 }
 
-// GetDeposit checks the existence of a deposit nonce
+// Stop halts the replacement of the operator's stuck rootchain transactions
+func (plasma *Plasma) Stop() {
+	plasma.txManager.Stop()
+}
+
+// SetTxConfig changes how operator transactions are priced and replaced
+func (plasma *Plasma) SetTxConfig(config TxConfig) error {
+	return plasma.txManager.SetConfig(config)
+}
+
+// GetDeposit checks the existence of a deposit nonce. Deposits are only returned once they have
+// `finalityBound` confirmations on the canonical chain
 func (plasma *Plasma) GetDeposit(nonce *big.Int) (*plasmaTypes.Deposit, error) {
 	key := prefixKey(depositPrefix, nonce.Bytes())
-	data, err := plasma.memdb.Get(key)
+	data, err := plasma.db.Get(key, nil)
 
 	var deposit plasmaTypes.Deposit
 	// check against the contract if the deposit is not in the cache or decoding fails
-	if err != nil || json.Unmarshal(data, &deposit) != nil {
-		if plasma.memdb.Contains(key) {
+	cached := err == nil && json.Unmarshal(data, &deposit) == nil
+	if !cached {
+		if err == nil {
 			plasma.logger.Info("corrupted deposit found within db")
-			plasma.memdb.Delete(key)
+			plasma.db.Delete(key, nil)
 		}
 
 		d, err := plasma.session.Deposits(nonce)
@@ -136,37 +172,52 @@ func (plasma *Plasma) GetDeposit(nonce *big.Int) (*plasmaTypes.Deposit, error) {
 			Amount:   sdk.NewUintFromBigInt(d.Amount),
 			BlockNum: sdk.NewUintFromBigInt(d.EthBlockNum),
 		}
-
-		// save to the db
-		data, err = json.Marshal(deposit)
-		if err != nil {
-			plasma.logger.Error("error encoding deposit. will not be cached")
-		} else {
-			plasma.memdb.Put(key, data)
-		}
 	}
 
 	// check finality bound for the deposit
-	plasma.lock.Lock()
-	ethBlockNum := plasma.ethBlockNum
-	plasma.lock.Unlock()
+	ethBlockNum := plasma.currentEthBlockNum()
 	if ethBlockNum.Sign() < 0 {
 		return nil, fmt.Errorf("not subscribed to ethereum block headers")
 	}
 
-	if new(big.Int).Sub(ethBlockNum, deposit.BlockNum.BigInt()).Uint64() < plasma.finalityBound {
+	finalized := new(big.Int).Add(deposit.BlockNum.BigInt(), new(big.Int).SetUint64(plasma.finalityBound))
+	if ethBlockNum.Cmp(finalized) < 0 {
 		return nil, fmt.Errorf("deposit not finalized")
 	}
 
+	// save to the db. Deposits read from the contract are cached once final as they are
+	// not recorded for rollback if the chain reorganizes
+	if !cached {
+		data, err = json.Marshal(deposit)
+		if err != nil {
+			plasma.logger.Error("error encoding deposit. will not be cached")
+		} else {
+			plasma.db.Put(key, data, nil)
+		}
+	}
+
 	return &deposit, nil
 }
 
-// HasTXBeenExited indicates if the position has ever been exited
+// HasBlockBeenSubmitted indicates if the block with the given header has been committed to the rootchain
+func (plasma *Plasma) HasBlockBeenSubmitted(blockNum *big.Int, header [32]byte) bool {
+	block, err := plasma.session.ChildChain(blockNum)
+	if err != nil {
+		plasma.logger.Error(fmt.Sprintf("Error querying contract %s", err))
+		return false
+	}
+
+	return block.Root == header
+}
+
+// HasTXBeenExited indicates if the position has been exited and the exit has `finalityBound`
+// confirmations on the canonical chain. Exits are read from the cache of rootchain events, from
+// which exits removed by a reorg are rolled back. Exits not yet cached are checked against the contract
 func (plasma *Plasma) HasTXBeenExited(position [4]*big.Int) bool {
 	var key []byte
 	var priority *big.Int
 	if position[3].Sign() == 0 { // utxo exit
-		txPos := [3]*big.Int{position[0], position[1], position[3]}
+		txPos := [3]*big.Int{position[0], position[1], position[2]}
 		priority = calcPriority(txPos)
 		key = prefixKey(transactionExitPrefix, priority.Bytes())
 	} else { // deposit exit
@@ -174,66 +225,83 @@ func (plasma *Plasma) HasTXBeenExited(position [4]*big.Int) bool {
 		key = prefixKey(depositExitPrefix, priority.Bytes())
 	}
 
-	type exit struct {
+	data, err := plasma.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return plasma.hasExitOnContract(position, priority)
+	} else if err != nil {
+		plasma.logger.Error(fmt.Sprintf("Error reading the exit cache %s", err))
+		return false
+	}
+
+	// exits cached before their block number was recorded are final
+	if len(data) != 8 {
+		return true
+	}
+
+	ethBlockNum := plasma.currentEthBlockNum()
+	if ethBlockNum.Sign() < 0 {
+		plasma.logger.Error("not subscribed to ethereum block headers")
+		return false
+	}
+
+	finalized := binary.BigEndian.Uint64(data) + plasma.finalityBound
+	return ethBlockNum.Uint64() >= finalized
+}
+
+// the subscription may not have delivered the exit yet. Exits that are not
+// final are included, as spending an output being exited is never safe
+func (plasma *Plasma) hasExitOnContract(position [4]*big.Int, priority *big.Int) bool {
+	var exit struct {
 		Amount    *big.Int
 		CreatedAt *big.Int
 		Owner     common.Address
 		State     uint8
 	}
+	var err error
+	if position[3].Sign() == 0 {
+		exit, err = plasma.session.TxExits(priority)
+	} else {
+		exit, err = plasma.session.DepositExits(priority)
+	}
 
-	if !plasma.memdb.Contains(key) {
-		var e exit
-		var err error
-		if position[3].Sign() == 0 {
-			e, err = plasma.session.TxExits(priority)
-		} else {
-			e, err = plasma.session.DepositExits(priority)
-		}
-
-		// default to true if the contract cannot be queried. Nothing should be spent
-		if err != nil {
-			plasma.logger.Error(fmt.Sprintf("Error querying contract %s", err))
-			return true
-		}
-
-		if e.State == 1 || e.State == 3 {
-			return true
-		} else {
-			return false
-		}
+	// default to true if the contract cannot be queried. Nothing should be spent
+	if err != nil {
+		plasma.logger.Error(fmt.Sprintf("Error querying contract %s", err))
+		return true
 	}
 
-	return true
+	// pending or finalized
+	return exit.State == 1 || exit.State == 3
 }
 
-func watchDeposits(plasma *Plasma) {
+func watchDeposits(plasma *Plasma) error {
 	// suscribe to future deposits
 	deposits := make(chan *contracts.PlasmaMVPDeposit)
 	opts := &bind.WatchOpts{
 		Start:   nil, // latest block
 		Context: context.Background(),
 	}
-	plasma.session.Contract.WatchDeposit(opts, deposits)
+	if _, err := plasma.session.Contract.WatchDeposit(opts, deposits); err != nil {
+		return err
+	}
 
-	for deposit := range deposits {
-		key := prefixKey(depositPrefix, deposit.DepositNonce.Bytes())
+	go func() {
+		for deposit := range deposits {
+			update, err := depositUpdate(deposit)
+			if err != nil {
+				plasma.logger.Error("Error encoding deposit event from contract -", deposit)
+				continue
+			}
+			plasma.process(deposit.Raw, update)
+		}
 
-		// remove the nonce, encode, and store
-		data, err := json.Marshal(plasmaTypes.Deposit{
-			Owner:    deposit.Depositor,
-			Amount:   sdk.NewUintFromBigInt(deposit.Amount),
-			BlockNum: sdk.NewUintFromBigInt(deposit.EthBlockNum),
-		})
+		plasma.logger.Info("stopped watching for deposits")
+	}()
 
-		if err != nil {
-			plasma.logger.Error("Error encoding deposit event from contract -", deposit)
-		} else {
-			plasma.memdb.Put(key, data)
-		}
-	}
+	return nil
 }
 
-func watchExits(plasma *Plasma) {
+func watchExits(plasma *Plasma) error {
 	startedDepositExits := make(chan *contracts.PlasmaMVPStartedDepositExit)
 	startedTransactionExits := make(chan *contracts.PlasmaMVPStartedTransactionExit)
 	challengedExits := make(chan *contracts.PlasmaMVPChallengedExit)
@@ -242,15 +310,19 @@ func watchExits(plasma *Plasma) {
 		Start:   nil, // latest block
 		Context: context.Background(),
 	}
-	plasma.session.Contract.WatchStartedDepositExit(opts, startedDepositExits)
-	plasma.session.Contract.WatchStartedTransactionExit(opts, startedTransactionExits)
-	plasma.session.Contract.WatchChallengedExit(opts, challengedExits)
+	if _, err := plasma.session.Contract.WatchStartedDepositExit(opts, startedDepositExits); err != nil {
+		return err
+	}
+	if _, err := plasma.session.Contract.WatchStartedTransactionExit(opts, startedTransactionExits); err != nil {
+		return err
+	}
+	if _, err := plasma.session.Contract.WatchChallengedExit(opts, challengedExits); err != nil {
+		return err
+	}
 
 	go func() {
 		for depositExit := range startedDepositExits {
-			nonce := depositExit.Nonce.Bytes()
-			key := prefixKey(depositExitPrefix, nonce)
-			plasma.memdb.Put(key, nil)
+			plasma.process(depositExit.Raw, depositExitUpdate(depositExit))
 		}
 
 		plasma.logger.Info("stopped watching for deposit exits")
@@ -258,9 +330,7 @@ func watchExits(plasma *Plasma) {
 
 	go func() {
 		for transactionExit := range startedTransactionExits {
-			priority := calcPriority(transactionExit.Position).Bytes()
-			key := prefixKey(transactionExitPrefix, priority)
-			plasma.memdb.Put(key, nil)
+			plasma.process(transactionExit.Raw, transactionExitUpdate(transactionExit))
 		}
 
 		plasma.logger.Info("stopped watching for transaction exits")
@@ -268,25 +338,259 @@ func watchExits(plasma *Plasma) {
 
 	go func() {
 		for challengedExit := range challengedExits {
-			if challengedExit.Position[3].Sign() == 0 {
-				position := [3]*big.Int{challengedExit.Position[0], challengedExit.Position[1], challengedExit.Position[2]}
-				key := prefixKey(transactionExitPrefix, calcPriority(position).Bytes())
-				plasma.memdb.Delete(key)
-			} else {
-				key := prefixKey(depositExitPrefix, challengedExit.Position[3].Bytes())
-				plasma.memdb.Delete(key)
-			}
+			plasma.process(challengedExit.Raw, challengedExitUpdate(challengedExit))
 		}
 
 		plasma.logger.Info("stopped watching for challenged exit")
 	}()
+
+	return nil
+}
+
+// a contract event and its change to the cache
+type cachedEvent struct {
+	raw    types.Log
+	update cacheUpdate
+}
+
+// rolls back the events of blocks no longer on the canonical chain, then applies the events
+// emitted since the last processed ethereum block in the order they were emitted, so that
+// deposits and exits missed while offline or replaced by a reorg are cached.
+// Events already cached are skipped
+func (plasma *Plasma) backfill() error {
+	plasma.cacheLock.Lock()
+	defer plasma.unlockCache()
+
+	if err := plasma.reconcile(); err != nil {
+		return err
+	}
+
+	head, err := plasma.client.ec.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	end := head.Number.Uint64()
+
+	opts := &bind.FilterOpts{
+		Start:   plasma.lastProcessedBlock(),
+		End:     &end,
+		Context: context.Background(),
+	}
+
+	var events []cachedEvent
+
+	deposits, err := plasma.session.Contract.FilterDeposit(opts)
+	if err != nil {
+		return err
+	}
+	for deposits.Next() {
+		update, err := depositUpdate(deposits.Event)
+		if err != nil {
+			return err
+		}
+		events = append(events, cachedEvent{deposits.Event.Raw, update})
+	}
+	if err := deposits.Error(); err != nil {
+		return err
+	}
+
+	depositExits, err := plasma.session.Contract.FilterStartedDepositExit(opts)
+	if err != nil {
+		return err
+	}
+	for depositExits.Next() {
+		events = append(events, cachedEvent{depositExits.Event.Raw, depositExitUpdate(depositExits.Event)})
+	}
+	if err := depositExits.Error(); err != nil {
+		return err
+	}
+
+	transactionExits, err := plasma.session.Contract.FilterStartedTransactionExit(opts)
+	if err != nil {
+		return err
+	}
+	for transactionExits.Next() {
+		events = append(events, cachedEvent{transactionExits.Event.Raw, transactionExitUpdate(transactionExits.Event)})
+	}
+	if err := transactionExits.Error(); err != nil {
+		return err
+	}
+
+	challengedExits, err := plasma.session.Contract.FilterChallengedExit(opts)
+	if err != nil {
+		return err
+	}
+	for challengedExits.Next() {
+		events = append(events, cachedEvent{challengedExits.Event.Raw, challengedExitUpdate(challengedExits.Event)})
+	}
+	if err := challengedExits.Error(); err != nil {
+		return err
+	}
+
+	// an exit may have been challenged after it started
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].raw.BlockNumber != events[j].raw.BlockNumber {
+			return events[i].raw.BlockNumber < events[j].raw.BlockNumber
+		}
+		return events[i].raw.Index < events[j].raw.Index
+	})
+	for _, event := range events {
+		// a conflicting event means the chain reorganized while filtering. The new head resyncs the cache
+		if _, err := plasma.apply(event.raw, event.update); err != nil {
+			return err
+		}
+	}
+
+	plasma.logger.Info(fmt.Sprintf("Backfilled %d rootchain events from ethereum block %d to %d", len(events), opts.Start, end))
+	return plasma.setProcessedBlock(end, head.Hash())
+}
+
+func depositUpdate(deposit *contracts.PlasmaMVPDeposit) (cacheUpdate, error) {
+	key := prefixKey(depositPrefix, deposit.DepositNonce.Bytes())
+
+	// remove the nonce, encode, and store
+	data, err := json.Marshal(plasmaTypes.Deposit{
+		Owner:    deposit.Depositor,
+		Amount:   sdk.NewUintFromBigInt(deposit.Amount),
+		BlockNum: sdk.NewUintFromBigInt(deposit.EthBlockNum),
+	})
+
+	zero := big.NewInt(0)
+	event := Event{Type: EventDeposit, Position: [4]*big.Int{zero, zero, zero, deposit.DepositNonce}}
+
+	return cacheUpdate{source: depositSource, key: key, value: data, event: event}, err
+}
+
+// exits are cached with the ethereum block they were started in so their finality can be checked
+func depositExitUpdate(depositExit *contracts.PlasmaMVPStartedDepositExit) cacheUpdate {
+	zero := big.NewInt(0)
+	event := Event{Type: EventExitStarted, Position: [4]*big.Int{zero, zero, zero, depositExit.Nonce}}
+
+	return cacheUpdate{source: depositExitSource, key: prefixKey(depositExitPrefix, depositExit.Nonce.Bytes()), value: exitBlockNum(depositExit.Raw), event: event}
+}
+
+func transactionExitUpdate(transactionExit *contracts.PlasmaMVPStartedTransactionExit) cacheUpdate {
+	priority := calcPriority(transactionExit.Position).Bytes()
+	position := transactionExit.Position
+	event := Event{Type: EventExitStarted, Position: [4]*big.Int{position[0], position[1], position[2], big.NewInt(0)}}
+
+	return cacheUpdate{source: transactionExitSource, key: prefixKey(transactionExitPrefix, priority), value: exitBlockNum(transactionExit.Raw), event: event}
+}
+
+func exitBlockNum(raw types.Log) []byte {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, raw.BlockNumber)
+	return value
+}
+
+func challengedExitUpdate(challengedExit *contracts.PlasmaMVPChallengedExit) cacheUpdate {
+	event := Event{Type: EventExitChallenged, Position: challengedExit.Position}
+	if challengedExit.Position[3].Sign() == 0 {
+		position := [3]*big.Int{challengedExit.Position[0], challengedExit.Position[1], challengedExit.Position[2]}
+		return cacheUpdate{source: challengedExitSource, key: prefixKey(transactionExitPrefix, calcPriority(position).Bytes()), delete: true, event: event}
+	}
+
+	return cacheUpdate{source: challengedExitSource, key: prefixKey(depositExitPrefix, challengedExit.Position[3].Bytes()), delete: true, event: event}
+}
+
+// ethereum block up to which the events of every subscription have been cached. Backfilling
+// resumes from it so that no subscription misses events. 0 if nothing has been cached
+func (plasma *Plasma) lastProcessedBlock() uint64 {
+	var processed uint64
+	for i, source := range eventSources {
+		blockNum, _ := plasma.processedBlock(source)
+		if i == 0 || blockNum < processed {
+			processed = blockNum
+		}
+	}
+	return processed
+}
+
+// ethereum block up to which the events of `source` have been cached along with its hash. The hash
+// is empty if unknown
+func (plasma *Plasma) processedBlock(source string) (uint64, common.Hash) {
+	data, err := plasma.db.Get(prefixKey(processedBlockPrefix, []byte(source)), nil)
+	if err != nil || len(data) < 8 {
+		return 0, common.Hash{}
+	}
+
+	var hash common.Hash
+	if len(data) == 8+common.HashLength {
+		hash = common.BytesToHash(data[8:])
+	}
+	return binary.BigEndian.Uint64(data[:8]), hash
+}
+
+// records that the events of every subscription up to `blockNum` have been cached. Never moves backwards
+func (plasma *Plasma) setProcessedBlock(blockNum uint64, hash common.Hash) error {
+	batch := new(leveldb.Batch)
+	for _, source := range eventSources {
+		if processed, _ := plasma.processedBlock(source); blockNum > processed {
+			putProcessedBlock(batch, source, blockNum, hash)
+		}
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	return plasma.db.Write(batch, nil)
+}
+
+func putProcessedBlock(batch *leveldb.Batch, source string, blockNum uint64, hash common.Hash) {
+	data := make([]byte, 8, 8+common.HashLength)
+	binary.BigEndian.PutUint64(data, blockNum)
+	batch.Put(prefixKey(processedBlockPrefix, []byte(source)), append(data, hash.Bytes()...))
+}
+
+// latest ethereum block number seen. Negative if no header has been received
+func (plasma *Plasma) currentEthBlockNum() *big.Int {
+	plasma.lock.Lock()
+	defer plasma.lock.Unlock()
+	return plasma.ethBlockNum
+}
+
+// SubscribeEvents sends deposits and exits to `ch` as they are cached, including those
+// backfilled. Events rolled back by a reorg are sent again as removed
+func (plasma *Plasma) SubscribeEvents(ch chan<- Event) event.Subscription {
+	return plasma.events.Subscribe(ch)
+}
+
+// Sync catches up with the latest ethereum block and caches the events emitted up to it,
+// rather than waiting on the subscriptions to deliver them
+func (plasma *Plasma) Sync() error {
+	head, err := plasma.client.ec.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	plasma.lock.Lock()
+	if plasma.ethBlockNum.Cmp(head.Number) < 0 {
+		plasma.ethBlockNum = head.Number
+	}
+	plasma.lock.Unlock()
+
+	return plasma.backfill()
 }
 
 func watchEthBlocks(plasma *Plasma, ch <-chan *types.Header) {
+	var parent common.Hash
 	for header := range ch {
 		plasma.lock.Lock()
 		plasma.ethBlockNum = header.Number
 		plasma.lock.Unlock()
+
+		// a head that does not build on the previous one replaced part of the chain
+		if parent != (common.Hash{}) && header.ParentHash != parent {
+			plasma.logger.Info(fmt.Sprintf("Ethereum chain reorganized at block %s", header.Number))
+			if err := plasma.backfill(); err != nil {
+				plasma.logger.Error(fmt.Sprintf("Could not resync rootchain events after a reorg - %s", err))
+			}
+		}
+		parent = header.Hash()
+
+		if err := plasma.prune(); err != nil {
+			plasma.logger.Error(fmt.Sprintf("Could not prune rootchain event records - %s", err))
+		}
 	}
 
 	plasma.logger.Info("Block subscription closed.")
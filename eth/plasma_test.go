@@ -2,10 +2,14 @@ package eth
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/json"
 	plasmaTypes "github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/tendermint/tendermint/libs/log"
@@ -16,63 +20,106 @@ import (
 	"time"
 )
 
-// private/public keys using the `plasma` mnemonic with ganache-cli
-// `ganache-cli -m=plasma`
-// plasmaContractAddr will be deterministic. `truffle migrate` immediately after `ganache-cli -m=plasma`
 const (
-	clientAddr         = "ws://127.0.0.1:8545"
-	plasmaContractAddr = "5cae340fb2c2bb0a2f194a95cda8a1ffdc9d2f85"
-	operatorPrivKey    = "9cd69f009ac86203e54ec50e3686de95ff6126d3b30a19f926a0fe9323c17181"
+	// operator of the simulated contract
+	operatorPrivKey = "9cd69f009ac86203e54ec50e3686de95ff6126d3b30a19f926a0fe9323c17181"
+
+	// enough gas to deploy the rootchain contract
+	simulatedGasLimit = 8000000
+
+	// written by `truffle compile` within contracts/
+	plasmaArtifact = "../contracts/build/contracts/PlasmaMVP.json"
+
+	// chain ID the simulated contract is deployed for
+	testChainID = "plasma"
 
 	minExitBond = 10000
 )
 
-func TestConnection(t *testing.T) {
-	logger := log.NewTMLogger(os.Stderr)
+// deploys the rootchain contract to an in-process chain with the operator as its only funded account
+func newSimulatedPlasma(t *testing.T) (*Plasma, *SimulatedBackend, *ecdsa.PrivateKey) {
+	bytecode, err := PlasmaMVPBytecode(plasmaArtifact)
+	if err != nil {
+		t.Skip("Rootchain contract not compiled -", err)
+	}
+
+	privKey, _ := crypto.HexToECDSA(operatorPrivKey)
+	balance, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	sim := NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(privKey.PublicKey): core.GenesisAccount{Balance: balance},
+	}, simulatedGasLimit)
 
-	t.Logf("Connecting to remote client: %s", clientAddr)
-	client, err := InitEthConn(clientAddr, logger)
+	contractAddr, err := DeployPlasmaMVP(bind.NewKeyedTransactor(privKey), sim, bytecode, testChainID)
 	if err != nil {
-		t.Fatal("Connection Error -", err)
+		t.Fatal("Could not deploy contract -", err)
 	}
 
-	_, err = client.accounts()
+	logger := log.NewTMLogger(os.Stderr)
+	plasma, err := InitPlasma(contractAddr, privKey, NewClient(sim, logger), logger, 0, "")
 	if err != nil {
-		t.Error("Error Retrieving Accounts -", err)
+		t.Fatal("Could not bind contract -", err)
 	}
+
+	return plasma, sim, privKey
 }
 
-func TestPlasmaInit(t *testing.T) {
-	logger := log.NewTMLogger(os.Stderr)
-	client, err := InitEthConn(clientAddr, logger)
+// polls `condition` until it holds. False if it does not hold within `timeout`
+func eventually(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
 
-	privKey, _ := crypto.HexToECDSA(operatorPrivKey)
-	_, err = InitPlasma(common.HexToAddress(plasmaContractAddr), privKey, client, logger, 1)
+	return true
+}
+
+// caches the events of the mined transactions
+func syncPlasma(t *testing.T, plasma *Plasma) {
+	if err := plasma.Sync(); err != nil {
+		t.Fatal("Could not sync with the rootchain -", err)
+	}
+}
+
+func TestConnection(t *testing.T) {
+	client := NewClient(NewSimulatedBackend(core.GenesisAlloc{}, simulatedGasLimit), log.NewTMLogger(os.Stderr))
+
+	blockNum, err := client.CurrentBlockNum()
 	if err != nil {
-		t.Fatal("Could not bind contract -", err)
+		t.Fatal("Connection Error -", err)
+	}
+	if blockNum.Sign() != 0 {
+		t.Errorf("Simulated chain does not start at genesis. Got block %d", blockNum)
+	}
+
+	if _, err = client.accounts(); err == nil {
+		t.Errorf("Accounts retrieved without a node")
 	}
 }
 
+func TestPlasmaInit(t *testing.T) {
+	newSimulatedPlasma(t)
+}
+
 func TestSubmitBlock(t *testing.T) {
-	logger := log.NewTMLogger(os.Stderr)
-	client, _ := InitEthConn(clientAddr, logger)
+	plasma, _, _ := newSimulatedPlasma(t)
 
-	privKey, _ := crypto.HexToECDSA(operatorPrivKey)
-	plasma, _ := InitPlasma(common.HexToAddress(plasmaContractAddr), privKey, client, logger, 1)
+	lastCommittedBlock, err := plasma.session.LastCommittedBlock()
+	if err != nil {
+		t.Fatal("Failed query for the last committed block -", err)
+	}
+	blockNum := new(big.Int).Add(lastCommittedBlock, big.NewInt(1))
 
 	header := crypto.Keccak256([]byte("blah"))
 	var root [32]byte
 	copy(root[:], header)
-	_, err := plasma.SubmitBlock(root, big.NewInt(0))
+	_, err = plasma.SubmitBlock([][32]byte{root}, []*big.Int{big.NewInt(0)}, blockNum)
 	if err != nil {
 		t.Fatal("Failed block submission -", err)
 	}
 
-	blockNum, err := plasma.session.LastCommittedBlock()
-	if err != nil {
-		t.Fatal("Failed query for the last committed block -", err)
-	}
-
 	result, err := plasma.session.ChildChain(blockNum)
 	if err != nil {
 		t.Fatal("Failed query for the child chain - ", err)
@@ -84,32 +131,20 @@ func TestSubmitBlock(t *testing.T) {
 }
 
 func TestEthBlockWatching(t *testing.T) {
-	logger := log.NewTMLogger(os.Stderr)
-	client, _ := InitEthConn(clientAddr, logger)
-
-	privKey, _ := crypto.HexToECDSA(operatorPrivKey)
-	plasma, _ := InitPlasma(common.HexToAddress(plasmaContractAddr), privKey, client, logger, 1)
-
-	// mine a block so that `ethBlockNum` within plasma gets set
-	// sleep after an rpc call to deal with the asynchrony
-	if err := client.rpc.Call(nil, "evm_mine"); err != nil {
-		t.Fatal("Could not mine a block -", err)
+	plasma, sim, _ := newSimulatedPlasma(t)
+	syncPlasma(t, plasma)
+	lastEthBlockNum := plasma.currentEthBlockNum().Uint64()
+
+	// mine a block that should get caught by the header subscription
+	sim.Commit()
+
+	var currEthBlockNum uint64
+	for i := 0; i < 100; i++ {
+		if currEthBlockNum = plasma.currentEthBlockNum().Uint64(); currEthBlockNum > lastEthBlockNum {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
 	}
-	time.Sleep(1 * time.Second)
-
-	plasma.lock.Lock()
-	lastEthBlockNum := plasma.ethBlockNum.Uint64()
-	plasma.lock.Unlock()
-
-	// mine another block that should get caught
-	if err := client.rpc.Call(nil, "evm_mine"); err != nil {
-		t.Fatal("Could not mine a block -", err)
-	}
-	time.Sleep(1 * time.Second)
-
-	plasma.lock.Lock()
-	currEthBlockNum := plasma.ethBlockNum.Uint64()
-	plasma.lock.Unlock()
 	if currEthBlockNum != lastEthBlockNum+1 {
 		t.Fatalf("EthBlockNum not incremented. Expected: %d, Got: %d",
 			lastEthBlockNum+1, currEthBlockNum)
@@ -117,11 +152,7 @@ func TestEthBlockWatching(t *testing.T) {
 }
 
 func TestDepositWatching(t *testing.T) {
-	logger := log.NewTMLogger(os.Stderr)
-	client, _ := InitEthConn(clientAddr, logger)
-
-	privKey, _ := crypto.HexToECDSA(operatorPrivKey)
-	plasma, _ := InitPlasma(common.HexToAddress(plasmaContractAddr), privKey, client, logger, 0)
+	plasma, _, privKey := newSimulatedPlasma(t)
 
 	nonce, err := plasma.session.DepositNonce()
 	if err != nil {
@@ -129,13 +160,19 @@ func TestDepositWatching(t *testing.T) {
 	}
 
 	// Deposit 10 eth from the operator
-	plasma.session.TransactOpts.Value = big.NewInt(10)
 	operatorAddress := crypto.PubkeyToAddress(privKey.PublicKey)
-	_, err = plasma.session.Deposit(operatorAddress)
+	_, err = plasma.txManager.Transact(big.NewInt(10), "deposit", operatorAddress)
 	if err != nil {
 		t.Fatalf("Error sending a deposit tx")
 	}
-	time.Sleep(500 * time.Millisecond)
+	syncPlasma(t, plasma)
+
+	// check persistence in the db
+	key := prefixKey(depositPrefix, nonce.Bytes())
+	data, err := plasma.db.Get(key, nil)
+	if err != nil {
+		t.Fatalf("Deposit not persisted - %s", err)
+	}
 
 	deposit, err := plasma.GetDeposit(nonce)
 	if err != nil {
@@ -150,13 +187,6 @@ func TestDepositWatching(t *testing.T) {
 		t.Errorf("Deposit owner incorrect. Expected %x, Got %x", operatorAddress, deposit.Owner)
 	}
 
-	// check persistence in the db
-	key := prefixKey(depositPrefix, nonce.Bytes())
-	data, err := plasma.memdb.Get(key)
-	if err != nil {
-		t.Fatalf("Deposit not persisted - %s", err)
-	}
-
 	var d plasmaTypes.Deposit
 	err = json.Unmarshal(data, &d)
 	if err != nil {
@@ -168,27 +198,54 @@ func TestDepositWatching(t *testing.T) {
 	}
 }
 
-func TestDepositExitWatching(t *testing.T) {
+func TestEventBackfill(t *testing.T) {
+	plasma, sim, privKey := newSimulatedPlasma(t)
+
+	// deposit and exit before the second cache is created
+	nonce, _ := plasma.session.DepositNonce()
+	_, err := plasma.txManager.Transact(big.NewInt(10), "deposit", crypto.PubkeyToAddress(privKey.PublicKey))
+	if err != nil {
+		t.Fatal("Failed deposit -", err)
+	}
+	_, err = plasma.txManager.Transact(big.NewInt(minExitBond), "startDepositExit", nonce)
+	if err != nil {
+		t.Fatal("Error starting deposit exit -", err)
+	}
+
 	logger := log.NewTMLogger(os.Stderr)
-	client, _ := InitEthConn(clientAddr, logger)
+	backfilled, err := InitPlasma(plasma.txManager.address, privKey, NewClient(sim, logger), logger, 0, "")
+	if err != nil {
+		t.Fatal("Could not backfill -", err)
+	}
 
-	privKey, _ := crypto.HexToECDSA(operatorPrivKey)
-	plasma, _ := InitPlasma(common.HexToAddress(plasmaContractAddr), privKey, client, logger, 0)
+	if has, _ := backfilled.db.Has(prefixKey(depositPrefix, nonce.Bytes()), nil); !has {
+		t.Errorf("Deposit not backfilled")
+	}
+	if has, _ := backfilled.db.Has(prefixKey(depositExitPrefix, nonce.Bytes()), nil); !has {
+		t.Errorf("Deposit exit not backfilled")
+	}
+
+	head, _ := sim.HeaderByNumber(context.Background(), nil)
+	if backfilled.lastProcessedBlock() != head.Number.Uint64() {
+		t.Errorf("Mismatch in last processed block. Got: %d. Expected: %d", backfilled.lastProcessedBlock(), head.Number)
+	}
+}
+
+func TestDepositExitWatching(t *testing.T) {
+	plasma, _, privKey := newSimulatedPlasma(t)
 
 	// deposit and exit
 	nonce, _ := plasma.session.DepositNonce()
-	plasma.session.TransactOpts.Value = big.NewInt(10)
-	_, err := plasma.session.Deposit(crypto.PubkeyToAddress(privKey.PublicKey))
+	_, err := plasma.txManager.Transact(big.NewInt(10), "deposit", crypto.PubkeyToAddress(privKey.PublicKey))
 	if err != nil {
 		t.Fatal("Failed deposit -", err)
 	}
 
-	plasma.session.TransactOpts.Value = big.NewInt(minExitBond)
-	_, err = plasma.session.StartDepositExit(nonce)
+	_, err = plasma.txManager.Transact(big.NewInt(minExitBond), "startDepositExit", nonce)
 	if err != nil {
 		t.Fatal("Error starting deposit exit -", err)
 	}
-	time.Sleep(500 * time.Millisecond)
+	syncPlasma(t, plasma)
 
 	zero := big.NewInt(0)
 	position := [4]*big.Int{zero, zero, zero, nonce}
@@ -199,6 +256,36 @@ func TestDepositExitWatching(t *testing.T) {
 	}
 }
 
+func TestExitFinality(t *testing.T) {
+	plasma, sim, privKey := newSimulatedPlasma(t)
+	plasma.finalityBound = 2
+
+	nonce, _ := plasma.session.DepositNonce()
+	_, err := plasma.txManager.Transact(big.NewInt(10), "deposit", crypto.PubkeyToAddress(privKey.PublicKey))
+	if err != nil {
+		t.Fatal("Failed deposit -", err)
+	}
+	_, err = plasma.txManager.Transact(big.NewInt(minExitBond), "startDepositExit", nonce)
+	if err != nil {
+		t.Fatal("Error starting deposit exit -", err)
+	}
+	syncPlasma(t, plasma)
+
+	zero := big.NewInt(0)
+	position := [4]*big.Int{zero, zero, zero, nonce}
+	if plasma.HasTXBeenExited(position) {
+		t.Errorf("Exit marked as exited before it was finalized")
+	}
+
+	sim.Commit()
+	sim.Commit()
+	syncPlasma(t, plasma)
+
+	if !plasma.HasTXBeenExited(position) {
+		t.Errorf("Exit not marked as exited after it was finalized")
+	}
+}
+
 type SpendMsg struct {
 	Blknum0           uint64
 	Txindex0          uint16
@@ -228,18 +315,19 @@ func toEthSignedMessageHash(msg []byte) []byte {
 	return crypto.Keccak256(buffer.Bytes())
 }
 
-func TestTxExitWatchingAndChallenge(t *testing.T) {
-	logger := log.NewTMLogger(os.Stderr)
-	client, _ := InitEthConn(clientAddr, logger)
+// hash input owners sign for transactions exited from the contract of `plasma`
+func signHash(plasma *Plasma, msgBytes []byte) []byte {
+	domain := plasmaTypes.NewSignDomain(plasmaTypes.SignVersionDomain, testChainID, plasma.txManager.address)
+	return toEthSignedMessageHash(domain.SignHash(msgBytes))
+}
 
-	privKey, _ := crypto.HexToECDSA(operatorPrivKey)
-	plasma, _ := InitPlasma(common.HexToAddress(plasmaContractAddr), privKey, client, logger, 0)
+func TestTxExitWatchingAndChallenge(t *testing.T) {
+	plasma, _, privKey := newSimulatedPlasma(t)
 	zero := big.NewInt(0)
 
 	// deposit and spend
 	nonce, _ := plasma.session.DepositNonce()
-	plasma.session.TransactOpts.Value = big.NewInt(10)
-	_, err := plasma.session.Deposit(crypto.PubkeyToAddress(privKey.PublicKey))
+	_, err := plasma.txManager.Transact(big.NewInt(10), "deposit", crypto.PubkeyToAddress(privKey.PublicKey))
 	if err != nil {
 		t.Fatal("Failed deposit -", err)
 	}
@@ -252,22 +340,21 @@ func TestTxExitWatchingAndChallenge(t *testing.T) {
 	msg.Amount0 = 10
 	txList, _ := rlp.EncodeToBytes(msg)
 
-	txHash := crypto.Keccak256(txList)
-	txHash = toEthSignedMessageHash(txHash)
-	sig0, _ := crypto.Sign(txHash, privKey)
+	sig0, _ := crypto.Sign(signHash(plasma, txList), privKey)
 	sigs := [2][]byte{sig0, make([]byte, 65)}
 
 	txBytes, _ := rlp.EncodeToBytes(tx{msg, sigs})
 
 	// submit header. header == merklehash
 	header := sha256.Sum256(txBytes)
-	plasma.session.TransactOpts.Value = nil
-	_, err = plasma.SubmitBlock(header, big.NewInt(1))
+	lastCommittedBlock, _ := plasma.session.LastCommittedBlock()
+	blockNum := new(big.Int).Add(lastCommittedBlock, big.NewInt(1))
+	_, err = plasma.SubmitBlock([][32]byte{header}, []*big.Int{big.NewInt(1)}, blockNum)
 	if err != nil {
 		t.Fatal("Error submitting block -", err)
 	}
 
-	time.Sleep(500 * time.Millisecond)
+	syncPlasma(t, plasma)
 
 	// merkleHash == header
 	var data []byte
@@ -277,14 +364,13 @@ func TestTxExitWatchingAndChallenge(t *testing.T) {
 	confHash := toEthSignedMessageHash(confirmationHash[:])
 	confirmSignature, _ := crypto.Sign(confHash, privKey)
 
-	plasma.session.TransactOpts.Value = big.NewInt(minExitBond)
-	_, err = plasma.session.StartTransactionExit([3]*big.Int{plasma.blockNum, zero, zero}, txBytes, []byte{}, confirmSignature)
+	_, err = plasma.txManager.Transact(big.NewInt(minExitBond), "startTransactionExit", [3]*big.Int{blockNum, zero, zero}, txBytes, []byte{}, confirmSignature)
 	if err != nil {
 		t.Fatal("Error starting tx exit -", err)
 	}
-	time.Sleep(500 * time.Millisecond)
+	syncPlasma(t, plasma)
 
-	txPos := [4]*big.Int{plasma.blockNum, zero, zero, zero}
+	txPos := [4]*big.Int{blockNum, zero, zero, zero}
 	exited := plasma.HasTXBeenExited(txPos)
 	if !exited {
 		t.Errorf("Transaction not marked as exited")
@@ -292,25 +378,73 @@ func TestTxExitWatchingAndChallenge(t *testing.T) {
 
 	// attempt to exit the deposit & challenge
 	depositPos := [4]*big.Int{zero, zero, zero, nonce}
-	_, err = plasma.session.StartDepositExit(nonce)
+	_, err = plasma.txManager.Transact(big.NewInt(minExitBond), "startDepositExit", nonce)
 	if err != nil {
 		t.Fatal("Error exiting deposit -", err)
 	}
-	time.Sleep(500 * time.Millisecond)
+	syncPlasma(t, plasma)
 
 	exited = plasma.HasTXBeenExited(depositPos)
 	if !exited {
 		t.Errorf("Deposit not marked as exited after exiting")
 	}
-	plasma.session.TransactOpts.Value = nil
-	_, err = plasma.session.ChallengeExit(depositPos, [2]*big.Int{plasma.blockNum, zero}, txBytes, []byte{}, confirmSignature)
+	_, err = plasma.txManager.Transact(nil, "challengeExit", depositPos, [2]*big.Int{blockNum, zero}, txBytes, []byte{}, confirmSignature)
 	if err != nil {
 		t.Fatal("Error challenging exit -", err)
 	}
-	time.Sleep(500 * time.Millisecond)
+	syncPlasma(t, plasma)
 
 	exited = plasma.HasTXBeenExited(depositPos)
 	if exited {
 		t.Errorf("Deposit marked as exited after being challenged")
 	}
 }
+
+// Transactions built by the sidechain must be accepted by the contract
+func TestSidechainTxExit(t *testing.T) {
+	plasma, _, privKey := newSimulatedPlasma(t)
+	owner := crypto.PubkeyToAddress(privKey.PublicKey)
+	zero := big.NewInt(0)
+
+	nonce, _ := plasma.session.DepositNonce()
+	_, err := plasma.txManager.Transact(big.NewInt(10), "deposit", owner)
+	if err != nil {
+		t.Fatal("Failed deposit -", err)
+	}
+
+	input := plasmaTypes.NewTxInput(plasmaTypes.NewPlasmaPosition(0, 0, 0, nonce.Uint64()), owner)
+	msg := plasmaTypes.NewMultiSpendMsg([]plasmaTypes.TxInput{input}, []plasmaTypes.TxOutput{{Owner: owner, Amount: 10}}, 0)
+	spendTx, err := plasmaTypes.NewSpendTx(msg, 10, func(signer common.Address, signBytes []byte) (sig [65]byte, err error) {
+		bz, err := crypto.Sign(signHash(plasma, signBytes), privKey)
+		copy(sig[:], bz)
+		return sig, err
+	})
+	if err != nil {
+		t.Fatal("Could not build transaction -", err)
+	}
+	if _, ok := spendTx.(plasmaTypes.BaseTx); !ok {
+		t.Fatal("Transaction not built in the legacy form")
+	}
+	txBytes, _ := rlp.EncodeToBytes(spendTx)
+
+	header := sha256.Sum256(txBytes)
+	lastCommittedBlock, _ := plasma.session.LastCommittedBlock()
+	blockNum := new(big.Int).Add(lastCommittedBlock, big.NewInt(1))
+	_, err = plasma.SubmitBlock([][32]byte{header}, []*big.Int{big.NewInt(1)}, blockNum)
+	if err != nil {
+		t.Fatal("Error submitting block -", err)
+	}
+
+	confirmationHash := sha256.Sum256(append(header[:], header[:]...))
+	confirmSignature, _ := crypto.Sign(toEthSignedMessageHash(confirmationHash[:]), privKey)
+
+	_, err = plasma.txManager.Transact(big.NewInt(minExitBond), "startTransactionExit", [3]*big.Int{blockNum, zero, zero}, txBytes, []byte{}, confirmSignature)
+	if err != nil {
+		t.Fatal("Contract rejected the exit of a sidechain transaction -", err)
+	}
+	syncPlasma(t, plasma)
+
+	if !plasma.HasTXBeenExited([4]*big.Int{blockNum, zero, zero, zero}) {
+		t.Errorf("Sidechain transaction not marked as exited")
+	}
+}
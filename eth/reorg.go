@@ -0,0 +1,338 @@
+package eth
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	dbutil "github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// deepest reorg the cache can be rolled back through. Records of older events are pruned
+const maxReorgDepth = 256
+
+// contract event subscriptions. Each is delivered independently, so the
+// ethereum block up to which events have been cached is tracked per subscription
+const (
+	depositSource         = "deposit"
+	depositExitSource     = "depositExit"
+	transactionExitSource = "transactionExit"
+	challengedExitSource  = "challengedExit"
+)
+
+var eventSources = []string{depositSource, depositExitSource, transactionExitSource, challengedExitSource}
+
+// change to the cache made by a contract event
+type cacheUpdate struct {
+	source string
+
+	key    []byte
+	value  []byte
+	delete bool
+
+	event Event
+}
+
+// what is needed to undo a cached event
+type eventRecord struct {
+	BlockHash common.Hash
+	Key       []byte
+	Previous  []byte
+	Existed   bool
+
+	Event Event
+}
+
+// records are ordered by the position of their event on the rootchain
+func eventKey(blockNum uint64, index uint) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], blockNum)
+	binary.BigEndian.PutUint64(key[8:], uint64(index))
+	return prefixKey(eventPrefix, key)
+}
+
+func eventBlockNum(key []byte) uint64 {
+	return binary.BigEndian.Uint64(key[len(prefixKey(eventPrefix, nil)):])
+}
+
+// applies an event received from a subscription. A log removed by a reorg, or an event
+// conflicting with the cached event of a replaced block, resyncs the cache with the canonical chain
+func (plasma *Plasma) process(raw types.Log, update cacheUpdate) {
+	if !raw.Removed {
+		plasma.cacheLock.Lock()
+		conflict, err := plasma.apply(raw, update)
+		plasma.unlockCache()
+
+		if err != nil {
+			plasma.logger.Error(fmt.Sprintf("Could not cache rootchain event - %s", err))
+			return
+		} else if !conflict {
+			return
+		}
+	}
+
+	plasma.logger.Info(fmt.Sprintf("Rootchain event of ethereum block %d was reorganized", raw.BlockNumber))
+	if err := plasma.backfill(); err != nil {
+		plasma.logger.Error(fmt.Sprintf("Could not resync rootchain events after a reorg - %s", err))
+	}
+}
+
+// writes an event to the cache along with the record needed to roll it back. Events already
+// cached are skipped. Returns true if a different event is cached at the same position, meaning
+// the cache has not been reconciled with a reorg. The cache lock must be held
+func (plasma *Plasma) apply(raw types.Log, update cacheUpdate) (bool, error) {
+	recordKey := eventKey(raw.BlockNumber, raw.Index)
+	data, err := plasma.db.Get(recordKey, nil)
+	if err == nil {
+		var record eventRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return false, err
+		}
+		return record.BlockHash != raw.BlockHash, nil
+	} else if err != leveldb.ErrNotFound {
+		return false, err
+	}
+
+	previous, err := plasma.db.Get(update.key, nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return false, err
+	}
+	existed := err == nil
+
+	event := update.event
+	event.EthBlockNum = raw.BlockNumber
+
+	data, err = json.Marshal(eventRecord{
+		BlockHash: raw.BlockHash,
+		Key:       update.key,
+		Previous:  previous,
+		Existed:   existed,
+		Event:     event,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	batch := new(leveldb.Batch)
+	if update.delete {
+		batch.Delete(update.key)
+	} else {
+		batch.Put(update.key, update.value)
+	}
+	batch.Put(recordKey, data)
+	if processed, _ := plasma.processedBlock(update.source); raw.BlockNumber > processed {
+		putProcessedBlock(batch, update.source, raw.BlockNumber, raw.BlockHash)
+	}
+	if err := plasma.db.Write(batch, nil); err != nil {
+		return false, err
+	}
+
+	plasma.unsent = append(plasma.unsent, event)
+	return false, nil
+}
+
+// releases the cache lock and then sends the events cached or rolled back while it was
+// held, so that a slow subscriber never holds up the cache
+func (plasma *Plasma) unlockCache() {
+	events := plasma.unsent
+	plasma.unsent = nil
+	plasma.cacheLock.Unlock()
+
+	for _, event := range events {
+		plasma.events.Send(event)
+	}
+}
+
+// rolls back the cached events of blocks that are no longer on the canonical chain.
+// The cache lock must be held
+func (plasma *Plasma) reconcile() error {
+	fork, reorged, err := plasma.findFork()
+	if err != nil || !reorged {
+		return err
+	}
+
+	depth, err := plasma.rollback(fork)
+	if err != nil {
+		return err
+	}
+
+	plasma.logger.Info(fmt.Sprintf("Rolled back rootchain events of %d ethereum blocks after a reorg to block %d", depth, fork))
+	return nil
+}
+
+// finds the newest block with a cached event that is still on the canonical chain. Returns false
+// if the last processed block of every subscription is canonical, in which case so are all the cached events
+func (plasma *Plasma) findFork() (uint64, bool, error) {
+	var processed uint64
+	reorged := false
+	for _, source := range eventSources {
+		blockNum, hash := plasma.processedBlock(source)
+		if blockNum == 0 || hash == (common.Hash{}) {
+			continue
+		}
+		if blockNum > processed {
+			processed = blockNum
+		}
+
+		canonical, err := plasma.canonicalHash(blockNum)
+		if err != nil {
+			return 0, false, err
+		}
+		if canonical != hash {
+			reorged = true
+		}
+	}
+	if !reorged {
+		return 0, false, nil
+	}
+
+	iter := plasma.db.NewIterator(dbutil.BytesPrefix(prefixKey(eventPrefix, nil)), nil)
+	defer iter.Release()
+
+	var checked uint64
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		blockNum := eventBlockNum(iter.Key())
+		if blockNum == checked {
+			continue
+		}
+		checked = blockNum
+
+		var record eventRecord
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			return 0, false, err
+		}
+
+		canonical, err := plasma.canonicalHash(blockNum)
+		if err != nil {
+			return 0, false, err
+		}
+		if canonical == record.BlockHash {
+			return blockNum, true, nil
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return 0, false, err
+	}
+
+	// none of the recorded events are canonical. Events older than the records are final
+	if processed < maxReorgDepth {
+		return 0, true, nil
+	}
+	return processed - maxReorgDepth, true, nil
+}
+
+// canonical hash of ethereum block `blockNum`. Empty if the block does not exist
+func (plasma *Plasma) canonicalHash(blockNum uint64) (common.Hash, error) {
+	header, err := plasma.client.ec.HeaderByNumber(context.Background(), new(big.Int).SetUint64(blockNum))
+	if err == ethereum.NotFound {
+		return common.Hash{}, nil
+	} else if err != nil {
+		return common.Hash{}, err
+	}
+
+	return header.Hash(), nil
+}
+
+// undoes the cached events emitted after ethereum block `fork`, newest first, and returns the
+// number of blocks rolled back. The cache lock must be held
+func (plasma *Plasma) rollback(fork uint64) (int, error) {
+	iter := plasma.db.NewIterator(&dbutil.Range{
+		Start: eventKey(fork+1, 0),
+		Limit: dbutil.BytesPrefix(prefixKey(eventPrefix, nil)).Limit,
+	}, nil)
+
+	var keys [][]byte
+	var records []eventRecord
+	for iter.Next() {
+		var record eventRecord
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			iter.Release()
+			return 0, err
+		}
+		keys = append(keys, append([]byte{}, iter.Key()...))
+		records = append(records, record)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+
+	batch := new(leveldb.Batch)
+	var depth int
+	var lastBlock uint64
+	for i := len(records) - 1; i >= 0; i-- {
+		if record := records[i]; record.Existed {
+			batch.Put(record.Key, record.Previous)
+		} else {
+			batch.Delete(record.Key)
+		}
+		batch.Delete(keys[i])
+
+		if blockNum := eventBlockNum(keys[i]); blockNum != lastBlock {
+			lastBlock = blockNum
+			depth++
+		}
+	}
+
+	// the hash of the fork is not needed, it is only compared to detect a reorg
+	for _, source := range eventSources {
+		if processed, _ := plasma.processedBlock(source); processed > fork {
+			putProcessedBlock(batch, source, fork, common.Hash{})
+		}
+	}
+	if err := plasma.db.Write(batch, nil); err != nil {
+		return 0, err
+	}
+
+	for i := len(records) - 1; i >= 0; i-- {
+		// records written before events were recorded cannot be retracted
+		if event := records[i].Event; event.Type != 0 {
+			event.Removed = true
+			plasma.unsent = append(plasma.unsent, event)
+		}
+	}
+
+	return depth, nil
+}
+
+// drops the records of events too old to be reorganized
+func (plasma *Plasma) prune() error {
+	plasma.cacheLock.Lock()
+	defer plasma.cacheLock.Unlock()
+
+	// the newest block seen by any subscription bounds how deep a reorg can reach
+	var processed uint64
+	for _, source := range eventSources {
+		if blockNum, _ := plasma.processedBlock(source); blockNum > processed {
+			processed = blockNum
+		}
+	}
+	if processed <= maxReorgDepth {
+		return nil
+	}
+
+	iter := plasma.db.NewIterator(&dbutil.Range{
+		Start: eventKey(0, 0),
+		Limit: eventKey(processed-maxReorgDepth, 0),
+	}, nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	return plasma.db.Write(batch, nil)
+}
@@ -0,0 +1,111 @@
+package eth
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestEventRollback(t *testing.T) {
+	db, _ := leveldb.Open(storage.NewMemStorage(), nil)
+	plasma := &Plasma{db: db, logger: log.NewTMLogger(os.Stderr), cacheLock: &sync.Mutex{}}
+
+	deposit := cacheUpdate{source: depositSource, key: prefixKey(depositPrefix, []byte{1}), value: []byte("deposit")}
+	exit := cacheUpdate{source: depositExitSource, key: prefixKey(depositExitPrefix, []byte{1})}
+	challenge := cacheUpdate{source: challengedExitSource, key: prefixKey(depositExitPrefix, []byte{1}), delete: true, event: Event{Type: EventExitChallenged}}
+
+	hashA, hashB, hashC := common.HexToHash("0a"), common.HexToHash("0b"), common.HexToHash("0c")
+	for _, event := range []cachedEvent{
+		{types.Log{BlockNumber: 5, BlockHash: hashA}, deposit},
+		{types.Log{BlockNumber: 6, BlockHash: hashB}, exit},
+		{types.Log{BlockNumber: 7, BlockHash: hashC, Index: 2}, challenge},
+	} {
+		plasma.cacheLock.Lock()
+		conflict, err := plasma.apply(event.raw, event.update)
+		plasma.unlockCache()
+		if err != nil || conflict {
+			t.Fatalf("Could not apply event of block %d - %v", event.raw.BlockNumber, err)
+		}
+	}
+
+	if has, _ := db.Has(exit.key, nil); has {
+		t.Errorf("Challenged exit remains cached")
+	}
+	if blockNum, hash := plasma.processedBlock(challengedExitSource); blockNum != 7 || hash != hashC {
+		t.Errorf("Last processed block not recorded. Got: %d", blockNum)
+	}
+	if blockNum, _ := plasma.processedBlock(depositSource); blockNum != 5 {
+		t.Errorf("Last processed block of deposits advanced by other events. Got: %d", blockNum)
+	}
+
+	// backfilling resumes from the subscription furthest behind
+	if plasma.lastProcessedBlock() != 0 {
+		t.Errorf("Last processed block ahead of the transaction exit subscription. Got: %d", plasma.lastProcessedBlock())
+	}
+
+	// cached events are skipped, replaced ones conflict
+	if conflict, _ := plasma.apply(types.Log{BlockNumber: 6, BlockHash: hashB}, exit); conflict {
+		t.Errorf("Cached event reported as conflicting")
+	}
+	if conflict, _ := plasma.apply(types.Log{BlockNumber: 6, BlockHash: hashC}, exit); !conflict {
+		t.Errorf("Event of a replaced block not reported as conflicting")
+	}
+
+	// undo the challenge
+	events := make(chan Event, 1)
+	sub := plasma.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	plasma.cacheLock.Lock()
+	depth, err := plasma.rollback(6)
+	plasma.unlockCache()
+	if err != nil || depth != 1 {
+		t.Fatalf("Rollback to block 6 failed. Depth: %d - %v", depth, err)
+	}
+	if event := <-events; event.Type != EventExitChallenged || !event.Removed || event.EthBlockNum != 7 {
+		t.Errorf("Rolled back challenge not retracted. Got: %v", event)
+	}
+	if has, _ := db.Has(exit.key, nil); !has {
+		t.Errorf("Exit not restored after rolling back its challenge")
+	}
+	if blockNum, _ := plasma.processedBlock(challengedExitSource); blockNum != 6 {
+		t.Errorf("Last processed block not rolled back. Got: %d", blockNum)
+	}
+
+	// undo the exit and deposit
+	plasma.cacheLock.Lock()
+	depth, err = plasma.rollback(4)
+	plasma.unlockCache()
+	if err != nil || depth != 2 {
+		t.Fatalf("Rollback to block 4 failed. Depth: %d - %v", depth, err)
+	}
+	if has, _ := db.Has(exit.key, nil); has {
+		t.Errorf("Exit remains cached after rollback")
+	}
+	if has, _ := db.Has(deposit.key, nil); has {
+		t.Errorf("Deposit remains cached after rollback")
+	}
+
+	// records older than the reorg depth are pruned, the events remain
+	plasma.apply(types.Log{BlockNumber: 5, BlockHash: hashA}, deposit)
+	plasma.apply(types.Log{BlockNumber: 5 + maxReorgDepth + 1, BlockHash: hashB}, exit)
+	if err := plasma.prune(); err != nil {
+		t.Fatal("Could not prune -", err)
+	}
+	if has, _ := db.Has(eventKey(5, 0), nil); has {
+		t.Errorf("Record older than the reorg depth not pruned")
+	}
+	if has, _ := db.Has(eventKey(5+maxReorgDepth+1, 0), nil); !has {
+		t.Errorf("Recent record pruned")
+	}
+	if data, _ := db.Get(deposit.key, nil); !bytes.Equal(data, deposit.value) {
+		t.Errorf("Pruning removed a cached deposit")
+	}
+}
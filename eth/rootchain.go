@@ -0,0 +1,51 @@
+package eth
+
+import (
+	"math/big"
+
+	plasmaTypes "github.com/AdityaSripal/plasma-mvp-sidechain/types"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// RootChain is the view of the rootchain contract the sidechain validates against.
+// Implemented by `Plasma` for a deployed contract and by `FakeRootChain` in memory
+type RootChain interface {
+	// GetDeposit returns the deposit with the given nonce once it is final
+	GetDeposit(nonce *big.Int) (*plasmaTypes.Deposit, error)
+
+	// HasTXBeenExited indicates if [blknum, txindex, oindex, depositnonce] has been exited and the exit is final
+	HasTXBeenExited(position [4]*big.Int) bool
+
+	// HasBlockBeenSubmitted indicates if the block with the given header has been committed
+	HasBlockBeenSubmitted(blockNum *big.Int, header [32]byte) bool
+
+	// SubmitBlock commits consecutive block headers starting at `blockNum`
+	SubmitBlock(headers [][32]byte, numTxns []*big.Int, blockNum *big.Int) (*types.Transaction, error)
+
+	// SubscribeEvents sends deposits and exits to `ch` as they are seen. Events are sent
+	// synchronously, so the subscriber must keep up or buffer
+	SubscribeEvents(ch chan<- Event) event.Subscription
+}
+
+// EventType identifies a rootchain event
+type EventType uint8
+
+const (
+	EventDeposit EventType = iota + 1
+	EventExitStarted
+	EventExitChallenged
+)
+
+// Event is a deposit or exit emitted by the rootchain contract
+type Event struct {
+	Type EventType
+
+	// [blknum, txindex, oindex, depositnonce] of the deposit or exited output
+	Position [4]*big.Int
+
+	EthBlockNum uint64
+
+	// set when the event is undone by a reorg of the rootchain
+	Removed bool
+}
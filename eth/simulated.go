@@ -0,0 +1,266 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// seconds between simulated blocks, as in go-ethereum's simulated chain
+const simulatedBlockTime = 10
+
+// SimulatedBackend is an in-process ethereum chain. Like ganache, every transaction is
+// mined into its own block as soon as it is sent.
+//
+// The simulated backend does not expose the headers of its blocks, so a header is kept
+// for every committed block and its hash is reported in logs and receipts in place of
+// the hash of the simulated block. Blocks are only added through Commit, so both chains
+// have the same length
+type SimulatedBackend struct {
+	*backends.SimulatedBackend
+
+	gasLimit uint64
+
+	// headers of the committed blocks, starting at genesis
+	headers []*types.Header
+	// added to the timestamp of the pending block by AdjustTime
+	timeOffset int64
+
+	heads event.Feed
+	lock  *sync.Mutex
+}
+
+// NewSimulatedBackend creates a chain whose genesis block funds the accounts in `alloc`
+// and whose blocks hold up to `gasLimit` gas
+func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	genesis := &types.Header{
+		Number:     big.NewInt(0),
+		Difficulty: big.NewInt(1),
+		GasLimit:   gasLimit,
+		Time:       big.NewInt(0),
+	}
+
+	return &SimulatedBackend{
+		SimulatedBackend: backends.NewSimulatedBackend(alloc, gasLimit),
+		gasLimit:         gasLimit,
+		headers:          []*types.Header{genesis},
+		lock:             &sync.Mutex{},
+	}
+}
+
+// Commit mines the pending transactions into a new block and notifies head subscribers
+func (sim *SimulatedBackend) Commit() {
+	sim.lock.Lock()
+	parent := sim.headers[len(sim.headers)-1]
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Difficulty: big.NewInt(1),
+		GasLimit:   sim.gasLimit,
+		Time:       new(big.Int).Add(parent.Time, big.NewInt(simulatedBlockTime+sim.timeOffset)),
+	}
+	// logs of the block are delivered while it is committed and are reported with its hash
+	sim.headers = append(sim.headers, header)
+	sim.timeOffset = 0
+	sim.lock.Unlock()
+
+	sim.SimulatedBackend.Commit()
+	sim.heads.Send(header)
+}
+
+// Rollback discards the pending transactions and time adjustments
+func (sim *SimulatedBackend) Rollback() {
+	sim.lock.Lock()
+	sim.timeOffset = 0
+	sim.lock.Unlock()
+
+	sim.SimulatedBackend.Rollback()
+}
+
+// AdjustTime moves the timestamp of the pending block forward
+func (sim *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
+	if err := sim.SimulatedBackend.AdjustTime(adjustment); err != nil {
+		return err
+	}
+
+	sim.lock.Lock()
+	sim.timeOffset += int64(adjustment.Seconds())
+	sim.lock.Unlock()
+	return nil
+}
+
+// SendTransaction mines the transaction into a new block
+func (sim *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := sim.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+
+	sim.Commit()
+	return nil
+}
+
+// TransactionReceipt returns `ethereum.NotFound` for unmined transactions, as a node does
+func (sim *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := sim.SimulatedBackend.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	} else if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+
+	if receipt.BlockNumber != nil {
+		receipt.BlockHash = sim.blockHash(receipt.BlockNumber.Uint64())
+	}
+	for _, log := range receipt.Logs {
+		log.BlockHash = sim.blockHash(log.BlockNumber)
+	}
+
+	return receipt, nil
+}
+
+// FilterLogs returns the logs matching `query`
+func (sim *SimulatedBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	logs, err := sim.SimulatedBackend.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range logs {
+		logs[i].BlockHash = sim.blockHash(logs[i].BlockNumber)
+	}
+
+	return logs, nil
+}
+
+// SubscribeFilterLogs funnels the logs matching `query` of every committed block to `ch`
+func (sim *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	logs := make(chan types.Log)
+	sub, err := sim.SimulatedBackend.SubscribeFilterLogs(ctx, query, logs)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				log.BlockHash = sim.blockHash(log.BlockNumber)
+				select {
+				case ch <- log:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// HeaderByNumber returns the header of the committed block at `number`. The latest header if nil
+func (sim *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	sim.lock.Lock()
+	defer sim.lock.Unlock()
+
+	if number == nil {
+		return sim.headers[len(sim.headers)-1], nil
+	}
+	if !number.IsUint64() || number.Uint64() >= uint64(len(sim.headers)) {
+		return nil, ethereum.NotFound
+	}
+
+	return sim.headers[number.Uint64()], nil
+}
+
+// SubscribeNewHead funnels the header of every committed block to `ch`
+func (sim *SimulatedBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	// buffered so that committing a block does not wait on the subscriber
+	heads := make(chan *types.Header, 16)
+	sub := sim.heads.Subscribe(heads)
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-heads:
+				select {
+				case ch <- head:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// hash of the header kept for committed block `number`
+func (sim *SimulatedBackend) blockHash(number uint64) common.Hash {
+	sim.lock.Lock()
+	defer sim.lock.Unlock()
+
+	if number >= uint64(len(sim.headers)) {
+		return common.Hash{}
+	}
+	return sim.headers[number].Hash()
+}
+
+// PlasmaMVPBytecode reads the creation bytecode of the rootchain contract from the
+// artifact written by `truffle compile`, build/contracts/PlasmaMVP.json
+func PlasmaMVPBytecode(artifactPath string) ([]byte, error) {
+	data, err := ioutil.ReadFile(artifactPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifact struct {
+		Bytecode string `json:"bytecode"`
+	}
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, fmt.Errorf("Error decoding contract artifact - %s", err)
+	}
+
+	bytecode, err := hexutil.Decode(artifact.Bytecode)
+	if err != nil || len(bytecode) == 0 {
+		return nil, fmt.Errorf("Contract artifact does not contain creation bytecode")
+	}
+
+	return bytecode, nil
+}
+
+// DeployPlasmaMVP deploys the rootchain contract for the sidechain `chainID` with `auth` as the operator
+func DeployPlasmaMVP(auth *bind.TransactOpts, backend bind.ContractBackend, bytecode []byte, chainID string) (common.Address, error) {
+	parsed, err := abi.JSON(strings.NewReader(contracts.PlasmaMVPABI))
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	addr, _, _, err := bind.DeployContract(auth, parsed, bytecode, backend, chainID)
+	return addr, err
+}
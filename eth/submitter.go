@@ -0,0 +1,593 @@
+package eth
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// interval at which in-flight submissions are polled for receipts
+const submitterPollInterval = time.Second
+
+// maximum number of missing blocks reported at once
+const maxMissingBlocks = 256
+
+// SubmissionStatus describes how far a sidechain block has progressed through rootchain submission
+type SubmissionStatus uint8
+
+const (
+	// block is recorded and waiting to be sent to the rootchain
+	StatusPending SubmissionStatus = iota
+	// the rootchain transaction has been sent but is not yet mined
+	StatusSubmitted
+	// the rootchain transaction has been mined
+	StatusMined
+	// the rootchain transaction has `finalityBound` confirmations
+	StatusFinalized
+)
+
+func (status SubmissionStatus) String() string {
+	switch status {
+	case StatusPending:
+		return "pending"
+	case StatusSubmitted:
+		return "submitted"
+	case StatusMined:
+		return "mined"
+	case StatusFinalized:
+		return "finalized"
+	default:
+		return "unknown"
+	}
+}
+
+// Submission is the persisted record of a sidechain block's submission to the rootchain.
+// Blocks submitted together in a batch share the same TxHash
+type Submission struct {
+	BlockNum    uint64
+	Root        [32]byte
+	NumTxns     uint64
+	QueuedAt    time.Time
+	Status      SubmissionStatus
+	TxHash      common.Hash
+	EthBlockNum uint64
+}
+
+// BatchPolicy determines how consecutive blocks are coalesced into a single rootchain transaction
+type BatchPolicy struct {
+	// Maximum number of headers in a single submission
+	MaxBatchSize int
+
+	// Maximum time a block waits for its batch to fill up before it is submitted
+	MaxWait time.Duration
+
+	// Empty blocks never trigger a submission by themselves. They are
+	// submitted alongside the next block that contains transactions
+	SkipEmptyBlocks bool
+}
+
+// DefaultBatchPolicy submits every block by itself as soon as it is committed
+func DefaultBatchPolicy() BatchPolicy {
+	return BatchPolicy{
+		MaxBatchSize:    1,
+		MaxWait:         0,
+		SkipEmptyBlocks: false,
+	}
+}
+
+// Submitter sends committed sidechain blocks to the rootchain in order and tracks
+// each submission until it is final. Progress is persisted per block so a restarted
+// node resumes from its records and the contract rather than an in-memory counter
+type Submitter struct {
+	plasma *Plasma
+	db     *leveldb.DB
+	policy BatchPolicy
+	logger log.Logger
+
+	notify chan struct{}
+	quit   chan struct{}
+	done   chan struct{}
+
+	// blocks that must be submitted next but have no record
+	missing []uint64
+
+	// every block up to and including this one is final and has no record
+	finalized uint64
+
+	lock *sync.Mutex
+}
+
+// NewSubmitter opens the submission records stored at `path` and starts submitting
+// according to the batch policy. An empty path keeps the records in memory
+func NewSubmitter(plasma *Plasma, path string, policy BatchPolicy, logger log.Logger) (*Submitter, error) {
+	if policy.MaxBatchSize < 1 {
+		return nil, fmt.Errorf("batches must contain at least one block")
+	}
+
+	var db *leveldb.DB
+	var err error
+	if path == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(path, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not open submission records - %s", err)
+	}
+
+	var finalized uint64
+	value, err := db.Get([]byte(finalizedSubmissionKey), nil)
+	switch err {
+	case nil:
+		finalized = binary.BigEndian.Uint64(value)
+	case leveldb.ErrNotFound:
+	default:
+		db.Close()
+		return nil, fmt.Errorf("Could not read submission records - %s", err)
+	}
+
+	submitter := &Submitter{
+		plasma: plasma,
+		db:     db,
+		policy: policy,
+		logger: logger,
+
+		// buffered so that enqueuing never blocks the caller
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+
+		finalized: finalized,
+
+		lock: &sync.Mutex{},
+	}
+
+	go submitter.run()
+
+	return submitter, nil
+}
+
+// Enqueue records a committed sidechain block for submission. Blocks that are
+// already recorded or finalized are left untouched so replayed blocks are not resubmitted
+func (submitter *Submitter) Enqueue(blockNum uint64, root [32]byte, numTxns uint64) error {
+	// the rootchain numbers blocks starting at 1
+	if blockNum == 0 {
+		return nil
+	}
+
+	submitter.lock.Lock()
+	defer submitter.lock.Unlock()
+
+	if blockNum <= submitter.finalized {
+		return nil
+	}
+
+	key := submissionKey(blockNum)
+	exists, err := submitter.db.Has(key, nil)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	err = submitter.put(Submission{
+		BlockNum: blockNum,
+		Root:     root,
+		NumTxns:  numTxns,
+		QueuedAt: time.Now(),
+		Status:   StatusPending,
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case submitter.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Submission returns the submission record of a sidechain block. Records of finalized
+// blocks are compacted away, so only the block number and status are returned for them
+func (submitter *Submitter) Submission(blockNum uint64) (*Submission, error) {
+	submitter.lock.Lock()
+	defer submitter.lock.Unlock()
+
+	if blockNum > 0 && blockNum <= submitter.finalized {
+		return &Submission{BlockNum: blockNum, Status: StatusFinalized}, nil
+	}
+
+	return submitter.get(blockNum)
+}
+
+// MissingBlocks returns the blocks that must be submitted next but were never recorded, such as
+// blocks committed while the records were unavailable. Submission stalls until they are enqueued
+func (submitter *Submitter) MissingBlocks() []uint64 {
+	submitter.lock.Lock()
+	defer submitter.lock.Unlock()
+
+	return append([]uint64(nil), submitter.missing...)
+}
+
+// Stop halts submission and closes the records. An in-flight rootchain call is
+// allowed to finish so that its result is recorded
+func (submitter *Submitter) Stop() error {
+	close(submitter.quit)
+	<-submitter.done
+
+	submitter.lock.Lock()
+	defer submitter.lock.Unlock()
+	return submitter.db.Close()
+}
+
+func (submitter *Submitter) run() {
+	defer close(submitter.done)
+
+	ticker := time.NewTicker(submitterPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-submitter.quit:
+			submitter.logger.Info("stopped block submission")
+			return
+		case <-submitter.notify:
+		case <-ticker.C:
+		}
+
+		if err := submitter.process(); err != nil {
+			submitter.logger.Error(fmt.Sprintf("Error processing block submissions - %s", err))
+		}
+	}
+}
+
+// process advances every unfinalized record by at most one status and
+// submits the next batch of pending blocks once the batch policy allows it.
+// The records are only locked while they are read and written so that
+// enqueuing a block never waits on the rootchain
+func (submitter *Submitter) process() error {
+	ethBlockNum := submitter.plasma.currentEthBlockNum()
+	if ethBlockNum.Sign() < 0 {
+		return fmt.Errorf("not subscribed to ethereum block headers")
+	}
+
+	submissions, err := submitter.unfinalized()
+	if err != nil {
+		return err
+	}
+
+	// only mined state is relevant for ordering. Pending state would include our own in-flight submission
+	lastCommittedBlock, err := submitter.plasma.session.Contract.LastCommittedBlock(&bind.CallOpts{})
+	if err != nil {
+		return err
+	}
+
+	// blocks of a batch share a receipt
+	receipts := make(map[common.Hash]*types.Receipt)
+
+	inFlight := false
+	var pending, updated []Submission
+
+	// the contract only accepts the block following its last committed block
+	nextBlock := lastCommittedBlock.Uint64() + 1
+	recorded := false
+
+	for _, submission := range submissions {
+		if submission.BlockNum == nextBlock {
+			recorded = true
+		}
+
+		committed := new(big.Int).SetUint64(submission.BlockNum).Cmp(lastCommittedBlock) <= 0
+
+		switch submission.Status {
+		case StatusPending:
+			if !committed {
+				pending = append(pending, submission)
+				continue
+			}
+
+			// submitted before a restart without a record of the transaction. The
+			// block it was mined in is unknown so confirmations are counted from now
+			matches, err := submitter.committedRoot(submission)
+			if err != nil {
+				return err
+			}
+			if !matches {
+				submitter.logger.Error(fmt.Sprintf("Rootchain committed a different root for block %d", submission.BlockNum))
+				continue
+			}
+			submission.Status = StatusMined
+			submission.EthBlockNum = ethBlockNum.Uint64()
+
+		case StatusSubmitted:
+			receipt, ok := receipts[submission.TxHash]
+			if !ok {
+				// resolves to the mined version if the submission was replaced
+				receipt, err = submitter.plasma.txManager.TransactionReceipt(submission.TxHash)
+				if err != nil && err != ethereum.NotFound {
+					return err
+				}
+				receipts[submission.TxHash] = receipt
+			}
+
+			if receipt == nil && !committed {
+				inFlight = true
+				continue
+			}
+
+			switch {
+			case receipt != nil && receipt.Status == types.ReceiptStatusFailed:
+				submitter.logger.Error(fmt.Sprintf("Block submission reverted. Block: %d, Tx: %x", submission.BlockNum, submission.TxHash))
+				submission.Status = StatusPending
+				submission.TxHash = common.Hash{}
+			case receipt != nil:
+				submission.Status = StatusMined
+				submission.EthBlockNum = receipt.BlockNumber.Uint64()
+			default:
+				// committed by a transaction that is not ours
+				matches, err := submitter.committedRoot(submission)
+				if err != nil {
+					return err
+				}
+				if !matches {
+					submitter.logger.Error(fmt.Sprintf("Rootchain committed a different root for block %d", submission.BlockNum))
+					continue
+				}
+				submission.Status = StatusMined
+				submission.EthBlockNum = ethBlockNum.Uint64()
+			}
+
+		case StatusMined:
+			// the head falls behind the mined block when the chain reorgs
+			if ethBlockNum.Uint64() >= submission.EthBlockNum &&
+				ethBlockNum.Uint64()-submission.EthBlockNum < submitter.plasma.finalityBound {
+				continue
+			}
+
+			matches := false
+			if committed {
+				matches, err = submitter.committedRoot(submission)
+				if err != nil {
+					return err
+				}
+			}
+
+			switch {
+			case matches && ethBlockNum.Uint64() >= submission.EthBlockNum:
+				submission.Status = StatusFinalized
+			case matches:
+				// remined at a lower height. Confirmations are counted from the new head
+				submission.EthBlockNum = ethBlockNum.Uint64()
+			case submission.TxHash != (common.Hash{}):
+				// reorged out. The receipt is looked up again
+				submitter.logger.Info(fmt.Sprintf("Block submission reorged out. Block: %d", submission.BlockNum))
+				submission.Status = StatusSubmitted
+			default:
+				submitter.logger.Info(fmt.Sprintf("Block submission reorged out. Block: %d", submission.BlockNum))
+				submission.Status = StatusPending
+			}
+
+		default:
+			continue
+		}
+
+		updated = append(updated, submission)
+	}
+
+	// later blocks cannot be submitted until the next block is recorded
+	var missing []uint64
+	if !recorded && len(pending) > 0 && pending[0].BlockNum > nextBlock {
+		for blockNum := nextBlock; blockNum < pending[0].BlockNum && len(missing) < maxMissingBlocks; blockNum++ {
+			missing = append(missing, blockNum)
+		}
+		submitter.logger.Error(fmt.Sprintf("No submission record for blocks %d to %d", nextBlock, pending[0].BlockNum-1))
+	}
+
+	if err := submitter.record(updated, missing); err != nil {
+		return err
+	}
+	if len(missing) > 0 || inFlight {
+		return nil
+	}
+
+	batch := submitter.nextBatch(pending, nextBlock)
+	if len(batch) == 0 {
+		return nil
+	}
+
+	headers := make([][32]byte, len(batch))
+	numTxns := make([]*big.Int, len(batch))
+	for i, submission := range batch {
+		headers[i] = submission.Root
+		numTxns[i] = new(big.Int).SetUint64(submission.NumTxns)
+	}
+
+	tx, err := submitter.plasma.SubmitBlock(headers, numTxns, new(big.Int).SetUint64(batch[0].BlockNum))
+	if err != nil {
+		return err
+	}
+
+	submitter.logger.Info(fmt.Sprintf("Submitted blocks %d to %d to the rootchain. Tx: %x",
+		batch[0].BlockNum, batch[len(batch)-1].BlockNum, tx.Hash()))
+
+	for i := range batch {
+		batch[i].Status = StatusSubmitted
+		batch[i].TxHash = tx.Hash()
+	}
+
+	return submitter.record(batch, nil)
+}
+
+// unfinalized returns the records that have not been compacted in block order
+func (submitter *Submitter) unfinalized() ([]Submission, error) {
+	submitter.lock.Lock()
+	defer submitter.lock.Unlock()
+
+	var submissions []Submission
+	iter := submitter.db.NewIterator(util.BytesPrefix([]byte(submissionPrefix+prefixSeperator)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var submission Submission
+		if err := json.Unmarshal(iter.Value(), &submission); err != nil {
+			submitter.logger.Error("corrupted submission record found within db")
+			continue
+		}
+		submissions = append(submissions, submission)
+	}
+
+	return submissions, iter.Error()
+}
+
+// record writes the updated records and compacts the finalized ones. The records are
+// only changed by `process` and created by `Enqueue`, so none of them went stale while unlocked
+func (submitter *Submitter) record(submissions []Submission, missing []uint64) error {
+	submitter.lock.Lock()
+	defer submitter.lock.Unlock()
+
+	submitter.missing = missing
+
+	batch := new(leveldb.Batch)
+	for _, submission := range submissions {
+		data, err := json.Marshal(submission)
+		if err != nil {
+			return err
+		}
+		batch.Put(submissionKey(submission.BlockNum), data)
+	}
+	if err := submitter.db.Write(batch, nil); err != nil {
+		return err
+	}
+
+	return submitter.compact()
+}
+
+// compact drops the leading run of finalized records and only remembers the
+// highest block among them. Records finalized out of order are kept until
+// every record before them is final
+func (submitter *Submitter) compact() error {
+	finalized := submitter.finalized
+	batch := new(leveldb.Batch)
+
+	iter := submitter.db.NewIterator(util.BytesPrefix([]byte(submissionPrefix+prefixSeperator)), nil)
+	for iter.Next() {
+		var submission Submission
+		if err := json.Unmarshal(iter.Value(), &submission); err != nil || submission.Status != StatusFinalized {
+			break
+		}
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		finalized = submission.BlockNum
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	if finalized == submitter.finalized {
+		return nil
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, finalized)
+	batch.Put([]byte(finalizedSubmissionKey), value)
+	if err := submitter.db.Write(batch, nil); err != nil {
+		return err
+	}
+
+	submitter.finalized = finalized
+	return nil
+}
+
+// nextBatch returns the consecutive pending blocks starting at `blockNum` that should be
+// submitted now. Empty if the batch policy says to keep waiting
+func (submitter *Submitter) nextBatch(pending []Submission, blockNum uint64) []Submission {
+	// the contract requires blocks to be submitted in consecutive order
+	var queue []Submission
+	for _, submission := range pending {
+		if submission.BlockNum != blockNum+uint64(len(queue)) {
+			break
+		}
+		queue = append(queue, submission)
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+
+	// the wait time is counted from the first block that warrants a submission
+	first := -1
+	for i, submission := range queue {
+		if !submitter.policy.SkipEmptyBlocks || submission.NumTxns > 0 {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+
+	batch := queue
+	if len(batch) > submitter.policy.MaxBatchSize {
+		batch = batch[:submitter.policy.MaxBatchSize]
+	}
+
+	full := len(batch) == submitter.policy.MaxBatchSize
+	if !full && time.Since(queue[first].QueuedAt) < submitter.policy.MaxWait {
+		return nil
+	}
+
+	return batch
+}
+
+// whether the mined state of the rootchain holds the root of the submission
+func (submitter *Submitter) committedRoot(submission Submission) (bool, error) {
+	block, err := submitter.plasma.session.Contract.ChildChain(&bind.CallOpts{}, new(big.Int).SetUint64(submission.BlockNum))
+	if err != nil {
+		return false, err
+	}
+
+	return block.Root == submission.Root, nil
+}
+
+func (submitter *Submitter) get(blockNum uint64) (*Submission, error) {
+	data, err := submitter.db.Get(submissionKey(blockNum), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var submission Submission
+	if err := json.Unmarshal(data, &submission); err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+func (submitter *Submitter) put(submission Submission) error {
+	data, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+
+	return submitter.db.Put(submissionKey(submission.BlockNum), data, nil)
+}
+
+// big endian encoding keeps records iterated in block order
+func submissionKey(blockNum uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, blockNum)
+	return prefixKey(submissionPrefix, key)
+}
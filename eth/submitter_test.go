@@ -0,0 +1,177 @@
+package eth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestBlockSubmission(t *testing.T) {
+	plasma, _, _ := newSimulatedPlasma(t)
+	syncPlasma(t, plasma)
+
+	submitter, err := NewSubmitter(plasma, "", DefaultBatchPolicy(), log.NewTMLogger(os.Stderr))
+	if err != nil {
+		t.Fatal("Could not create submitter -", err)
+	}
+	defer submitter.Stop()
+
+	lastCommittedBlock, err := plasma.session.LastCommittedBlock()
+	if err != nil {
+		t.Fatal("Failed query for the last committed block -", err)
+	}
+	blockNum := lastCommittedBlock.Uint64() + 1
+
+	var root [32]byte
+	copy(root[:], crypto.Keccak256([]byte("submission")))
+	if err := submitter.Enqueue(blockNum, root, 1); err != nil {
+		t.Fatal("Could not enqueue block -", err)
+	}
+
+	// finality bound is 0 so the submission finalizes once mined
+	var submission *Submission
+	finalized := eventually(5*time.Second, func() bool {
+		submission, err = submitter.Submission(blockNum)
+		return err == nil && submission.Status == StatusFinalized
+	})
+	if !finalized {
+		t.Fatalf("Block %d not finalized. Status: %v", blockNum, submission)
+	}
+
+	result, err := plasma.session.ChildChain(new(big.Int).SetUint64(blockNum))
+	if err != nil {
+		t.Fatal("Failed query for the child chain - ", err)
+	}
+	if !bytes.Equal(result.Root[:], root[:]) {
+		t.Errorf("Mismatch in block headers. Got: %x. Expected: %x", result.Root, root)
+	}
+}
+
+func TestSubmissionPersistence(t *testing.T) {
+	plasma, _, _ := newSimulatedPlasma(t)
+	logger := log.NewTMLogger(os.Stderr)
+
+	dir, err := ioutil.TempDir("", "submissions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "submissions.db")
+
+	// a block far ahead of the contract is recorded but never submitted
+	submitter, err := NewSubmitter(plasma, path, DefaultBatchPolicy(), logger)
+	if err != nil {
+		t.Fatal("Could not create submitter -", err)
+	}
+	root := [32]byte{1}
+	submitter.Enqueue(1<<32, root, 2)
+	submitter.Stop()
+
+	submitter, err = NewSubmitter(plasma, path, DefaultBatchPolicy(), logger)
+	if err != nil {
+		t.Fatal("Could not reopen submitter -", err)
+	}
+	defer submitter.Stop()
+
+	// a replayed block does not overwrite the record
+	submitter.Enqueue(1<<32, [32]byte{2}, 3)
+
+	submission, err := submitter.Submission(1 << 32)
+	if err != nil {
+		t.Fatal("Submission not persisted -", err)
+	}
+	if submission.Root != root || submission.NumTxns != 2 || submission.Status != StatusPending {
+		t.Errorf("Mismatch in persisted submission. Got: %v", submission)
+	}
+}
+
+func TestNextBatch(t *testing.T) {
+	now := time.Now()
+	queued := func(blockNums []uint64, numTxns []uint64, queuedAt time.Time) []Submission {
+		var submissions []Submission
+		for i, blockNum := range blockNums {
+			submissions = append(submissions, Submission{BlockNum: blockNum, NumTxns: numTxns[i], QueuedAt: queuedAt})
+		}
+		return submissions
+	}
+
+	cases := []struct {
+		policy   BatchPolicy
+		pending  []Submission
+		expected []uint64
+	}{
+		// Case 0: default policy submits one block immediately
+		{DefaultBatchPolicy(), queued([]uint64{5, 6}, []uint64{1, 1}, now), []uint64{5}},
+
+		// Case 1: batch is not full and has not waited long enough
+		{BatchPolicy{4, time.Minute, false}, queued([]uint64{5, 6}, []uint64{1, 1}, now), nil},
+
+		// Case 2: batch has waited long enough
+		{BatchPolicy{4, time.Minute, false}, queued([]uint64{5, 6}, []uint64{1, 1}, now.Add(-2*time.Minute)), []uint64{5, 6}},
+
+		// Case 3: full batches are submitted right away
+		{BatchPolicy{2, time.Minute, false}, queued([]uint64{5, 6, 7}, []uint64{1, 1, 1}, now), []uint64{5, 6}},
+
+		// Case 4: batches must start at the next block to commit
+		{BatchPolicy{2, 0, false}, queued([]uint64{6, 7}, []uint64{1, 1}, now), nil},
+
+		// Case 5: batches stop at a gap in block numbers
+		{BatchPolicy{4, 0, false}, queued([]uint64{5, 7}, []uint64{1, 1}, now), []uint64{5}},
+
+		// Case 6: empty blocks are held back
+		{BatchPolicy{4, 0, true}, queued([]uint64{5, 6}, []uint64{0, 0}, now), nil},
+
+		// Case 7: empty blocks are submitted along with a block containing transactions
+		{BatchPolicy{4, 0, true}, queued([]uint64{5, 6, 7}, []uint64{0, 0, 3}, now), []uint64{5, 6, 7}},
+	}
+
+	for index, tc := range cases {
+		submitter := &Submitter{policy: tc.policy}
+		batch := submitter.nextBatch(tc.pending, 5)
+
+		var blockNums []uint64
+		for _, submission := range batch {
+			blockNums = append(blockNums, submission.BlockNum)
+		}
+		if !reflect.DeepEqual(blockNums, tc.expected) {
+			t.Errorf("Mismatch in batch. Case: %d. Got: %v, Expected: %v", index, blockNums, tc.expected)
+		}
+	}
+}
+
+func TestMissingBlocks(t *testing.T) {
+	plasma, _, _ := newSimulatedPlasma(t)
+	syncPlasma(t, plasma)
+
+	submitter, err := NewSubmitter(plasma, "", DefaultBatchPolicy(), log.NewTMLogger(os.Stderr))
+	if err != nil {
+		t.Fatal("Could not create submitter -", err)
+	}
+	defer submitter.Stop()
+
+	lastCommittedBlock, err := plasma.session.LastCommittedBlock()
+	if err != nil {
+		t.Fatal("Failed query for the last committed block -", err)
+	}
+
+	// the block following the last committed block is never recorded
+	next := lastCommittedBlock.Uint64() + 1
+	submitter.Enqueue(next+2, [32]byte{1}, 1)
+
+	var missing []uint64
+	eventually(5*time.Second, func() bool {
+		missing = submitter.MissingBlocks()
+		return len(missing) > 0
+	})
+	if !reflect.DeepEqual(missing, []uint64{next, next + 1}) {
+		t.Errorf("Mismatch in missing blocks. Got: %v, Expected: %v", missing, []uint64{next, next + 1})
+	}
+}
@@ -0,0 +1,513 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	contracts "github.com/AdityaSripal/plasma-mvp-sidechain/contracts/wrappers"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	// interval at which unmined transactions are checked
+	txMonitorInterval = 5 * time.Second
+
+	// percentage added on top of the estimated gas
+	gasLimitMargin = 20
+
+	// ethereum nodes only accept replacements with a 10% higher gas price
+	minGasPriceBump = 10
+)
+
+// GasPriceStrategy determines the gas price of rootchain transactions
+type GasPriceStrategy string
+
+const (
+	// always use the configured gas price
+	GasPriceFixed GasPriceStrategy = "fixed"
+	// use the price suggested by the ethereum node
+	GasPriceOracle GasPriceStrategy = "oracle"
+	// use the price suggested by the ethereum node, bounded by the maximum gas price
+	GasPriceCapped GasPriceStrategy = "capped"
+)
+
+// TxConfig configures how rootchain transactions are priced and replaced
+type TxConfig struct {
+	Strategy GasPriceStrategy
+
+	// Gas price used by the fixed strategy
+	GasPrice *big.Int
+
+	// Upper bound for the capped strategy and for replacements. Nil if unbounded
+	MaxGasPrice *big.Int
+
+	// Time a transaction may remain unmined before it is replaced
+	Deadline time.Duration
+
+	// Percentage the gas price is increased by when replacing a transaction
+	BumpPercent int64
+}
+
+// DefaultTxConfig uses the ethereum node's suggested gas price and replaces transactions after 5 minutes
+func DefaultTxConfig() TxConfig {
+	return TxConfig{
+		Strategy:    GasPriceOracle,
+		Deadline:    5 * time.Minute,
+		BumpPercent: minGasPriceBump,
+	}
+}
+
+// ValidateBasic checks the configuration for consistency
+func (config TxConfig) ValidateBasic() error {
+	switch config.Strategy {
+	case GasPriceFixed:
+		if config.GasPrice == nil || config.GasPrice.Sign() <= 0 {
+			return fmt.Errorf("fixed gas price strategy requires a positive gas price")
+		}
+	case GasPriceOracle:
+	case GasPriceCapped:
+		if config.MaxGasPrice == nil || config.MaxGasPrice.Sign() <= 0 {
+			return fmt.Errorf("capped gas price strategy requires a positive maximum gas price")
+		}
+	default:
+		return fmt.Errorf("unknown gas price strategy: %s", config.Strategy)
+	}
+
+	if config.BumpPercent < minGasPriceBump {
+		return fmt.Errorf("gas price bump must be at least %d percent", minGasPriceBump)
+	}
+
+	return nil
+}
+
+// a transaction along with every version of it that has been broadcast
+type managedTx struct {
+	method string
+	params []interface{}
+	value  *big.Int
+
+	nonce    uint64
+	gasLimit uint64
+	gasPrice *big.Int
+
+	hashes []common.Hash
+	sentAt time.Time
+
+	// ethereum block the transaction was mined in
+	minedAt uint64
+}
+
+// TxManager sends transactions to the rootchain contract on behalf of the operator.
+// It estimates gas, prices transactions according to its configuration, tracks the
+// operator nonce locally and replaces transactions that remain unmined past the deadline
+type TxManager struct {
+	client   *Client
+	contract *bind.BoundContract
+	abi      abi.ABI
+	address  common.Address
+	from     common.Address
+	signer   bind.SignerFn
+	config   TxConfig
+	logger   log.Logger
+
+	// next nonce to use. Nil if it must be fetched from the ethereum node
+	nonce *uint64
+
+	// unmined transactions by nonce
+	pending map[uint64]*managedTx
+
+	// every broadcast version of a managed transaction. Versions are
+	// forgotten once the transaction has `confirmations` confirmations
+	versions map[common.Hash]*managedTx
+
+	// mined transactions whose versions are still tracked
+	mined []*managedTx
+
+	confirmations uint64
+
+	quit chan struct{}
+
+	// guards the fields above. Never held across calls to the ethereum node
+	lock *sync.Mutex
+	// serializes broadcasts so that nonces are assigned in order
+	sendLock *sync.Mutex
+}
+
+// NewTxManager creates a transaction manager for the rootchain contract that signs with the operator's private key.
+// Receipts of replaced transactions are resolved until the mined version has `confirmations` confirmations
+func NewTxManager(contractAddr common.Address, privateKey *ecdsa.PrivateKey, client *Client, confirmations uint64, config TxConfig, logger log.Logger) (*TxManager, error) {
+	if err := config.ValidateBasic(); err != nil {
+		return nil, err
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(contracts.PlasmaMVPABI))
+	if err != nil {
+		return nil, err
+	}
+
+	auth := bind.NewKeyedTransactor(privateKey)
+	manager := &TxManager{
+		client:   client,
+		contract: bind.NewBoundContract(contractAddr, parsed, client.ec, client.ec, client.ec),
+		abi:      parsed,
+		address:  contractAddr,
+		from:     auth.From,
+		signer:   auth.Signer,
+		config:   config,
+		logger:   logger,
+
+		pending:  make(map[uint64]*managedTx),
+		versions: make(map[common.Hash]*managedTx),
+
+		confirmations: confirmations,
+
+		quit: make(chan struct{}),
+
+		lock:     &sync.Mutex{},
+		sendLock: &sync.Mutex{},
+	}
+
+	go manager.monitor()
+
+	return manager, nil
+}
+
+// Transact invokes `method` on the rootchain contract. The transaction is not sent if gas estimation
+// fails, as the call would revert
+func (manager *TxManager) Transact(value *big.Int, method string, params ...interface{}) (*types.Transaction, error) {
+	data, err := manager.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := manager.client.ec.EstimateGas(context.Background(), ethereum.CallMsg{
+		From:  manager.from,
+		To:    &manager.address,
+		Value: value,
+		Data:  data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Gas estimation for %s failed - %s", method, err)
+	}
+	gasLimit := gas + gas*gasLimitMargin/100
+
+	manager.sendLock.Lock()
+	defer manager.sendLock.Unlock()
+
+	gasPrice, err := manager.gasPrice()
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *types.Transaction
+	var mtx *managedTx
+	// a nonce used outside of the manager is only noticed once a transaction is rejected for it
+	for attempt := 0; attempt < 2; attempt++ {
+		nonce, err := manager.nextNonce()
+		if err != nil {
+			return nil, err
+		}
+
+		mtx = &managedTx{
+			method:   method,
+			params:   params,
+			value:    value,
+			nonce:    nonce,
+			gasLimit: gasLimit,
+		}
+
+		tx, err = manager.send(mtx, gasPrice)
+		if err == nil {
+			break
+		}
+
+		// the local nonce may be out of sync with the ethereum node
+		manager.lock.Lock()
+		manager.nonce = nil
+		manager.lock.Unlock()
+
+		if !isNonceTooLow(err) {
+			return nil, err
+		}
+		manager.logger.Info(fmt.Sprintf("Operator nonce %d already used. Resyncing with the ethereum node", nonce))
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("Could not send %s after resyncing the operator nonce", method)
+	}
+
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	nonce := mtx.nonce + 1
+	manager.nonce = &nonce
+	manager.pending[mtx.nonce] = mtx
+
+	return tx, nil
+}
+
+// SetConfig changes how transactions are priced and replaced
+func (manager *TxManager) SetConfig(config TxConfig) error {
+	if err := config.ValidateBasic(); err != nil {
+		return err
+	}
+
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.config = config
+
+	return nil
+}
+
+// Stop halts the replacement of stuck transactions
+func (manager *TxManager) Stop() {
+	close(manager.quit)
+}
+
+// TransactionReceipt returns the receipt of a transaction sent through the manager. If the transaction
+// was replaced, the receipt of whichever version was mined is returned until it is confirmed. Returns
+// ethereum.NotFound if no version has been mined
+func (manager *TxManager) TransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	manager.lock.Lock()
+	hashes := []common.Hash{hash}
+	if mtx, ok := manager.versions[hash]; ok {
+		hashes = append([]common.Hash{}, mtx.hashes...)
+	}
+	manager.lock.Unlock()
+
+	return manager.receipt(hashes)
+}
+
+// signs and broadcasts a version of a managed transaction at `gasPrice`
+func (manager *TxManager) send(mtx *managedTx, gasPrice *big.Int) (*types.Transaction, error) {
+	opts := &bind.TransactOpts{
+		From:     manager.from,
+		Signer:   manager.signer,
+		Nonce:    new(big.Int).SetUint64(mtx.nonce),
+		Value:    mtx.value,
+		GasPrice: gasPrice,
+		GasLimit: mtx.gasLimit,
+	}
+
+	tx, err := manager.contract.Transact(opts, mtx.method, mtx.params...)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	mtx.gasPrice = gasPrice
+	mtx.hashes = append(mtx.hashes, tx.Hash())
+	mtx.sentAt = time.Now()
+	manager.versions[tx.Hash()] = mtx
+
+	return tx, nil
+}
+
+func (manager *TxManager) nextNonce() (uint64, error) {
+	manager.lock.Lock()
+	local := manager.nonce
+	manager.lock.Unlock()
+	if local != nil {
+		return *local, nil
+	}
+
+	nonce, err := manager.client.ec.PendingNonceAt(context.Background(), manager.from)
+	if err != nil {
+		return 0, fmt.Errorf("Could not retrieve the operator nonce - %s", err)
+	}
+
+	return nonce, nil
+}
+
+func (manager *TxManager) gasPrice() (*big.Int, error) {
+	manager.lock.Lock()
+	config := manager.config
+	manager.lock.Unlock()
+
+	if config.Strategy == GasPriceFixed {
+		return new(big.Int).Set(config.GasPrice), nil
+	}
+
+	price, err := manager.client.ec.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve the suggested gas price - %s", err)
+	}
+
+	if config.Strategy == GasPriceCapped && price.Cmp(config.MaxGasPrice) > 0 {
+		price = new(big.Int).Set(config.MaxGasPrice)
+	}
+
+	return price, nil
+}
+
+// the gas price of a replacement. Never lower than the current strategy price
+func (manager *TxManager) bumpedGasPrice(gasPrice *big.Int) *big.Int {
+	manager.lock.Lock()
+	config := manager.config
+	manager.lock.Unlock()
+
+	bump := new(big.Int).Mul(gasPrice, big.NewInt(config.BumpPercent))
+	bump.Div(bump, big.NewInt(100))
+	price := new(big.Int).Add(gasPrice, bump)
+
+	if current, err := manager.gasPrice(); err == nil && current.Cmp(price) > 0 {
+		price = current
+	}
+
+	if config.MaxGasPrice != nil && price.Cmp(config.MaxGasPrice) > 0 {
+		price = new(big.Int).Set(config.MaxGasPrice)
+	}
+
+	return price
+}
+
+// receipt of the first mined transaction among `hashes`
+func (manager *TxManager) receipt(hashes []common.Hash) (*types.Receipt, error) {
+	for _, hash := range hashes {
+		receipt, err := manager.client.ec.TransactionReceipt(context.Background(), hash)
+		if err == ethereum.NotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return receipt, nil
+	}
+
+	return nil, ethereum.NotFound
+}
+
+func (manager *TxManager) monitor() {
+	ticker := time.NewTicker(txMonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-manager.quit:
+			return
+		case <-ticker.C:
+			manager.replaceStuck()
+			manager.forgetConfirmed()
+		}
+	}
+}
+
+// replaces pending transactions that have been unmined for longer than the deadline
+func (manager *TxManager) replaceStuck() {
+	manager.sendLock.Lock()
+	defer manager.sendLock.Unlock()
+
+	type snapshot struct {
+		mtx      *managedTx
+		hashes   []common.Hash
+		gasPrice *big.Int
+		sentAt   time.Time
+	}
+
+	// receipts are looked up without holding the lock
+	manager.lock.Lock()
+	deadline := manager.config.Deadline
+	pending := make(map[uint64]snapshot, len(manager.pending))
+	for nonce, mtx := range manager.pending {
+		pending[nonce] = snapshot{mtx, append([]common.Hash{}, mtx.hashes...), mtx.gasPrice, mtx.sentAt}
+	}
+	manager.lock.Unlock()
+
+	for nonce, tx := range pending {
+		receipt, err := manager.receipt(tx.hashes)
+		if err != nil && err != ethereum.NotFound {
+			manager.logger.Error(fmt.Sprintf("Error retrieving receipt for nonce %d - %s", nonce, err))
+			continue
+		}
+		if receipt != nil {
+			manager.lock.Lock()
+			delete(manager.pending, nonce)
+			tx.mtx.minedAt = receipt.BlockNumber.Uint64()
+			manager.mined = append(manager.mined, tx.mtx)
+			manager.lock.Unlock()
+			continue
+		}
+
+		if time.Since(tx.sentAt) < deadline {
+			continue
+		}
+
+		price := manager.bumpedGasPrice(tx.gasPrice)
+		if price.Cmp(tx.gasPrice) <= 0 {
+			manager.logger.Error(fmt.Sprintf("Transaction with nonce %d is stuck at the maximum gas price", nonce))
+			continue
+		}
+
+		replacement, err := manager.send(tx.mtx, price)
+		if err != nil && isNonceTooLow(err) {
+			// either a previous version was mined since the receipt check, in which case it is dropped
+			// on the next check, or the nonce was used outside of the manager and is never mined
+			manager.lock.Lock()
+			manager.nonce = nil
+			manager.lock.Unlock()
+
+			if receipt, _ := manager.receipt(tx.hashes); receipt == nil {
+				manager.logger.Error(fmt.Sprintf("Nonce %d of %s transaction was used by another transaction", nonce, tx.mtx.method))
+				manager.lock.Lock()
+				delete(manager.pending, nonce)
+				manager.forget(tx.mtx)
+				manager.lock.Unlock()
+			}
+			continue
+		} else if err != nil {
+			manager.logger.Error(fmt.Sprintf("Could not replace transaction with nonce %d - %s", nonce, err))
+			continue
+		}
+
+		manager.logger.Info(fmt.Sprintf("Replaced stuck %s transaction with nonce %d. Tx: %x, Gas price: %s",
+			tx.mtx.method, nonce, replacement.Hash(), price))
+	}
+}
+
+// forgets the versions of mined transactions that have `confirmations` confirmations. A
+// reorg any deeper than that would also revert the rootchain state the operator relies on
+func (manager *TxManager) forgetConfirmed() {
+	head, err := manager.client.CurrentBlockNum()
+	if err != nil {
+		manager.logger.Error(fmt.Sprintf("Could not retrieve the current ethereum block - %s", err))
+		return
+	}
+
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	var mined []*managedTx
+	for _, mtx := range manager.mined {
+		if head.Uint64() < mtx.minedAt+manager.confirmations {
+			mined = append(mined, mtx)
+			continue
+		}
+		manager.forget(mtx)
+	}
+	manager.mined = mined
+}
+
+// removes every version of a transaction. Must be called with the lock held
+func (manager *TxManager) forget(mtx *managedTx) {
+	for _, hash := range mtx.hashes {
+		delete(manager.versions, hash)
+	}
+}
+
+// ethereum nodes reject transactions whose nonce has already been used by a mined transaction.
+// Geth reports "nonce too low" while ganache reports an incorrect nonce
+func isNonceTooLow(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "correct nonce")
+}
@@ -0,0 +1,107 @@
+package eth
+
+import (
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestTxManagerTransact(t *testing.T) {
+	plasma, _, privKey := newSimulatedPlasma(t)
+
+	config := DefaultTxConfig()
+	config.Strategy = GasPriceFixed
+	config.GasPrice = big.NewInt(2000000000)
+	manager, err := NewTxManager(plasma.txManager.address, privKey, plasma.client, 0, config, log.NewTMLogger(os.Stderr))
+	if err != nil {
+		t.Fatal("Could not create tx manager -", err)
+	}
+	defer manager.Stop()
+
+	lastCommittedBlock, err := plasma.session.LastCommittedBlock()
+	if err != nil {
+		t.Fatal("Failed query for the last committed block -", err)
+	}
+
+	// out of order submissions revert and are never sent
+	header := [32]byte{1}
+	invalid := new(big.Int).Add(lastCommittedBlock, big.NewInt(2))
+	_, err = manager.Transact(nil, "submitBlock", [][32]byte{header}, []*big.Int{big.NewInt(1)}, invalid)
+	if err == nil {
+		t.Fatal("Reverting transaction was sent")
+	}
+	if manager.nonce != nil {
+		t.Error("Nonce consumed by a transaction that was not sent")
+	}
+
+	blockNum := new(big.Int).Add(lastCommittedBlock, big.NewInt(1))
+	tx, err := manager.Transact(nil, "submitBlock", [][32]byte{header}, []*big.Int{big.NewInt(1)}, blockNum)
+	if err != nil {
+		t.Fatal("Failed to submit block -", err)
+	}
+	if tx.GasPrice().Cmp(config.GasPrice) != 0 {
+		t.Errorf("Mismatch in gas price. Got: %s. Expected: %s", tx.GasPrice(), config.GasPrice)
+	}
+	if manager.nonce == nil || *manager.nonce != tx.Nonce()+1 {
+		t.Errorf("Local nonce not incremented past %d", tx.Nonce())
+	}
+
+	// the simulated chain mines transactions as they are sent
+	receipt, err := manager.TransactionReceipt(tx.Hash())
+	if err != nil {
+		t.Fatal("Could not retrieve receipt -", err)
+	}
+	if receipt.TxHash != tx.Hash() {
+		t.Errorf("Mismatch in receipt. Got: %x. Expected: %x", receipt.TxHash, tx.Hash())
+	}
+
+	// mined transactions are no longer tracked for replacement
+	manager.replaceStuck()
+	if len(manager.pending) != 0 {
+		t.Errorf("Mined transaction still pending")
+	}
+
+	// a stale local nonce is resynced with the ethereum node
+	stale := uint64(0)
+	manager.nonce = &stale
+	blockNum.Add(blockNum, big.NewInt(1))
+	tx, err = manager.Transact(nil, "submitBlock", [][32]byte{header}, []*big.Int{big.NewInt(1)}, blockNum)
+	if err != nil {
+		t.Fatal("Failed to submit block after resyncing the nonce -", err)
+	}
+	if tx.Nonce() == stale {
+		t.Errorf("Transaction sent with a used nonce")
+	}
+}
+
+func TestBumpedGasPrice(t *testing.T) {
+	cases := []struct {
+		config   TxConfig
+		current  int64
+		expected int64
+	}{
+		// Case 0: price raised by the bump percentage
+		{TxConfig{GasPriceFixed, big.NewInt(100), nil, time.Minute, 10}, 100, 110},
+
+		// Case 1: never below the current fixed price
+		{TxConfig{GasPriceFixed, big.NewInt(200), nil, time.Minute, 10}, 100, 200},
+
+		// Case 2: bounded by the maximum gas price
+		{TxConfig{GasPriceFixed, big.NewInt(100), big.NewInt(105), time.Minute, 10}, 100, 105},
+
+		// Case 3: no increase once the maximum is reached
+		{TxConfig{GasPriceFixed, big.NewInt(100), big.NewInt(100), time.Minute, 25}, 100, 100},
+	}
+
+	for index, tc := range cases {
+		manager := &TxManager{config: tc.config, lock: &sync.Mutex{}}
+		price := manager.bumpedGasPrice(big.NewInt(tc.current))
+		if price.Cmp(big.NewInt(tc.expected)) != 0 {
+			t.Errorf("Mismatch in gas price. Case: %d. Got: %s, Expected: %d", index, price, tc.expected)
+		}
+	}
+}
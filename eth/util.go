@@ -11,6 +11,14 @@ const (
 	depositPrefix         = "deposit"
 	transactionExitPrefix = "txExit"
 	depositExitPrefix     = "depositExit"
+	submissionPrefix      = "submission"
+	eventPrefix           = "event"
+
+	// prefix of the last ethereum block whose events have been cached, per subscription
+	processedBlockPrefix = "processedBlock"
+
+	// key of the highest sidechain block below which every submission is final
+	finalizedSubmissionKey = "finalizedSubmission"
 
 	// constants
 	blockIndexFactor = 1000000
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	rlp "github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
+)
+
+// Versions of the bytes input owners sign
+const (
+	// The RLP encoded msg. Only verified by rootchain contracts deployed without a chain ID,
+	// and valid on every chain using the same keys
+	SignVersionLegacy uint8 = 0
+
+	// The RLP encoded msg prefixed with the domain of the chain, as verified by the rootchain
+	// contract when exiting a transaction. See SignDomain.SignBytes
+	SignVersionDomain uint8 = 1
+)
+
+// SignDomain separates the signatures of different plasma chains, so that a spend signed
+// for one chain is not valid on another chain, or on a testnet, using the same keys
+type SignDomain struct {
+	Version   uint8
+	ChainID   string
+	Rootchain common.Address
+}
+
+func NewSignDomain(version uint8, chainID string, rootchain common.Address) SignDomain {
+	return SignDomain{
+		Version:   version,
+		ChainID:   chainID,
+		Rootchain: rootchain,
+	}
+}
+
+// DecodeSignDomain decodes a domain encoded with SignDomain.Bytes
+func DecodeSignDomain(bz []byte) (domain SignDomain, err error) {
+	if err = rlp.DecodeBytes(bz, &domain); err != nil {
+		return SignDomain{}, err
+	}
+	return domain, domain.ValidateBasic()
+}
+
+// Bytes returns the RLP encoding of the domain
+func (domain SignDomain) Bytes() []byte {
+	b, err := rlp.EncodeToBytes(domain)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (domain SignDomain) ValidateBasic() error {
+	switch domain.Version {
+	case SignVersionLegacy:
+		return nil
+	case SignVersionDomain:
+		if domain.ChainID == "" || utils.ZeroAddress(domain.Rootchain) {
+			return fmt.Errorf("sign version %d requires a chain ID and rootchain address", domain.Version)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sign version %d", domain.Version)
+	}
+}
+
+// SignBytes returns the bytes input owners sign for a msg with sign bytes `msgBytes`.
+// Under SignVersionDomain these are
+//
+//	version (1 byte) || rootchain (20 bytes) || keccak256(chain ID) (32 bytes) || msgBytes
+//
+// which a rootchain contract rebuilds from its own address, the hash of the chain ID it is
+// deployed with and the RLP encoded msg of the exiting transaction
+func (domain SignDomain) SignBytes(msgBytes []byte) []byte {
+	if domain.Version == SignVersionLegacy {
+		return msgBytes
+	}
+
+	bz := make([]byte, 0, 1+common.AddressLength+32+len(msgBytes))
+	bz = append(bz, domain.Version)
+	bz = append(bz, domain.Rootchain.Bytes()...)
+	bz = append(bz, ethcrypto.Keccak256([]byte(domain.ChainID))...)
+	return append(bz, msgBytes...)
+}
+
+// SignHash returns the hash input owners sign, as an ethereum signed message, for a msg
+// with sign bytes `msgBytes`
+func (domain SignDomain) SignHash(msgBytes []byte) []byte {
+	return ethcrypto.Keccak256(domain.SignBytes(msgBytes))
+}
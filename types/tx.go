@@ -9,8 +9,10 @@ import (
 	rlp "github.com/ethereum/go-ethereum/rlp"
 )
 
-var _ utxo.SpendMsg = SpendMsg{}
+var _ Spend = SpendMsg{}
 
+// SpendMsg is the legacy form decoded by the rootchain contract, which takes exactly its 14
+// fields. It has no fee field, its fee is the Ether its inputs leave unspent
 type SpendMsg struct {
 	Blknum0     uint64
 	Txindex0    uint16
@@ -65,6 +67,11 @@ func (msg SpendMsg) ValidateBasic() sdk.Error {
 		return ErrInvalidAmount(DefaultCodespace, "first amount must be positive")
 	}
 
+	// inputs must be able to cover the outputs
+	if amount := msg.Amount0 + msg.Amount1; amount < msg.Amount0 {
+		return ErrInvalidAmount(DefaultCodespace, "amounts overflow")
+	}
+
 	return nil
 }
 
@@ -102,6 +109,16 @@ func (msg SpendMsg) Inputs() []utxo.Input {
 	return inputs
 }
 
+// InputIndex returns the index of the input that spends `position`. -1 if it is not spent by this msg
+func (msg SpendMsg) InputIndex(position PlasmaPosition) int {
+	for index, input := range msg.Inputs() {
+		if input.Position == position {
+			return index
+		}
+	}
+	return -1
+}
+
 func (msg SpendMsg) Outputs() []utxo.Output {
 	outputs := []utxo.Output{utxo.Output{msg.Newowner0.Bytes(), Denom, msg.Amount0}}
 	if msg.Amount1 != 0 {
@@ -112,7 +129,7 @@ func (msg SpendMsg) Outputs() []utxo.Output {
 
 //----------------------------------------
 // BaseTx
-var _ sdk.Tx = BaseTx{}
+var _ SpendTx = BaseTx{}
 
 type BaseTx struct {
 	Msg        SpendMsg
@@ -128,3 +145,570 @@ func NewBaseTx(msg SpendMsg, sigs [2][65]byte) BaseTx {
 
 func (tx BaseTx) GetMsgs() []sdk.Msg         { return []sdk.Msg{tx.Msg} }
 func (tx BaseTx) GetSignatures() [2][65]byte { return tx.Signatures }
+
+// Implements SpendTx.
+func (tx BaseTx) GetSpend() Spend { return tx.Msg }
+
+// Implements SpendTx.
+func (tx BaseTx) SignerSignatures() [][65]byte {
+	return tx.Signatures[:len(tx.Msg.GetSigners())]
+}
+
+//----------------------------------------
+// Spend
+
+// Spend is implemented by the msgs spending utxos. SpendMsg is the legacy form with at most
+// two inputs and outputs accepted by the rootchain contract, MultiSpendMsg the general form
+type Spend interface {
+	utxo.SpendMsg
+
+	// Index of the input that spends `position`. -1 if it is not spent by the msg
+	InputIndex(position PlasmaPosition) int
+}
+
+// SpendTx is implemented by the transactions carrying a Spend
+type SpendTx interface {
+	sdk.Tx
+
+	GetSpend() Spend
+
+	// Signatures of the signers of the spend, in the order of GetSigners
+	SignerSignatures() [][65]byte
+}
+
+// DecodeSpendTx decodes a transaction of either spend form
+func DecodeSpendTx(txBytes []byte) (SpendTx, error) {
+	var baseTx BaseTx
+	err := rlp.DecodeBytes(txBytes, &baseTx)
+	if err == nil {
+		return baseTx, nil
+	}
+
+	// rlp decoding is strict on the number of fields so the forms cannot be confused
+	var multiSpendTx MultiSpendTx
+	if rlp.DecodeBytes(txBytes, &multiSpendTx) == nil {
+		return multiSpendTx, nil
+	}
+
+	return nil, err
+}
+
+// NewSpendTx signs `msg` in the form it should be sent in. Spends fitting the legacy form are
+// converted to it so that their outputs can be exited through the rootchain contract. The legacy
+// form pays what its inputs leave unspent, so `inputTotal`, the amount of the spent outputs, must
+// equal the outputs plus Fee. `sign` returns the signature of `owner` over the sign bytes
+func NewSpendTx(msg MultiSpendMsg, inputTotal uint64, sign func(owner common.Address, signBytes []byte) ([65]byte, error)) (SpendTx, error) {
+	outputTotal := msg.Fee
+	for _, output := range msg.TxOut {
+		outputTotal += output.Amount
+	}
+	if inputTotal != outputTotal {
+		return nil, fmt.Errorf("inputs of %d do not equal the outputs plus fee of %d", inputTotal, outputTotal)
+	}
+
+	if legacy, ok := msg.LegacySpendMsg(); ok {
+		var sigs [2][65]byte
+		for i, signer := range legacy.GetSigners() {
+			sig, err := sign(common.BytesToAddress(signer), legacy.GetSignBytes())
+			if err != nil {
+				return nil, err
+			}
+			sigs[i] = sig
+		}
+		return NewBaseTx(legacy, sigs), nil
+	}
+
+	sigs := make([][65]byte, len(msg.TxIn))
+	for i, input := range msg.TxIn {
+		sig, err := sign(input.Owner, msg.GetSignBytes())
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = sig
+	}
+	return NewMultiSpendTx(msg, sigs), nil
+}
+
+//----------------------------------------
+// MultiSpendMsg
+
+const (
+	// Maximum number of inputs of a MultiSpendMsg, bounded by the input index recorded
+	// for each spent utxo
+	MaxSpendInputs = 1 << 8
+
+	// Maximum number of outputs of a MultiSpendMsg, bounded by the rootchain's exit
+	// priority which reserves a single decimal digit for the output index
+	MaxSpendOutputs = 10
+)
+
+// TxInput spends the output owned by Owner at (Blknum, Txindex, Oindex, DepositNum)
+type TxInput struct {
+	Blknum     uint64
+	Txindex    uint16
+	Oindex     uint8
+	DepositNum uint64
+	Owner      common.Address
+}
+
+func NewTxInput(position PlasmaPosition, owner common.Address) TxInput {
+	return TxInput{
+		Blknum:     position.Blknum,
+		Txindex:    position.TxIndex,
+		Oindex:     position.Oindex,
+		DepositNum: position.DepositNum,
+		Owner:      owner,
+	}
+}
+
+// Position of the spent output
+func (input TxInput) Position() PlasmaPosition {
+	return NewPlasmaPosition(input.Blknum, input.Txindex, input.Oindex, input.DepositNum)
+}
+
+// TxOutput creates an output of Amount owned by Owner
+type TxOutput struct {
+	Owner  common.Address
+	Amount uint64
+}
+
+var _ Spend = MultiSpendMsg{}
+
+// MultiSpendMsg spends at most MaxSpendInputs inputs into at most MaxSpendOutputs outputs,
+// created at the output index of their position in TxOut. Every input owner signs the msg
+type MultiSpendMsg struct {
+	TxIn  []TxInput
+	TxOut []TxOutput
+	Fee   uint64
+}
+
+func NewMultiSpendMsg(inputs []TxInput, outputs []TxOutput, fee uint64) MultiSpendMsg {
+	return MultiSpendMsg{
+		TxIn:  inputs,
+		TxOut: outputs,
+		Fee:   fee,
+	}
+}
+
+// Implements Msg.
+func (msg MultiSpendMsg) Type() string { return "multi_spend_utxo" }
+
+// Implements Msg.
+func (msg MultiSpendMsg) Route() string { return "spend" }
+
+// Implements Msg.
+func (msg MultiSpendMsg) ValidateBasic() sdk.Error {
+	if len(msg.TxIn) == 0 {
+		return ErrInvalidTransaction(DefaultCodespace, "no inputs to transaction")
+	}
+	if len(msg.TxOut) == 0 {
+		return ErrInvalidAddress(DefaultCodespace, "no recipients of transaction")
+	}
+	if len(msg.TxIn) > MaxSpendInputs {
+		return ErrInvalidTransaction(DefaultCodespace, fmt.Sprintf("transaction has %d inputs, at most %d are allowed", len(msg.TxIn), MaxSpendInputs))
+	}
+	if len(msg.TxOut) > MaxSpendOutputs {
+		return ErrInvalidTransaction(DefaultCodespace, fmt.Sprintf("transaction has %d outputs, at most %d are allowed", len(msg.TxOut), MaxSpendOutputs))
+	}
+
+	spent := make(map[PlasmaPosition]bool)
+	for i, input := range msg.TxIn {
+		position := input.Position()
+		if !utils.ValidAddress(input.Owner) {
+			return ErrInvalidAddress(DefaultCodespace, fmt.Sprintf("input %d owner must have a valid address", i))
+		}
+		if !position.IsValid() {
+			return ErrInvalidTransaction(DefaultCodespace, fmt.Sprintf("input %d is malformed: %v", i, position))
+		}
+		if spent[position] {
+			return ErrInvalidTransaction(DefaultCodespace, fmt.Sprintf("cannot spend same position twice: %v", position))
+		}
+		spent[position] = true
+	}
+
+	// inputs must be able to cover the outputs and fee
+	total := msg.Fee
+	for i, output := range msg.TxOut {
+		if !utils.ValidAddress(output.Owner) {
+			return ErrInvalidAddress(DefaultCodespace, fmt.Sprintf("output %d owner must have a valid address", i))
+		}
+		if output.Amount == 0 {
+			return ErrInvalidAmount(DefaultCodespace, fmt.Sprintf("output %d amount must be positive", i))
+		}
+		if total+output.Amount < total {
+			return ErrInvalidAmount(DefaultCodespace, "amounts and fee overflow")
+		}
+		total += output.Amount
+	}
+
+	return nil
+}
+
+// Implements Msg.
+func (msg MultiSpendMsg) GetSignBytes() []byte {
+	b, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Implements Msg. The owner of every input signs, in the order of the inputs
+func (msg MultiSpendMsg) GetSigners() []sdk.AccAddress {
+	addrs := make([]sdk.AccAddress, len(msg.TxIn))
+	for i, input := range msg.TxIn {
+		addrs[i] = sdk.AccAddress(input.Owner.Bytes())
+	}
+	return addrs
+}
+
+func (msg MultiSpendMsg) Inputs() []utxo.Input {
+	inputs := make([]utxo.Input, len(msg.TxIn))
+	for i, input := range msg.TxIn {
+		inputs[i] = utxo.Input{
+			Owner:    input.Owner.Bytes(),
+			Position: input.Position(),
+		}
+	}
+	return inputs
+}
+
+func (msg MultiSpendMsg) Outputs() []utxo.Output {
+	outputs := make([]utxo.Output, len(msg.TxOut))
+	for i, output := range msg.TxOut {
+		outputs[i] = utxo.Output{output.Owner.Bytes(), Denom, output.Amount}
+	}
+	return outputs
+}
+
+// Fee paid to the validator
+func (msg MultiSpendMsg) GetFee() uint64 { return msg.Fee }
+
+// Implements Spend.
+func (msg MultiSpendMsg) InputIndex(position PlasmaPosition) int {
+	for index, input := range msg.TxIn {
+		if input.Position() == position {
+			return index
+		}
+	}
+	return -1
+}
+
+// LegacySpendMsg converts the msg into the legacy form accepted by the rootchain contract.
+// False if the msg has more than two inputs or outputs, or spends an output the legacy form
+// cannot reference. The legacy form pays what its inputs leave unspent, so Fee is only kept
+// if the inputs cover exactly the outputs and Fee
+func (msg MultiSpendMsg) LegacySpendMsg() (SpendMsg, bool) {
+	if len(msg.TxIn) == 0 || len(msg.TxIn) > 2 || len(msg.TxOut) == 0 || len(msg.TxOut) > 2 {
+		return SpendMsg{}, false
+	}
+	for _, input := range msg.TxIn {
+		if input.Oindex > 1 {
+			return SpendMsg{}, false
+		}
+	}
+
+	legacy := SpendMsg{
+		Blknum0:     msg.TxIn[0].Blknum,
+		Txindex0:    msg.TxIn[0].Txindex,
+		Oindex0:     msg.TxIn[0].Oindex,
+		DepositNum0: msg.TxIn[0].DepositNum,
+		Owner0:      msg.TxIn[0].Owner,
+		Newowner0:   msg.TxOut[0].Owner,
+		Amount0:     msg.TxOut[0].Amount,
+	}
+	if len(msg.TxIn) == 2 {
+		legacy.Blknum1 = msg.TxIn[1].Blknum
+		legacy.Txindex1 = msg.TxIn[1].Txindex
+		legacy.Oindex1 = msg.TxIn[1].Oindex
+		legacy.DepositNum1 = msg.TxIn[1].DepositNum
+		legacy.Owner1 = msg.TxIn[1].Owner
+	}
+	if len(msg.TxOut) == 2 {
+		legacy.Newowner1 = msg.TxOut[1].Owner
+		legacy.Amount1 = msg.TxOut[1].Amount
+	}
+	return legacy, true
+}
+
+//----------------------------------------
+// MultiSpendTx
+var _ SpendTx = MultiSpendTx{}
+
+// MultiSpendTx carries a MultiSpendMsg with the signature of every input owner
+type MultiSpendTx struct {
+	Msg        MultiSpendMsg
+	Signatures [][65]byte
+}
+
+func NewMultiSpendTx(msg MultiSpendMsg, sigs [][65]byte) MultiSpendTx {
+	return MultiSpendTx{
+		Msg:        msg,
+		Signatures: sigs,
+	}
+}
+
+func (tx MultiSpendTx) GetMsgs() []sdk.Msg { return []sdk.Msg{tx.Msg} }
+
+// Implements SpendTx.
+func (tx MultiSpendTx) GetSpend() Spend { return tx.Msg }
+
+// Implements SpendTx.
+func (tx MultiSpendTx) SignerSignatures() [][65]byte { return tx.Signatures }
+
+//----------------------------------------
+// ConfirmSigMsg
+
+var _ sdk.Msg = ConfirmSigMsg{}
+
+// ConfirmSigMsg carries the confirmation signatures of the input owners of the
+// transaction at (Blknum, Txindex). The second signature is empty if the
+// transaction has a single input
+type ConfirmSigMsg struct {
+	Blknum     uint64
+	Txindex    uint16
+	Signatures [2][65]byte
+}
+
+func NewConfirmSigMsg(blknum uint64, txindex uint16, sigs [2][65]byte) ConfirmSigMsg {
+	return ConfirmSigMsg{
+		Blknum:     blknum,
+		Txindex:    txindex,
+		Signatures: sigs,
+	}
+}
+
+// Implements Msg.
+func (msg ConfirmSigMsg) Type() string { return "confirm_sig" }
+
+// Implements Msg.
+func (msg ConfirmSigMsg) Route() string { return "confirm" }
+
+// Implements Msg.
+func (msg ConfirmSigMsg) ValidateBasic() sdk.Error {
+	if msg.Blknum == 0 {
+		return ErrInvalidTransaction(DefaultCodespace, "deposits do not have confirmation signatures")
+	}
+	if msg.Signatures[0] == [65]byte{} {
+		return ErrInvalidTransaction(DefaultCodespace, "first confirmation signature must be provided")
+	}
+	return nil
+}
+
+// Implements Msg.
+func (msg ConfirmSigMsg) GetSignBytes() []byte {
+	b, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Implements Msg. The signers are the input owners of the confirmed
+// transaction, which are only known from state
+func (msg ConfirmSigMsg) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{}
+}
+
+// ConfirmSigs returns the signatures in the form expected by the rootchain contract.
+// 65 bytes for a single input transaction, 130 bytes otherwise
+func (msg ConfirmSigMsg) ConfirmSigs() []byte {
+	sigs := append([]byte{}, msg.Signatures[0][:]...)
+	if msg.Signatures[1] != [65]byte{} {
+		sigs = append(sigs, msg.Signatures[1][:]...)
+	}
+	return sigs
+}
+
+//----------------------------------------
+// ConfirmSigTx
+var _ sdk.Tx = ConfirmSigTx{}
+
+type ConfirmSigTx struct {
+	Msg ConfirmSigMsg
+}
+
+func NewConfirmSigTx(msg ConfirmSigMsg) ConfirmSigTx {
+	return ConfirmSigTx{
+		Msg: msg,
+	}
+}
+
+func (tx ConfirmSigTx) GetMsgs() []sdk.Msg { return []sdk.Msg{tx.Msg} }
+
+//----------------------------------------
+// DepositMsg
+
+var _ sdk.Msg = DepositMsg{}
+
+// DepositMsg includes the rootchain deposit with nonce DepositNum in the sidechain. The
+// operator signs the msg once the deposit is final on the rootchain, so that every node
+// delivering it computes the same state without an ethereum node
+type DepositMsg struct {
+	DepositNum  uint64
+	Owner       common.Address
+	Amount      uint64
+	EthBlockNum uint64
+}
+
+func NewDepositMsg(depositNum uint64, owner common.Address, amount, ethBlockNum uint64) DepositMsg {
+	return DepositMsg{
+		DepositNum:  depositNum,
+		Owner:       owner,
+		Amount:      amount,
+		EthBlockNum: ethBlockNum,
+	}
+}
+
+// Implements Msg.
+func (msg DepositMsg) Type() string { return "include_deposit" }
+
+// Implements Msg.
+func (msg DepositMsg) Route() string { return "deposit" }
+
+// Implements Msg.
+func (msg DepositMsg) ValidateBasic() sdk.Error {
+	if msg.DepositNum == 0 {
+		return ErrInvalidTransaction(DefaultCodespace, "deposit nonce must be positive")
+	}
+	if !utils.ValidAddress(msg.Owner) {
+		return ErrInvalidAddress(DefaultCodespace, "deposit owner must have a valid address", msg.Owner)
+	}
+	if msg.Amount == 0 {
+		return ErrInvalidAmount(DefaultCodespace, "deposit amount must be positive")
+	}
+	return nil
+}
+
+// Implements Msg.
+func (msg DepositMsg) GetSignBytes() []byte {
+	b, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Implements Msg. Deposits are authorized by the operator rather than the owner
+func (msg DepositMsg) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{}
+}
+
+// Position of the deposit's output
+func (msg DepositMsg) Position() PlasmaPosition {
+	return NewPlasmaPosition(0, 0, 0, msg.DepositNum)
+}
+
+//----------------------------------------
+// DepositTx
+var _ sdk.Tx = DepositTx{}
+
+type DepositTx struct {
+	Msg DepositMsg
+
+	// Signature of the operator over the sign bytes of the msg
+	Signature [65]byte
+}
+
+func NewDepositTx(msg DepositMsg, sig [65]byte) DepositTx {
+	return DepositTx{
+		Msg:       msg,
+		Signature: sig,
+	}
+}
+
+func (tx DepositTx) GetMsgs() []sdk.Msg { return []sdk.Msg{tx.Msg} }
+
+//----------------------------------------
+// ExitMsg
+
+// states of a rootchain exit mirrored by an ExitMsg. Match the rootchain contract
+const (
+	ExitStarted    uint8 = 1
+	ExitChallenged uint8 = 2
+)
+
+var _ sdk.Msg = ExitMsg{}
+
+// ExitMsg mirrors the state of the rootchain exit of the output owned by Owner at
+// (Blknum, Txindex, Oindex, DepositNum). The operator signs the msg once the exit is final on
+// the rootchain so that delivery does not need an ethereum node
+type ExitMsg struct {
+	Blknum     uint64
+	Txindex    uint16
+	Oindex     uint8
+	DepositNum uint64
+	Owner      common.Address
+	State      uint8
+}
+
+func NewExitMsg(position PlasmaPosition, owner common.Address, state uint8) ExitMsg {
+	return ExitMsg{
+		Blknum:     position.Blknum,
+		Txindex:    position.TxIndex,
+		Oindex:     position.Oindex,
+		DepositNum: position.DepositNum,
+		Owner:      owner,
+		State:      state,
+	}
+}
+
+// Implements Msg.
+func (msg ExitMsg) Type() string { return "mirror_exit" }
+
+// Implements Msg.
+func (msg ExitMsg) Route() string { return "exit" }
+
+// Implements Msg.
+func (msg ExitMsg) ValidateBasic() sdk.Error {
+	// the rootchain contract only exits the first two outputs of a transaction
+	if !msg.Position().IsValid() || msg.Oindex > 1 {
+		return ErrInvalidTransaction(DefaultCodespace, fmt.Sprintf("invalid exit position: %v", msg.Position()))
+	}
+	if !utils.ValidAddress(msg.Owner) {
+		return ErrInvalidAddress(DefaultCodespace, "exit owner must have a valid address", msg.Owner)
+	}
+	if msg.State != ExitStarted && msg.State != ExitChallenged {
+		return ErrInvalidTransaction(DefaultCodespace, fmt.Sprintf("unknown exit state: %d", msg.State))
+	}
+	return nil
+}
+
+// Implements Msg.
+func (msg ExitMsg) GetSignBytes() []byte {
+	b, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Implements Msg. Exits are authorized by the operator rather than the owner
+func (msg ExitMsg) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{}
+}
+
+// Position of the exited output
+func (msg ExitMsg) Position() PlasmaPosition {
+	return NewPlasmaPosition(msg.Blknum, msg.Txindex, msg.Oindex, msg.DepositNum)
+}
+
+//----------------------------------------
+// ExitTx
+var _ sdk.Tx = ExitTx{}
+
+type ExitTx struct {
+	Msg ExitMsg
+
+	// Signature of the operator over the sign bytes of the msg
+	Signature [65]byte
+}
+
+func NewExitTx(msg ExitMsg, sig [65]byte) ExitTx {
+	return ExitTx{
+		Msg:       msg,
+		Signature: sig,
+	}
+}
+
+func (tx ExitTx) GetMsgs() []sdk.Msg { return []sdk.Msg{tx.Msg} }
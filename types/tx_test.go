@@ -7,6 +7,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	rlp "github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/AdityaSripal/plasma-mvp-sidechain/utils"
 )
@@ -139,3 +140,233 @@ func TestGetSigners(t *testing.T) {
 	signers = msg.GetSigners()
 	require.Equal(t, addrs, signers, "signer Addresses do not match")
 }
+
+// Tests the encoding of confirmation signatures for the rootchain
+func TestConfirmSigMsg(t *testing.T) {
+	var sigs [2][65]byte
+	msg := NewConfirmSigMsg(1, 0, sigs)
+	err := msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	sigs[0][0] = 1
+	msg = NewConfirmSigMsg(0, 0, sigs)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = NewConfirmSigMsg(1, 0, sigs)
+	require.NoError(t, msg.ValidateBasic())
+	require.Equal(t, sigs[0][:], msg.ConfirmSigs(), "single input transactions have one signature")
+
+	sigs[1][0] = 2
+	msg = NewConfirmSigMsg(1, 0, sigs)
+	require.Equal(t, append(sigs[0][:], sigs[1][:]...), msg.ConfirmSigs(), "two input transactions have two signatures")
+}
+
+func TestInputIndex(t *testing.T) {
+	msg := GenBasicSpendMsg()
+
+	require.Equal(t, 0, msg.InputIndex(NewPlasmaPosition(1, 0, 0, 0)))
+	require.Equal(t, 1, msg.InputIndex(NewPlasmaPosition(1, 1, 0, 0)))
+	require.Equal(t, -1, msg.InputIndex(NewPlasmaPosition(1, 1, 1, 0)), "position is not an input")
+
+	// an unused second input does not match the empty position
+	msg.Blknum1, msg.Txindex1 = 0, 0
+	require.Equal(t, -1, msg.InputIndex(NewPlasmaPosition(0, 0, 0, 0)))
+}
+
+func TestDepositMsg(t *testing.T) {
+	addr := utils.GenerateAddress()
+
+	msg := NewDepositMsg(0, addr, 100, 1)
+	err := msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = NewDepositMsg(1, common.Address{}, 100, 1)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(201), err.Code(), err.Error())
+
+	msg = NewDepositMsg(1, addr, 0, 1)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(203), err.Code(), err.Error())
+
+	msg = NewDepositMsg(1, addr, 100, 1)
+	require.NoError(t, msg.ValidateBasic())
+	require.Equal(t, NewPlasmaPosition(0, 0, 0, 1), msg.Position())
+	require.Empty(t, msg.GetSigners(), "deposits are not signed")
+}
+
+func TestExitMsg(t *testing.T) {
+	addr := utils.GenerateAddress()
+
+	msg := NewExitMsg(NewPlasmaPosition(1, 0, 2, 0), addr, ExitStarted)
+	err := msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = NewExitMsg(NewPlasmaPosition(1, 0, 1, 0), common.Address{}, ExitStarted)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(201), err.Code(), err.Error())
+
+	msg = NewExitMsg(NewPlasmaPosition(0, 0, 0, 1), addr, 3)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = NewExitMsg(NewPlasmaPosition(1, 0, 1, 0), addr, ExitChallenged)
+	require.NoError(t, msg.ValidateBasic())
+	require.Equal(t, NewPlasmaPosition(1, 0, 1, 0), msg.Position())
+}
+
+// Amounts that cannot be covered by any inputs are rejected
+func TestAmountOverflow(t *testing.T) {
+	var msg = GenSpendMsgWithAddresses()
+	require.Nil(t, msg.ValidateBasic())
+
+	msg.Amount0, msg.Amount1 = ^uint64(0), 1
+	err := msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(203), err.Code(), err.Error())
+}
+
+func GenMultiSpendMsg(inputs, outputs int) MultiSpendMsg {
+	var msg MultiSpendMsg
+	for i := 0; i < inputs; i++ {
+		msg.TxIn = append(msg.TxIn, NewTxInput(NewPlasmaPosition(1, uint16(i), 0, 0), utils.GenerateAddress()))
+	}
+	for i := 0; i < outputs; i++ {
+		msg.TxOut = append(msg.TxOut, TxOutput{Owner: utils.GenerateAddress(), Amount: 100})
+	}
+	return msg
+}
+
+func TestMultiSpendMsg(t *testing.T) {
+	msg := GenMultiSpendMsg(3, 4)
+	require.NoError(t, msg.ValidateBasic())
+	require.Len(t, msg.Inputs(), 3)
+	require.Len(t, msg.Outputs(), 4)
+	require.Len(t, msg.GetSigners(), 3)
+	require.Equal(t, 2, msg.InputIndex(NewPlasmaPosition(1, 2, 0, 0)))
+
+	// outputs beyond the second can be spent
+	msg.TxIn[1] = NewTxInput(NewPlasmaPosition(2, 0, 3, 0), msg.TxIn[1].Owner)
+	require.NoError(t, msg.ValidateBasic())
+
+	msg = GenMultiSpendMsg(0, 1)
+	err := msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = GenMultiSpendMsg(2, 0)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(201), err.Code(), err.Error())
+
+	msg = GenMultiSpendMsg(1, MaxSpendOutputs+1)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = GenMultiSpendMsg(MaxSpendInputs+1, 1)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	// output indexes beyond the rootchain's exit priority cannot be spent
+	msg = GenMultiSpendMsg(1, 1)
+	msg.TxIn[0] = NewTxInput(NewPlasmaPosition(2, 0, MaxSpendOutputs, 0), msg.TxIn[0].Owner)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = GenMultiSpendMsg(3, 1)
+	msg.TxIn[2] = NewTxInput(msg.TxIn[0].Position(), msg.TxIn[2].Owner)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), "spent the same position twice")
+
+	msg = GenMultiSpendMsg(1, 1)
+	msg.TxIn[0].DepositNum = 1
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(204), err.Code(), err.Error())
+
+	msg = GenMultiSpendMsg(1, 2)
+	msg.TxOut[1].Amount = 0
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(203), err.Code(), err.Error())
+
+	msg.TxOut[1].Amount, msg.Fee = 1, ^uint64(0)
+	err = msg.ValidateBasic()
+	require.Equal(t, sdk.CodeType(203), err.Code(), err.Error())
+}
+
+// Spends fitting the legacy form are sent in it, others in the multi spend form
+func TestNewSpendTx(t *testing.T) {
+	sign := func(owner common.Address, signBytes []byte) (sig [65]byte, err error) {
+		copy(sig[:], owner.Bytes())
+		return sig, nil
+	}
+
+	msg := GenMultiSpendMsg(2, 2)
+	msg.Fee = 10
+	legacy, ok := msg.LegacySpendMsg()
+	require.True(t, ok)
+	require.Equal(t, msg.Inputs(), legacy.Inputs())
+	require.Equal(t, msg.Outputs(), legacy.Outputs())
+
+	// the fee would be lost if the inputs did not cover exactly the outputs and fee
+	_, err := NewSpendTx(msg, 200, sign)
+	require.Error(t, err)
+
+	tx, err := NewSpendTx(msg, 210, sign)
+	require.NoError(t, err)
+	require.IsType(t, BaseTx{}, tx)
+	require.Len(t, tx.SignerSignatures(), 2)
+
+	txBytes, _ := rlp.EncodeToBytes(tx)
+	decoded, err := DecodeSpendTx(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, tx, decoded)
+
+	// the rootchain contract decodes rlp([14 msg fields, [sig0, sig1]])
+	var layout []rlp.RawValue
+	require.NoError(t, rlp.DecodeBytes(txBytes, &layout))
+	require.Len(t, layout, 2)
+	var fields, sigs []rlp.RawValue
+	require.NoError(t, rlp.DecodeBytes(layout[0], &fields))
+	require.Len(t, fields, 14)
+	require.NoError(t, rlp.DecodeBytes(layout[1], &sigs))
+	require.Len(t, sigs, 2)
+
+	msg = GenMultiSpendMsg(3, 1)
+	_, ok = msg.LegacySpendMsg()
+	require.False(t, ok, "three inputs do not fit the legacy form")
+
+	tx, err = NewSpendTx(msg, 100, sign)
+	require.NoError(t, err)
+	require.IsType(t, MultiSpendTx{}, tx)
+	require.Len(t, tx.SignerSignatures(), 3)
+
+	txBytes, _ = rlp.EncodeToBytes(tx)
+	decoded, err = DecodeSpendTx(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, tx, decoded)
+}
+
+func TestSignDomain(t *testing.T) {
+	msgBytes := GenMultiSpendMsg(1, 1).GetSignBytes()
+	rootchain := utils.GenerateAddress()
+
+	legacy := NewSignDomain(SignVersionLegacy, "", common.Address{})
+	require.NoError(t, legacy.ValidateBasic())
+	require.Equal(t, msgBytes, legacy.SignBytes(msgBytes), "legacy domain changed the sign bytes")
+
+	domain := NewSignDomain(SignVersionDomain, "plasma", rootchain)
+	require.NoError(t, domain.ValidateBasic())
+	signBytes := domain.SignBytes(msgBytes)
+	require.Equal(t, SignVersionDomain, signBytes[0])
+	require.Equal(t, rootchain.Bytes(), signBytes[1:21])
+	require.Equal(t, ethcrypto.Keccak256([]byte("plasma")), signBytes[21:53])
+	require.Equal(t, msgBytes, signBytes[53:])
+
+	// signatures of other chains differ
+	require.NotEqual(t, domain.SignHash(msgBytes), NewSignDomain(SignVersionDomain, "testnet", rootchain).SignHash(msgBytes))
+	require.NotEqual(t, domain.SignHash(msgBytes), NewSignDomain(SignVersionDomain, "plasma", utils.GenerateAddress()).SignHash(msgBytes))
+
+	decoded, err := DecodeSignDomain(domain.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, domain, decoded)
+
+	require.Error(t, NewSignDomain(SignVersionDomain, "", rootchain).ValidateBasic(), "domain without a chain ID")
+	require.Error(t, NewSignDomain(2, "plasma", rootchain).ValidateBasic(), "unknown sign version")
+}
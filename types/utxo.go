@@ -10,6 +10,11 @@ import (
 const (
 	// Only allowed Denomination on this plasma chain
 	Denom = "Ether"
+
+	// Transaction index reserved for the output collecting the fees of a block. Fee outputs
+	// are minted by the sidechain without a transaction in the block's merkle tree, so they
+	// cannot be exited on the rootchain and must be spent into an exitable output first
+	FeeTxIndex = 1<<16 - 1
 )
 
 //----------------------------------------
@@ -42,15 +47,25 @@ func (position PlasmaPosition) Get() []sdk.Uint {
 // check that the position is formatted correctly
 // Implements Position
 func (position PlasmaPosition) IsValid() bool {
-	// If position is a regular tx, output index must be 0 or 1 and depositnum must be 0
+	// If position is a regular tx, depositnum must be 0 and oindex must be an output of a MultiSpendMsg
 	if position.Blknum != 0 {
-		return position.Oindex < 2 && position.DepositNum == 0
+		return position.Oindex < MaxSpendOutputs && position.DepositNum == 0
 	} else {
 		// If position represents deposit, depositnum is not 0 and txindex and oindex are 0.
 		return position.DepositNum != 0 && position.TxIndex == 0 && position.Oindex == 0
 	}
 }
 
+// NewFeePosition returns the position of the fee output of block `blknum`
+func NewFeePosition(blknum uint64) PlasmaPosition {
+	return NewPlasmaPosition(blknum, FeeTxIndex, 0, 0)
+}
+
+// IsFee is true for the position of a fee output, which only exists on the sidechain
+func (position PlasmaPosition) IsFee() bool {
+	return position.Blknum != 0 && position.TxIndex == FeeTxIndex
+}
+
 func (position PlasmaPosition) IsDeposit() bool {
 	if !position.IsValid() {
 		return false
@@ -70,4 +85,12 @@ func RegisterAmino(cdc *amino.Codec) {
 	cdc.RegisterConcrete(PlasmaPosition{}, "types/PlasmaPosition", nil)
 	cdc.RegisterConcrete(BaseTx{}, "types/BaseTX", nil)
 	cdc.RegisterConcrete(SpendMsg{}, "types/SpendMsg", nil)
+	cdc.RegisterConcrete(MultiSpendTx{}, "types/MultiSpendTx", nil)
+	cdc.RegisterConcrete(MultiSpendMsg{}, "types/MultiSpendMsg", nil)
+	cdc.RegisterConcrete(ConfirmSigTx{}, "types/ConfirmSigTx", nil)
+	cdc.RegisterConcrete(ConfirmSigMsg{}, "types/ConfirmSigMsg", nil)
+	cdc.RegisterConcrete(DepositTx{}, "types/DepositTx", nil)
+	cdc.RegisterConcrete(DepositMsg{}, "types/DepositMsg", nil)
+	cdc.RegisterConcrete(ExitTx{}, "types/ExitTx", nil)
+	cdc.RegisterConcrete(ExitMsg{}, "types/ExitMsg", nil)
 }
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// Tendermint's simple merkle tree as verified by the rootchain contract's TMSimpleMerkleTree.
+// Leaves are the sha256 hashes of the transaction bytes and inner nodes hash their children
+// each prefixed by their length, 0x20
+
+// MerkleRoot returns the root of the tree over `txs`. Nil if there are no transactions
+func MerkleRoot(txs [][]byte) []byte {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	return merkleRoot(leafHashes(txs))
+}
+
+// MerkleProof returns the aunts of the transaction at `index`, ordered from the leaf up to but excluding the root
+func MerkleProof(txs [][]byte, index int) []byte {
+	if index < 0 || index >= len(txs) {
+		return nil
+	}
+
+	return merkleProof(leafHashes(txs), index)
+}
+
+// VerifyMerkleProof checks that `txBytes` is the transaction at `index` of the `total` transactions under `root`
+func VerifyMerkleProof(txBytes []byte, index, total int, root, proof []byte) bool {
+	if index < 0 || index >= total || len(proof)%32 != 0 {
+		return false
+	}
+
+	leaf := sha256.Sum256(txBytes)
+	computed, ok := computeHashFromAunts(index, total, leaf[:], proof)
+	return ok && bytes.Equal(computed, root)
+}
+
+func leafHashes(txs [][]byte) [][]byte {
+	leaves := make([][]byte, len(txs))
+	for i, tx := range txs {
+		hash := sha256.Sum256(tx)
+		leaves[i] = hash[:]
+	}
+	return leaves
+}
+
+func innerHash(left, right []byte) []byte {
+	data := make([]byte, 0, 2+len(left)+len(right))
+	data = append(data, byte(len(left)))
+	data = append(data, left...)
+	data = append(data, byte(len(right)))
+	data = append(data, right...)
+
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+func merkleRoot(hashes [][]byte) []byte {
+	if len(hashes) == 1 {
+		return hashes[0]
+	}
+
+	numLeft := (len(hashes) + 1) / 2
+	return innerHash(merkleRoot(hashes[:numLeft]), merkleRoot(hashes[numLeft:]))
+}
+
+func merkleProof(hashes [][]byte, index int) []byte {
+	if len(hashes) == 1 {
+		return []byte{}
+	}
+
+	// the aunt at this level is the last element of the proof
+	numLeft := (len(hashes) + 1) / 2
+	if index < numLeft {
+		return append(merkleProof(hashes[:numLeft], index), merkleRoot(hashes[numLeft:])...)
+	}
+	return append(merkleProof(hashes[numLeft:], index-numLeft), merkleRoot(hashes[:numLeft])...)
+}
+
+func computeHashFromAunts(index, total int, leaf, aunts []byte) ([]byte, bool) {
+	if total == 1 {
+		return leaf, len(aunts) == 0
+	}
+	if len(aunts) == 0 {
+		return nil, false
+	}
+
+	numLeft := (total + 1) / 2
+	aunt := aunts[len(aunts)-32:]
+	if index < numLeft {
+		left, ok := computeHashFromAunts(index, numLeft, leaf, aunts[:len(aunts)-32])
+		return innerHash(left, aunt), ok
+	}
+	right, ok := computeHashFromAunts(index-numLeft, total-numLeft, leaf, aunts[:len(aunts)-32])
+	return innerHash(aunt, right), ok
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleRoot(t *testing.T) {
+	require.Nil(t, MerkleRoot(nil), "root of an empty tree")
+
+	a, b := []byte("tx a"), []byte("tx b")
+	leafA, leafB := sha256.Sum256(a), sha256.Sum256(b)
+
+	// a single transaction is its own root
+	require.Equal(t, leafA[:], MerkleRoot([][]byte{a}))
+
+	// children are prefixed by their length
+	data := append(append([]byte{0x20}, leafA[:]...), append([]byte{0x20}, leafB[:]...)...)
+	expected := sha256.Sum256(data)
+	require.Equal(t, expected[:], MerkleRoot([][]byte{a, b}))
+}
+
+func TestMerkleProof(t *testing.T) {
+	for total := 1; total <= 10; total++ {
+		var txs [][]byte
+		for i := 0; i < total; i++ {
+			txs = append(txs, []byte(fmt.Sprintf("tx %d", i)))
+		}
+		root := MerkleRoot(txs)
+
+		for index := 0; index < total; index++ {
+			proof := MerkleProof(txs, index)
+			require.Equal(t, 0, len(proof)%32, "proof must consist of 32 byte hashes")
+			require.True(t, VerifyMerkleProof(txs[index], index, total, root, proof),
+				fmt.Sprintf("invalid proof. Total: %d, Index: %d", total, index))
+
+			// the proof does not hold for a different transaction or position
+			require.False(t, VerifyMerkleProof([]byte("other"), index, total, root, proof))
+			if total > 1 {
+				require.False(t, VerifyMerkleProof(txs[index], (index+1)%total, total, root, proof))
+			}
+		}
+	}
+
+	// the top level aunt is the last hash of the proof
+	txs := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	proof := MerkleProof(txs, 0)
+	leafC := sha256.Sum256(txs[2])
+	require.True(t, bytes.Equal(leafC[:], proof[32:]))
+}
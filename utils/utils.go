@@ -2,6 +2,8 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
@@ -11,6 +13,70 @@ import (
 
 var RootHashPrefix = []byte("root hash")
 var ConfirmSigPrefix = []byte("confirmation signatures")
+var TxBytesPrefix = []byte("transaction bytes")
+var DepositPrefix = []byte("deposit")
+var SpendPrefix = []byte("spend")
+
+// SignDomainKey is the plasma store key of the domain spends are signed under
+var SignDomainKey = []byte("sign domain")
+
+// OperatorKey is the plasma store key of the address authorizing rootchain deposits and exits
+var OperatorKey = []byte("operator")
+
+// RootHashKey is the plasma store key of the merkle root of block `blknum`
+func RootHashKey(blknum uint64) []byte {
+	return prefixKey(RootHashPrefix, blknumKey(blknum))
+}
+
+// TxBytesKey is the plasma store key of the encoded transaction at (`blknum`, `txindex`)
+func TxBytesKey(blknum uint64, txindex uint16) []byte {
+	return prefixKey(TxBytesPrefix, txKey(blknum, txindex))
+}
+
+// BlockTxsPrefix prefixes the plasma store keys of every transaction in block `blknum`
+func BlockTxsPrefix(blknum uint64) []byte {
+	return prefixKey(TxBytesPrefix, blknumKey(blknum))
+}
+
+// TxBytesPosition returns the block number and transaction index of a TxBytesKey
+func TxBytesPosition(key []byte) (uint64, uint16) {
+	return txPosition(key[len(TxBytesPrefix):])
+}
+
+// SpendKey is the plasma store key of the transaction spending the output at
+// (`blknum`, `txindex`, `oindex`, `depositNum`). The value is a SpenderValue
+func SpendKey(blknum uint64, txindex uint16, oindex uint8, depositNum uint64) []byte {
+	key := append(txKey(blknum, txindex), oindex)
+	return prefixKey(SpendPrefix, append(key, blknumKey(depositNum)...))
+}
+
+// SpenderValue encodes the position of a spending transaction
+func SpenderValue(blknum uint64, txindex uint16) []byte {
+	return txKey(blknum, txindex)
+}
+
+// SpenderPosition returns the block number and transaction index of a SpenderValue
+func SpenderPosition(value []byte) (uint64, uint16) {
+	return txPosition(value)
+}
+
+// ConfirmSigKey is the plasma store key of the confirmation signatures for the transaction at (`blknum`, `txindex`)
+func ConfirmSigKey(blknum uint64, txindex uint16) []byte {
+	return prefixKey(ConfirmSigPrefix, txKey(blknum, txindex))
+}
+
+// DepositKey is the plasma store key marking that the deposit with nonce `nonce` has been included
+func DepositKey(nonce uint64) []byte {
+	return prefixKey(DepositPrefix, blknumKey(nonce))
+}
+
+// ConfirmationHash is the hash input owners sign to acknowledge that their transaction was included
+// in the block with merkle root `root`. Matches the rootchain contract: sha256(sha256(txBytes), root)
+func ConfirmationHash(txBytes []byte, root []byte) []byte {
+	merkleHash := sha256.Sum256(txBytes)
+	hash := sha256.Sum256(append(merkleHash[:], root...))
+	return hash[:]
+}
 
 func ZeroAddress(addr common.Address) bool {
 	return new(big.Int).SetBytes(addr.Bytes()).Sign() == 0
@@ -37,6 +103,28 @@ func SignHash(data []byte) []byte {
 	return ethcrypto.Keccak256([]byte(msg))
 }
 
+func prefixKey(prefix []byte, key []byte) []byte {
+	return append(append([]byte{}, prefix...), key...)
+}
+
+// fixed width uvarint encoding of the block number
+func blknumKey(blknum uint64) []byte {
+	key := make([]byte, binary.MaxVarintLen64)
+	binary.PutUvarint(key, blknum)
+	return key
+}
+
+func txKey(blknum uint64, txindex uint16) []byte {
+	key := make([]byte, 2)
+	binary.BigEndian.PutUint16(key, txindex)
+	return append(blknumKey(blknum), key...)
+}
+
+func txPosition(key []byte) (uint64, uint16) {
+	blknum, _ := binary.Uvarint(key[:binary.MaxVarintLen64])
+	return blknum, binary.BigEndian.Uint16(key[binary.MaxVarintLen64:])
+}
+
 // helper function for tests
 func GetIndex(index int64) int64 {
 	if index >= 0 {
@@ -28,3 +28,9 @@ func (kvstore KVStore) Delete(ctx sdk.Context, key []byte) {
 	store := ctx.KVStore(kvstore.contextKey)
 	store.Delete(key)
 }
+
+// Iterates over all keys beginning with the prefix in ascending order
+func (kvstore KVStore) PrefixIterator(ctx sdk.Context, prefix []byte) sdk.Iterator {
+	store := ctx.KVStore(kvstore.contextKey)
+	return sdk.KVStorePrefixIterator(store, prefix)
+}
@@ -2,6 +2,7 @@ package utxo
 
 import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
 )
 
 // Return the next position for handler to store newly created UTXOs
@@ -20,11 +21,13 @@ func NewSpendHandler(um Mapper, nextPos NextPosition) sdk.Handler {
 			panic("Msg does not implement SpendMsg")
 		}
 
-		// Add outputs from store
+		// Add outputs from store. The position of the first output is the position of the transaction
+		var txPosition Position
 		for i, o := range spendMsg.Outputs() {
 			var next Position
 			if i == 0 {
 				next = nextPos(ctx, false)
+				txPosition = next
 			} else {
 				next = nextPos(ctx, true)
 			}
@@ -33,8 +36,13 @@ func NewSpendHandler(um Mapper, nextPos NextPosition) sdk.Handler {
 		}
 
 		// Spend inputs from store
-		for _, i := range spendMsg.Inputs() {
-			err := um.SpendUTXO(ctx, i.Owner, i.Position)
+		txHash := tmhash.Sum(ctx.TxBytes())
+		for index, i := range spendMsg.Inputs() {
+			err := um.SpendUTXO(ctx, i.Owner, i.Position, Spender{
+				Position:   txPosition,
+				TxHash:     txHash,
+				InputIndex: uint8(index),
+			})
 			if err != nil {
 				return err.Result()
 			}
@@ -11,6 +11,7 @@ import (
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	rlp "github.com/ethereum/go-ethereum/rlp"
 	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/tmhash"
 	"github.com/tendermint/tendermint/libs/log"
 
 	utils "github.com/AdityaSripal/plasma-mvp-sidechain/utils"
@@ -95,16 +96,17 @@ func TestHandleSpendMessage(t *testing.T) {
 	}
 
 	for index, tc := range cases {
-		ms, capKey, _ := SetupMultiStore()
+		ms, capKey, _, indexKey := SetupMultiStore()
 
 		cdc := MakeCodec()
 		cdc.RegisterConcrete(&UTXO{}, "x/utxo/UTXO", nil)
 		cdc.RegisterConcrete(&testPosition{}, "x/utxo/testPosition", nil)
-		mapper := NewBaseMapper(capKey, cdc)
+		mapper := NewBaseMapper(capKey, indexKey, cdc)
 		app := testApp{0, 0}
 		handler := NewSpendHandler(mapper, app.testNextPosition)
 
-		ctx := sdk.NewContext(ms, abci.Header{Height: 6}, false, log.NewNopLogger())
+		txBytes := []byte(fmt.Sprintf("spend %d", index))
+		ctx := sdk.NewContext(ms, abci.Header{Height: 6}, false, log.NewNopLogger()).WithTxBytes(txBytes)
 		var inputs []Input
 		var outputs []Output
 
@@ -133,11 +135,18 @@ func TestHandleSpendMessage(t *testing.T) {
 		res := handler(ctx, msg)
 		require.Equal(t, sdk.CodeType(0), sdk.CodeType(res.Code), res.Log)
 
-		// Delete inputs
-		for _, in := range msg.Inputs() {
+		// Delete inputs, recording the input of the transaction spending them
+		txHash := tmhash.Sum(txBytes)
+		for i, in := range msg.Inputs() {
 			utxo := mapper.GetUTXO(ctx, in.Owner, in.Position)
 			require.NotNil(t, utxo)
 			require.False(t, utxo.Valid, "Spent UTXO not valid")
+
+			spent, ok := mapper.GetSpentUTXO(ctx, in.Owner, in.Position)
+			require.True(t, ok, "Spent UTXO not in the history")
+			require.Equal(t, newTestPosition([]uint64{6, 0, 0}).Get(), spent.Spender.Position.Get())
+			require.Equal(t, txHash, spent.Spender.TxHash)
+			require.Equal(t, uint8(i), spent.Spender.InputIndex)
 		}
 
 		// Check that outputs were created and are valid
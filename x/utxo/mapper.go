@@ -1,19 +1,71 @@
 package utxo
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	amino "github.com/tendermint/go-amino"
 )
 
+// Prefixes of the keys of the index store. It holds everything the mapper records besides the
+// UTXOs, which are keyed by their owner's address in a store of their own
+var (
+	// index from positions to the addresses owning them
+	PositionIndexPrefix = []byte{0x01}
+
+	// total supply of each denomination
+	SupplyPrefix = []byte{0x02}
+
+	// history of spent UTXOs
+	SpentPrefix = []byte{0x03}
+
+	// history ordered by the time UTXOs were spent, used to prune it
+	SpentTimePrefix = []byte{0x04}
+)
+
+// UTXOFilter selects the UTXOs visited when iterating
+type UTXOFilter uint8
+
+const (
+	// every UTXO
+	AllUTXOs UTXOFilter = iota
+	// UTXOs that can be spent
+	ValidUTXOs
+	// UTXOs that were spent or exited
+	SpentUTXOs
+)
+
+func (filter UTXOFilter) matches(utxo UTXO) bool {
+	switch filter {
+	case ValidUTXOs:
+		return utxo.Valid
+	case SpentUTXOs:
+		return !utxo.Valid
+	default:
+		return true
+	}
+}
+
 // Mapper stores and retrieves UTXO's from stores
 // retrieved from the context.
 type Mapper interface {
 	GetUTXO(ctx sdk.Context, addr []byte, position Position) UTXO
+	GetUTXOByPosition(ctx sdk.Context, position Position) UTXO
+	GetUTXOsForAddress(ctx sdk.Context, addr []byte, filter UTXOFilter) []UTXO
+	IterateUTXOs(ctx sdk.Context, filter UTXOFilter, process func(UTXO) (stop bool))
+	TotalSupply(ctx sdk.Context) map[string]uint64
 	ConstructKey(addr []byte, position Position) []byte
 	ReceiveUTXO(sdk.Context, UTXO)
 	ValidateUTXO(sdk.Context, UTXO) sdk.Error
 	InvalidateUTXO(sdk.Context, UTXO)
-	SpendUTXO(ctx sdk.Context, addr []byte, position Position) sdk.Error
+	SpendUTXO(ctx sdk.Context, addr []byte, position Position, spender Spender) sdk.Error
+	GetSpentUTXO(ctx sdk.Context, addr []byte, position Position) (SpentUTXO, bool)
+	GetSpentUTXOByPosition(ctx sdk.Context, position Position) (SpentUTXO, bool)
+	IterateSpentUTXOs(ctx sdk.Context, process func(SpentUTXO) (stop bool))
+	PruneSpentUTXOs(ctx sdk.Context, before time.Time) []SpentUTXO
 }
 
 // Maps Address+Position to UTXO
@@ -23,13 +75,17 @@ type baseMapper struct {
 	// The contextKey used to access the store from the Context.
 	contextKey sdk.StoreKey
 
+	// The key of the store holding the position index, supply and history
+	indexKey sdk.StoreKey
+
 	// The Amino codec for binary encoding/decoding
 	cdc *amino.Codec
 }
 
-func NewBaseMapper(contextKey sdk.StoreKey, cdc *amino.Codec) Mapper {
+func NewBaseMapper(contextKey, indexKey sdk.StoreKey, cdc *amino.Codec) Mapper {
 	return baseMapper{
 		contextKey: contextKey,
+		indexKey:   indexKey,
 		cdc:        cdc,
 	}
 }
@@ -49,46 +105,255 @@ func (um baseMapper) GetUTXO(ctx sdk.Context, addr []byte, position Position) UT
 	return utxo
 }
 
+// Returns the UTXO at the position, whatever its owner
+// Returns nil if no UTXO exists at that position
+func (um baseMapper) GetUTXOByPosition(ctx sdk.Context, position Position) UTXO {
+	addr := ctx.KVStore(um.indexKey).Get(um.positionKey(position))
+	if addr == nil {
+		return UTXO{}
+	}
+
+	return um.GetUTXO(ctx, addr, position)
+}
+
+// Returns the UTXOs owned by the address selected by the filter, ordered by encoded position.
+// Spent UTXOs kept in the history follow the UTXOs that were not spent
+func (um baseMapper) GetUTXOsForAddress(ctx sdk.Context, addr []byte, filter UTXOFilter) []UTXO {
+	var utxos []UTXO
+	um.iterate(ctx, addr, filter, func(utxo UTXO) bool {
+		utxos = append(utxos, utxo)
+		return false
+	})
+	return utxos
+}
+
+// Calls process on every UTXO selected by the filter, ordered by address and encoded position,
+// until process returns true. Spent UTXOs kept in the history follow the UTXOs that were not spent
+func (um baseMapper) IterateUTXOs(ctx sdk.Context, filter UTXOFilter, process func(UTXO) (stop bool)) {
+	um.iterate(ctx, nil, filter, process)
+}
+
+// Returns the total amount of every denomination held by valid UTXOs
+func (um baseMapper) TotalSupply(ctx sdk.Context) map[string]uint64 {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(um.indexKey), SupplyPrefix)
+	defer iter.Close()
+
+	supply := make(map[string]uint64)
+	for ; iter.Valid(); iter.Next() {
+		supply[string(iter.Key()[len(SupplyPrefix):])] = binary.BigEndian.Uint64(iter.Value())
+	}
+	return supply
+}
+
+// Iterates over the UTXOs whose key starts with `addr`, or every UTXO if nil, followed by the
+// spent UTXOs of the history if the filter selects them
+func (um baseMapper) iterate(ctx sdk.Context, addr []byte, filter UTXOFilter, process func(UTXO) bool) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(um.contextKey), addr)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		utxo := um.decodeUTXO(iter.Value())
+		if filter.matches(utxo) && process(utxo) {
+			return
+		}
+	}
+
+	if filter == ValidUTXOs {
+		return
+	}
+	um.iterateSpent(ctx, addr, func(spent SpentUTXO) bool {
+		return process(spent.UTXO)
+	})
+}
+
+// Calls process on every spent UTXO kept in the history, ordered by address and encoded position,
+// until process returns true
+func (um baseMapper) IterateSpentUTXOs(ctx sdk.Context, process func(SpentUTXO) (stop bool)) {
+	um.iterateSpent(ctx, nil, process)
+}
+
+func (um baseMapper) iterateSpent(ctx sdk.Context, addr []byte, process func(SpentUTXO) bool) {
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(um.indexKey), append(append([]byte{}, SpentPrefix...), addr...))
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		if process(um.decodeSpentUTXO(iter.Value())) {
+			return
+		}
+	}
+}
+
 // Receives the UTXO to the mapper
 func (um baseMapper) ReceiveUTXO(ctx sdk.Context, utxo UTXO) {
-	store := ctx.KVStore(um.contextKey)
-
-	key := utxo.StoreKey(um.cdc)
-	bz := um.encodeUTXO(utxo)
-	store.Set(key, bz)
+	um.setUTXO(ctx, utxo)
 }
 
-// Spend UTXO corresponding to address + position from mapping
-func (um baseMapper) SpendUTXO(ctx sdk.Context, addr []byte, position Position) sdk.Error {
-	store := ctx.KVStore(um.contextKey)
-	key := um.ConstructKey(addr, position)
+// Spend UTXO corresponding to address + position from mapping.
+// The spent UTXO is moved to the history along with the input of the transaction spending it
+func (um baseMapper) SpendUTXO(ctx sdk.Context, addr []byte, position Position, spender Spender) sdk.Error {
 	utxo := um.GetUTXO(ctx, addr, position)
 	if !utxo.Valid {
 		return sdk.ErrUnauthorized("UTXO is not valid for spend")
 	}
+
+	index := ctx.KVStore(um.indexKey)
+	index.Set(supplyKey(utxo.Denom), encodeSupply(decodeSupply(index.Get(supplyKey(utxo.Denom)))-utxo.Amount))
+	ctx.KVStore(um.contextKey).Delete(utxo.StoreKey(um.cdc))
+
 	utxo.Valid = false
-	encodedUTXO := um.encodeUTXO(utxo)
-	store.Set(key, encodedUTXO)
+	spent := SpentUTXO{
+		UTXO:    utxo,
+		Spender: spender,
+		SpentAt: ctx.BlockHeader().Time,
+	}
+	key := um.spentKey(addr, position)
+	index.Set(key, um.encodeSpentUTXO(spent))
+	index.Set(spentTimeKey(spent.SpentAt, key), key)
 	return nil
 }
 
-// Validates UTXO only if it not spent already
+// Returns the spent UTXO corresponding to the address + position from the history
+func (um baseMapper) GetSpentUTXO(ctx sdk.Context, addr []byte, position Position) (SpentUTXO, bool) {
+	bz := ctx.KVStore(um.indexKey).Get(um.spentKey(addr, position))
+	if bz == nil {
+		return SpentUTXO{}, false
+	}
+	return um.decodeSpentUTXO(bz), true
+}
+
+// Returns the spent UTXO at the position from the history, whatever its owner
+func (um baseMapper) GetSpentUTXOByPosition(ctx sdk.Context, position Position) (SpentUTXO, bool) {
+	addr := ctx.KVStore(um.indexKey).Get(um.positionKey(position))
+	if addr == nil {
+		return SpentUTXO{}, false
+	}
+	return um.GetSpentUTXO(ctx, addr, position)
+}
+
+// Deletes the UTXOs spent before the given time from the history along with their position
+// index entries, returning the deleted spends
+func (um baseMapper) PruneSpentUTXOs(ctx sdk.Context, before time.Time) []SpentUTXO {
+	store := ctx.KVStore(um.indexKey)
+	end := spentTimeKey(before, nil)
+
+	// collect the keys first, the store must not be written while iterating
+	var keys [][]byte
+	iter := sdk.KVStorePrefixIterator(store, SpentTimePrefix)
+	for ; iter.Valid() && bytes.Compare(iter.Key(), end) < 0; iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+
+	var pruned []SpentUTXO
+	for _, key := range keys {
+		spentKey := store.Get(key)
+		if bz := store.Get(spentKey); bz != nil {
+			spent := um.decodeSpentUTXO(bz)
+			store.Delete(um.positionKey(spent.UTXO.Position))
+			store.Delete(spentKey)
+			pruned = append(pruned, spent)
+		}
+		store.Delete(key)
+	}
+	return pruned
+}
+
+// Validates UTXO only if it is stored and was not spent. Clears the exited flag of a UTXO whose exit was challenged
 func (um baseMapper) ValidateUTXO(ctx sdk.Context, utxo UTXO) sdk.Error {
-	store := ctx.KVStore(um.contextKey)
-	key := utxo.StoreKey(um.cdc)
+	if utxo.Position == nil || ctx.KVStore(um.contextKey).Get(utxo.StoreKey(um.cdc)) == nil {
+		return sdk.ErrUnauthorized("UTXO does not exist or was spent")
+	}
+
 	utxo.Valid = true
-	encodedUTXO := um.encodeUTXO(utxo)
-	store.Set(key, encodedUTXO)
+	utxo.Exited = false
+	um.setUTXO(ctx, utxo)
 	return nil
 }
 
 // Invalidates UTXO
 func (um baseMapper) InvalidateUTXO(ctx sdk.Context, utxo UTXO) {
+	utxo.Valid = false
+	um.setUTXO(ctx, utxo)
+}
+
+// Stores the UTXO, indexes its position and accounts for the change in supply
+func (um baseMapper) setUTXO(ctx sdk.Context, utxo UTXO) {
 	store := ctx.KVStore(um.contextKey)
+	index := ctx.KVStore(um.indexKey)
 	key := utxo.StoreKey(um.cdc)
-	utxo.Valid = false
-	encodedUTXO := um.encodeUTXO(utxo)
-	store.Set(key, encodedUTXO)
+
+	if bz := store.Get(key); bz != nil {
+		if prev := um.decodeUTXO(bz); prev.Valid {
+			index.Set(supplyKey(prev.Denom), encodeSupply(decodeSupply(index.Get(supplyKey(prev.Denom)))-prev.Amount))
+		}
+	}
+	if utxo.Valid {
+		index.Set(supplyKey(utxo.Denom), encodeSupply(decodeSupply(index.Get(supplyKey(utxo.Denom)))+utxo.Amount))
+	}
+
+	store.Set(key, um.encodeUTXO(utxo))
+	index.Set(um.positionKey(utxo.Position), utxo.Address)
+}
+
+// (<spent prefix> + <address> + <encoded position>) maps to a spent UTXO
+func (um baseMapper) spentKey(address []byte, position Position) []byte {
+	key := append([]byte{}, SpentPrefix...)
+	return append(key, um.ConstructKey(address, position)...)
+}
+
+// (<spent time prefix> + <big endian unix time in nanoseconds> + <spent key>) maps to the spent key,
+// ordering the history by the time UTXOs were spent. Times before the unix epoch order first
+func spentTimeKey(spentAt time.Time, spentKey []byte) []byte {
+	var nanos uint64
+	if spentAt.After(time.Unix(0, 0)) {
+		nanos = uint64(spentAt.UnixNano())
+	}
+
+	key := make([]byte, len(SpentTimePrefix)+8, len(SpentTimePrefix)+8+len(spentKey))
+	copy(key, SpentTimePrefix)
+	binary.BigEndian.PutUint64(key[len(SpentTimePrefix):], nanos)
+	return append(key, spentKey...)
+}
+
+// (<supply prefix> + <denom>) maps to the total supply of the denomination
+func supplyKey(denom string) []byte {
+	return append(append([]byte{}, SupplyPrefix...), denom...)
+}
+
+func encodeSupply(amount uint64) []byte {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, amount)
+	return bz
+}
+
+func decodeSupply(bz []byte) uint64 {
+	if bz == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(bz)
+}
+
+// CheckSupply returns an error if the tracked total supply of a denomination does not
+// match the amounts of the valid UTXOs of that denomination
+func CheckSupply(ctx sdk.Context, mapper Mapper) error {
+	amounts := make(map[string]uint64)
+	mapper.IterateUTXOs(ctx, ValidUTXOs, func(utxo UTXO) bool {
+		amounts[utxo.Denom] += utxo.Amount
+		return false
+	})
+
+	supply := mapper.TotalSupply(ctx)
+	for denom, amount := range supply {
+		if amounts[denom] != amount {
+			return fmt.Errorf("total supply of %s is %d but valid UTXOs hold %d", denom, amount, amounts[denom])
+		}
+	}
+	for denom, amount := range amounts {
+		if _, ok := supply[denom]; !ok {
+			return fmt.Errorf("total supply of %s is not tracked but valid UTXOs hold %d", denom, amount)
+		}
+	}
+	return nil
 }
 
 // (<address> + <encoded position>) forms the unique key that maps to an UTXO.
@@ -101,6 +366,15 @@ func (um baseMapper) ConstructKey(address []byte, position Position) []byte {
 	return key
 }
 
+// (<position index prefix> + <encoded position>) maps to the address owning the UTXO at the position
+func (um baseMapper) positionKey(position Position) []byte {
+	posBytes, err := um.cdc.MarshalBinaryBare(position)
+	if err != nil {
+		panic(err)
+	}
+	return append(append([]byte{}, PositionIndexPrefix...), posBytes...)
+}
+
 func (um baseMapper) encodeUTXO(utxo UTXO) []byte {
 	bz, err := um.cdc.MarshalBinaryBare(utxo)
 	if err != nil {
@@ -116,3 +390,19 @@ func (um baseMapper) decodeUTXO(bz []byte) (utxo UTXO) {
 	}
 	return utxo
 }
+
+func (um baseMapper) encodeSpentUTXO(spent SpentUTXO) []byte {
+	bz, err := um.cdc.MarshalBinaryBare(spent)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (um baseMapper) decodeSpentUTXO(bz []byte) (spent SpentUTXO) {
+	err := um.cdc.UnmarshalBinaryBare(bz, &spent)
+	if err != nil {
+		panic(err)
+	}
+	return spent
+}
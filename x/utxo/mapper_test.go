@@ -3,6 +3,7 @@ package utxo
 import (
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
@@ -52,12 +53,12 @@ func (pos testPosition) IsValid() bool {
 */
 
 func TestUTXOGetReceiveSpend(t *testing.T) {
-	ms, capKey, _ := SetupMultiStore()
+	ms, capKey, _, indexKey := SetupMultiStore()
 
 	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
 	cdc := MakeCodec()
 	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
-	mapper := NewBaseMapper(capKey, cdc)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
 
 	priv, _ := ethcrypto.GenerateKey()
 	addr := utils.PrivKeyToAddress(priv)
@@ -75,7 +76,7 @@ func TestUTXOGetReceiveSpend(t *testing.T) {
 	require.True(t, received.Valid, "output UTXO is not valid")
 	require.Equal(t, utxo, received, "not equal after receive")
 
-	mapper.SpendUTXO(ctx, addr.Bytes(), position)
+	mapper.SpendUTXO(ctx, addr.Bytes(), position, Spender{Position: newTestPosition([]uint64{5, 0, 0})})
 	utxo = mapper.GetUTXO(ctx, addr.Bytes(), position)
 	require.False(t, utxo.Valid, "Spent UTXO is still valid")
 }
@@ -87,12 +88,12 @@ func TestUTXOGetReceiveSpend(t *testing.T) {
 */
 
 func TestMultiUTXOAddDeleteSameBlock(t *testing.T) {
-	ms, capKey, _ := SetupMultiStore()
+	ms, capKey, _, indexKey := SetupMultiStore()
 
 	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
 	cdc := MakeCodec()
 	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
-	mapper := NewBaseMapper(capKey, cdc)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
 
 	priv, _ := ethcrypto.GenerateKey()
 	addr := utils.PrivKeyToAddress(priv)
@@ -110,7 +111,7 @@ func TestMultiUTXOAddDeleteSameBlock(t *testing.T) {
 		position := newTestPosition([]uint64{uint64(i%4) + 1, uint64(i / 4), 0})
 
 		utxo := mapper.GetUTXO(ctx, addr.Bytes(), position)
-		mapper.SpendUTXO(ctx, addr.Bytes(), position)
+		mapper.SpendUTXO(ctx, addr.Bytes(), position, Spender{Position: newTestPosition([]uint64{5, 0, 0})})
 		utxo = mapper.GetUTXO(ctx, addr.Bytes(), position)
 		require.False(t, utxo.Valid, "Spent UTXO is still valid")
 	}
@@ -118,13 +119,13 @@ func TestMultiUTXOAddDeleteSameBlock(t *testing.T) {
 }
 
 func TestInvalidAddress(t *testing.T) {
-	ms, capKey, _ := SetupMultiStore()
+	ms, capKey, _, indexKey := SetupMultiStore()
 
 	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
 
 	cdc := MakeCodec()
 	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
-	mapper := NewBaseMapper(capKey, cdc)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
 
 	priv0, _ := ethcrypto.GenerateKey()
 	addr0 := utils.PrivKeyToAddress(priv0)
@@ -148,22 +149,22 @@ func TestInvalidAddress(t *testing.T) {
 	utxo = mapper.GetUTXO(ctx, addr0.Bytes(), position)
 
 	// SpendUTXO with correct position but wrong address
-	mapper.SpendUTXO(ctx, addr1.Bytes(), position)
+	mapper.SpendUTXO(ctx, addr1.Bytes(), position, Spender{Position: newTestPosition([]uint64{5, 0, 0})})
 	utxo = mapper.GetUTXO(ctx, addr0.Bytes(), position)
 	require.True(t, utxo.Valid, "UTXO invalid after invalid spend")
 
-	mapper.SpendUTXO(ctx, addr0.Bytes(), position)
+	mapper.SpendUTXO(ctx, addr0.Bytes(), position, Spender{Position: newTestPosition([]uint64{5, 0, 0})})
 	utxo = mapper.GetUTXO(ctx, addr0.Bytes(), position)
 	require.False(t, utxo.Valid, "UTXO still valid after valid spend")
 }
 
 func TestSpendInvalidUTXO(t *testing.T) {
-	ms, capKey, _ := SetupMultiStore()
+	ms, capKey, _, indexKey := SetupMultiStore()
 
 	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
 	cdc := MakeCodec()
 	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
-	mapper := NewBaseMapper(capKey, cdc)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
 
 	priv, _ := ethcrypto.GenerateKey()
 	addr := utils.PrivKeyToAddress(priv)
@@ -176,15 +177,204 @@ func TestSpendInvalidUTXO(t *testing.T) {
 
 	mapper.InvalidateUTXO(ctx, utxo)
 
-	err := mapper.SpendUTXO(ctx, addr.Bytes(), position)
+	err := mapper.SpendUTXO(ctx, addr.Bytes(), position, Spender{Position: newTestPosition([]uint64{5, 0, 0})})
 
 	utxo = mapper.GetUTXO(ctx, addr.Bytes(), position)
 	require.NotNil(t, err, "Allowed invalid UTXO to be spent")
 
 	mapper.ValidateUTXO(ctx, utxo)
-	err = mapper.SpendUTXO(ctx, addr.Bytes(), position)
+	err = mapper.SpendUTXO(ctx, addr.Bytes(), position, Spender{Position: newTestPosition([]uint64{5, 0, 0})})
 
 	utxo = mapper.GetUTXO(ctx, addr.Bytes(), position)
 	require.Nil(t, err, "Spend of valid UTXO errorred")
 	require.False(t, utxo.Valid, "Spent UTXO is still valid")
 }
+
+// Tests that UTXOs are found by position through every change of their state
+func TestGetUTXOByPosition(t *testing.T) {
+	ms, capKey, _, indexKey := SetupMultiStore()
+
+	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
+	cdc := MakeCodec()
+	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
+
+	addr := utils.GenerateAddress()
+	position := newTestPosition([]uint64{1, 0, 0})
+	require.Equal(t, UTXO{}, mapper.GetUTXOByPosition(ctx, position), "found a UTXO that was not received")
+
+	utxo := NewUTXO(addr.Bytes(), 100, "testEther", position)
+	mapper.ReceiveUTXO(ctx, utxo)
+	require.Equal(t, utxo, mapper.GetUTXOByPosition(ctx, position))
+
+	mapper.InvalidateUTXO(ctx, utxo)
+	require.False(t, mapper.GetUTXOByPosition(ctx, position).Valid, "invalidated UTXO is still valid")
+
+	require.Nil(t, mapper.ValidateUTXO(ctx, utxo))
+	require.True(t, mapper.GetUTXOByPosition(ctx, position).Valid, "validated UTXO is not valid")
+
+	utxo.Exited = true
+	mapper.InvalidateUTXO(ctx, utxo)
+	require.True(t, mapper.GetUTXOByPosition(ctx, position).Exited)
+
+	// spent UTXOs cannot be validated again
+	spentPosition := newTestPosition([]uint64{2, 0, 0})
+	spent := NewUTXO(addr.Bytes(), 100, "testEther", spentPosition)
+	mapper.ReceiveUTXO(ctx, spent)
+	require.Nil(t, mapper.SpendUTXO(ctx, addr.Bytes(), spentPosition, Spender{Position: newTestPosition([]uint64{5, 0, 0})}))
+	require.Equal(t, mapper.GetUTXO(ctx, addr.Bytes(), spentPosition), mapper.GetUTXOByPosition(ctx, spentPosition))
+	require.False(t, mapper.GetUTXOByPosition(ctx, spentPosition).Valid, "spent UTXO is still valid")
+	require.NotNil(t, mapper.ValidateUTXO(ctx, spent), "validated a spent UTXO")
+	require.Equal(t, UTXO{}, mapper.GetUTXO(ctx, addr.Bytes(), spentPosition))
+
+	// the index is not part of the UTXOs of an address
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(capKey), addr.Bytes())
+	defer iter.Close()
+	var keys int
+	for ; iter.Valid(); iter.Next() {
+		keys++
+	}
+	require.Equal(t, 1, keys)
+}
+
+// Tests iteration over the UTXOs of the mapper and that the total supply follows their state
+func TestIterateUTXOs(t *testing.T) {
+	ms, capKey, _, indexKey := SetupMultiStore()
+
+	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
+	cdc := MakeCodec()
+	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
+
+	addr0 := utils.GenerateAddress()
+	addr1 := utils.GenerateAddress()
+	for i := 0; i < 4; i++ {
+		mapper.ReceiveUTXO(ctx, NewUTXO(addr0.Bytes(), 100, "testEther", newTestPosition([]uint64{1, uint64(i), 0})))
+	}
+	mapper.ReceiveUTXO(ctx, NewUTXO(addr1.Bytes(), 50, "testToken", newTestPosition([]uint64{2, 0, 0})))
+	require.Equal(t, map[string]uint64{"testEther": 400, "testToken": 50}, mapper.TotalSupply(ctx))
+
+	mapper.SpendUTXO(ctx, addr0.Bytes(), newTestPosition([]uint64{1, 0, 0}), Spender{Position: newTestPosition([]uint64{5, 0, 0})})
+	mapper.SpendUTXO(ctx, addr0.Bytes(), newTestPosition([]uint64{1, 1, 0}), Spender{Position: newTestPosition([]uint64{5, 0, 0})})
+	require.Equal(t, map[string]uint64{"testEther": 200, "testToken": 50}, mapper.TotalSupply(ctx))
+
+	require.Len(t, mapper.GetUTXOsForAddress(ctx, addr0.Bytes(), AllUTXOs), 4)
+	require.Len(t, mapper.GetUTXOsForAddress(ctx, addr0.Bytes(), ValidUTXOs), 2)
+	require.Len(t, mapper.GetUTXOsForAddress(ctx, addr0.Bytes(), SpentUTXOs), 2)
+	require.Len(t, mapper.GetUTXOsForAddress(ctx, addr1.Bytes(), SpentUTXOs), 0)
+
+	var visited int
+	mapper.IterateUTXOs(ctx, ValidUTXOs, func(utxo UTXO) bool {
+		require.True(t, utxo.Valid)
+		visited++
+		return false
+	})
+	require.Equal(t, 3, visited)
+
+	// iteration stops when asked to
+	visited = 0
+	mapper.IterateUTXOs(ctx, AllUTXOs, func(utxo UTXO) bool {
+		visited++
+		return true
+	})
+	require.Equal(t, 1, visited)
+
+	// receiving a UTXO again does not count its amount twice
+	utxo := mapper.GetUTXO(ctx, addr1.Bytes(), newTestPosition([]uint64{2, 0, 0}))
+	mapper.ReceiveUTXO(ctx, utxo)
+	mapper.InvalidateUTXO(ctx, utxo)
+	require.Equal(t, uint64(0), mapper.TotalSupply(ctx)["testToken"])
+	require.NoError(t, CheckSupply(ctx, mapper))
+}
+
+// Tests that owners whose address starts with the prefix of an index key only see their UTXOs
+func TestIndexKeysDisjoint(t *testing.T) {
+	ms, capKey, _, indexKey := SetupMultiStore()
+
+	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
+	cdc := MakeCodec()
+	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
+
+	prefixes := [][]byte{PositionIndexPrefix, SupplyPrefix, SpentPrefix, SpentTimePrefix}
+	var addrs [][]byte
+	for i, prefix := range prefixes {
+		addr := append(append([]byte{}, prefix...), utils.GenerateAddress().Bytes()[len(prefix):]...)
+		addrs = append(addrs, addr)
+		mapper.ReceiveUTXO(ctx, NewUTXO(addr, 100, "testEther", newTestPosition([]uint64{1, uint64(i), 0})))
+	}
+	mapper.SpendUTXO(ctx, addrs[0], newTestPosition([]uint64{1, 0, 0}), Spender{Position: newTestPosition([]uint64{2, 0, 0})})
+
+	for i, addr := range addrs {
+		utxos := mapper.GetUTXOsForAddress(ctx, addr, AllUTXOs)
+		require.Len(t, utxos, 1, "address %d", i)
+		require.Equal(t, addr, utxos[0].Address)
+	}
+
+	var visited int
+	mapper.IterateUTXOs(ctx, AllUTXOs, func(utxo UTXO) bool {
+		visited++
+		return false
+	})
+	require.Equal(t, len(addrs), visited)
+	require.NoError(t, CheckSupply(ctx, mapper))
+}
+
+// Tests that spent UTXOs are moved to the history with their spender and pruned by the time they were spent
+func TestSpentUTXOHistory(t *testing.T) {
+	ms, capKey, _, indexKey := SetupMultiStore()
+
+	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
+	cdc := MakeCodec()
+	cdc.RegisterConcrete(testPosition{}, "x/utxo/testPosition", nil)
+	mapper := NewBaseMapper(capKey, indexKey, cdc)
+
+	addr := utils.GenerateAddress()
+	start := time.Unix(1000, 0)
+	for i := 0; i < 3; i++ {
+		position := newTestPosition([]uint64{1, uint64(i), 0})
+		mapper.ReceiveUTXO(ctx, NewUTXO(addr.Bytes(), 100, "testEther", position))
+
+		spendCtx := ctx.WithBlockHeader(abci.Header{Time: start.Add(time.Duration(i) * time.Hour)})
+		spender := Spender{Position: newTestPosition([]uint64{2, uint64(i), 0}), TxHash: []byte{byte(i)}, InputIndex: 1}
+		require.Nil(t, mapper.SpendUTXO(spendCtx, addr.Bytes(), position, spender))
+	}
+
+	// spent UTXOs leave the keyspace of their owner
+	position := newTestPosition([]uint64{1, 1, 0})
+	require.Equal(t, UTXO{}, mapper.GetUTXO(ctx, addr.Bytes(), position))
+	iter := sdk.KVStorePrefixIterator(ctx.KVStore(capKey), addr.Bytes())
+	require.False(t, iter.Valid(), "spent UTXO kept in the keyspace of its owner")
+	iter.Close()
+
+	spent, ok := mapper.GetSpentUTXOByPosition(ctx, position)
+	require.True(t, ok, "spent UTXO not found by position")
+	require.False(t, spent.UTXO.Valid)
+	require.Equal(t, uint64(100), spent.UTXO.Amount)
+	require.Equal(t, Spender{Position: newTestPosition([]uint64{2, 1, 0}), TxHash: []byte{1}, InputIndex: 1}, spent.Spender)
+	require.True(t, start.Add(time.Hour).Equal(spent.SpentAt))
+	require.Len(t, mapper.GetUTXOsForAddress(ctx, addr.Bytes(), SpentUTXOs), 3)
+	require.Len(t, mapper.GetUTXOsForAddress(ctx, addr.Bytes(), ValidUTXOs), 0)
+
+	// a spent UTXO cannot be spent again
+	require.NotNil(t, mapper.SpendUTXO(ctx, addr.Bytes(), position, Spender{Position: newTestPosition([]uint64{3, 0, 0})}))
+	require.Equal(t, map[string]uint64{"testEther": 0}, mapper.TotalSupply(ctx))
+
+	// pruning drops the UTXOs spent before the given time along with their position index
+	pruned := mapper.PruneSpentUTXOs(ctx, start.Add(90*time.Minute))
+	require.Len(t, pruned, 2)
+	require.Equal(t, spent, pruned[1])
+	_, ok = mapper.GetSpentUTXOByPosition(ctx, position)
+	require.False(t, ok, "pruned UTXO still in the history")
+	require.Nil(t, ctx.KVStore(indexKey).Get(mapper.(baseMapper).positionKey(position)), "position of a pruned UTXO still indexed")
+	_, ok = mapper.GetSpentUTXO(ctx, addr.Bytes(), newTestPosition([]uint64{1, 2, 0}))
+	require.True(t, ok, "UTXO spent after the pruning time was pruned")
+
+	var visited int
+	mapper.IterateSpentUTXOs(ctx, func(spent SpentUTXO) bool {
+		visited++
+		return false
+	})
+	require.Equal(t, 1, visited)
+	require.Empty(t, mapper.PruneSpentUTXOs(ctx, start.Add(90*time.Minute)))
+}
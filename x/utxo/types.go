@@ -1,18 +1,23 @@
 package utxo
 
 import (
+	"time"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/tendermint/go-amino"
 )
 
 // UTXO is a standard unspent transaction output
-// When spent, it becomes invalid and spender keys are filled in
+// When spent, it becomes invalid and is moved to the history as a SpentUTXO
 type UTXO struct {
 	Address  []byte
 	Amount   uint64
 	Denom    string
 	Valid    bool
 	Position Position
+
+	// Exited is set when the UTXO was invalidated by an exit on the rootchain rather than a spend
+	Exited bool
 }
 
 func NewUTXO(owner []byte, amount uint64, denom string, position Position) UTXO {
@@ -25,6 +30,26 @@ func NewUTXO(owner []byte, amount uint64, denom string, position Position) UTXO
 	}
 }
 
+// SpentUTXO is a UTXO kept in the history after it was spent, along with the transaction
+// spending it, which is needed to challenge exits of the UTXO
+type SpentUTXO struct {
+	UTXO    UTXO
+	Spender Spender
+	SpentAt time.Time
+}
+
+// Spender identifies the input of the transaction that spent a UTXO
+type Spender struct {
+	// Position of the spending transaction, the position of its first output
+	Position Position
+
+	// Tendermint hash of the spending transaction bytes
+	TxHash []byte
+
+	// Index of the spent UTXO among the inputs of the spending transaction
+	InputIndex uint8
+}
+
 func (utxo UTXO) StoreKey(cdc *amino.Codec) []byte {
 	encPos := cdc.MustMarshalBinaryBare(utxo.Position)
 	return append(utxo.Address, encPos...)